@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// LoadABI reads a contract ABI from a file holding either the bare ABI array
+// or a Hardhat/Foundry artifact with an "abi" field
+func LoadABI(path string) (*abi.ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, fmt.Errorf("failed to parse ABI artifact: %w", err)
+		}
+		if artifact.ABI == nil {
+			return nil, fmt.Errorf("%s has no abi field", path)
+		}
+		data = artifact.ABI
+	}
+
+	parsed, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+	return &parsed, nil
+}
+
+// PackCall encodes a call to method with arguments given as command-line strings
+func PackCall(contract *abi.ABI, method string, args []string) ([]byte, error) {
+	m, ok := contract.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in ABI (available: %s)", method, methodNames(contract))
+	}
+	if len(args) != len(m.Inputs) {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", m.Sig, len(m.Inputs), len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := parseABIArg(m.Inputs[i].Type, arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, m.Inputs[i].Type, err)
+		}
+		values[i] = v
+	}
+
+	return contract.Pack(method, values...)
+}
+
+// parseABIArg converts a string into the Go value the ABI encoder expects for t.
+// Arrays are written as JSON arrays, e.g. ["0x..","0x.."].
+func parseABIArg(t abi.Type, s string) (interface{}, error) {
+	switch t.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		return common.HexToAddress(s), nil
+
+	case abi.BoolTy:
+		return strconv.ParseBool(s)
+
+	case abi.StringTy:
+		return s, nil
+
+	case abi.BytesTy:
+		return hexutil.Decode(s)
+
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("want %d bytes, got %d", t.Size, len(b))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v.Interface(), nil
+
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		if t.T == abi.UintTy && n.Sign() < 0 {
+			return nil, fmt.Errorf("negative value for unsigned type")
+		}
+		if t.Size > 64 {
+			return n, nil
+		}
+		// Sizes up to 64 bits must be passed as the matching Go integer type
+		v := reflect.New(t.GetType()).Elem()
+		if t.T == abi.UintTy {
+			if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+				return nil, fmt.Errorf("%s overflows %s", s, t)
+			}
+			v.SetUint(n.Uint64())
+		} else {
+			if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+				return nil, fmt.Errorf("%s overflows %s", s, t)
+			}
+			v.SetInt(n.Int64())
+		}
+		return v.Interface(), nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(s), &items); err != nil {
+			return nil, fmt.Errorf("want a JSON array: %w", err)
+		}
+		if t.T == abi.ArrayTy && len(items) != t.Size {
+			return nil, fmt.Errorf("want %d elements, got %d", t.Size, len(items))
+		}
+
+		var v reflect.Value
+		if t.T == abi.ArrayTy {
+			v = reflect.New(t.GetType()).Elem()
+		} else {
+			v = reflect.MakeSlice(t.GetType(), len(items), len(items))
+		}
+		for i, item := range items {
+			// Elements may be JSON strings or bare literals such as numbers
+			elem := string(item)
+			var str string
+			if json.Unmarshal(item, &str) == nil {
+				elem = str
+			}
+			ev, err := parseABIArg(*t.Elem, elem)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			v.Index(i).Set(reflect.ValueOf(ev))
+		}
+		return v.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported argument type %s", t)
+}
+
+// UnpackResult decodes the return data of method into named output fields
+func UnpackResult(contract *abi.ABI, method string, data []byte) (Record, error) {
+	m, ok := contract.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in ABI", method)
+	}
+	values, err := m.Outputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+
+	rec := make(Record, len(values))
+	for i, v := range values {
+		name := m.Outputs[i].Name
+		if name == "" {
+			name = fmt.Sprintf("output%d", i)
+		}
+		rec[i] = Field{name, displayABIValue(v)}
+	}
+	return rec, nil
+}
+
+// displayABIValue converts decoded values into forms that print and
+// marshal cleanly: big integers as decimal strings and bytes as hex
+func displayABIValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = displayABIValue(rv.Index(i).Interface())
+		}
+		return out
+	}
+	return v
+}
+
+// CallContract runs eth_call with calldata against to at block (nil for latest)
+func (r *RPCClient) CallContract(ctx context.Context, from, to common.Address, data []byte, block *big.Int) ([]byte, error) {
+	out, err := r.client.CallContract(ctx, ethereum.CallMsg{From: from, To: &to, Data: data}, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call contract: %w", err)
+	}
+	return out, nil
+}
+
+// methodNames lists ABI methods for error messages
+func methodNames(contract *abi.ABI) string {
+	names := make([]string, 0, len(contract.Methods))
+	for name := range contract.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
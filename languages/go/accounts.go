@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultHDBasePath is the BIP-44 Ethereum path; account indexes are appended to it
+const DefaultHDBasePath = "m/44'/60'/0'/0"
+
+// HDAccount is one key derived from a mnemonic
+type HDAccount struct {
+	Path       string
+	Address    common.Address
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// MnemonicSeed turns a BIP-39 mnemonic and optional passphrase into a seed.
+// The words are not checked against a wordlist, only counted.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("mnemonic has %d words, want 12, 15, 18, 21, or 24", len(words))
+	}
+
+	normalized := strings.ToLower(strings.Join(words, " "))
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// DeriveHDAccounts derives count consecutive accounts below basePath
+func DeriveHDAccounts(mnemonic, passphrase, basePath string, start, count int) ([]HDAccount, error) {
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	base, err := accounts.ParseDerivationPath(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %w", err)
+	}
+
+	out := make([]HDAccount, 0, count)
+	for i := start; i < start+count; i++ {
+		path := append(accounts.DerivationPath{}, base...)
+		path = append(path, uint32(i))
+
+		key, err := deriveBIP32(seed, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+		}
+		out = append(out, HDAccount{
+			Path:       path.String(),
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: key,
+		})
+	}
+
+	return out, nil
+}
+
+// seedPoolDomain separates seed pool keys from any other use of the seed
+const seedPoolDomain = "megaeth-rpc-tester/seed-pool/v1"
+
+// DeriveSeedAccounts derives count accounts from a run seed, starting at
+// index start. Account i's private key is
+//
+//	keccak256(seedPoolDomain || seed || uint64be(i) || uint32be(attempt))
+//
+// taking the first attempt, counting from 0, whose digest is a valid
+// secp256k1 scalar (non-zero and below the curve order). Any worker given
+// the same seed computes the same key for the same index, so workers that
+// take disjoint index ranges get disjoint accounts without sharing key files.
+func DeriveSeedAccounts(seed string, start, count int) ([]HDAccount, error) {
+	if seed == "" {
+		return nil, fmt.Errorf("seed is empty")
+	}
+	if start < 0 || count < 0 {
+		return nil, fmt.Errorf("invalid account range %d+%d", start, count)
+	}
+
+	n := crypto.S256().Params().N
+	out := make([]HDAccount, 0, count)
+	for i := start; i < start+count; i++ {
+		prefix := binary.BigEndian.AppendUint64([]byte(seedPoolDomain+seed), uint64(i))
+		var key *ecdsa.PrivateKey
+		for attempt := uint32(0); key == nil; attempt++ {
+			digest := crypto.Keccak256(binary.BigEndian.AppendUint32(prefix, attempt))
+			if k := new(big.Int).SetBytes(digest); k.Sign() > 0 && k.Cmp(n) < 0 {
+				var err error
+				if key, err = crypto.ToECDSA(digest); err != nil {
+					return nil, fmt.Errorf("failed to derive seed account %d: %w", i, err)
+				}
+			}
+		}
+		out = append(out, HDAccount{
+			Path:       fmt.Sprintf("seed[%d]", i),
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: key,
+		})
+	}
+
+	return out, nil
+}
+
+// deriveBIP32 walks a BIP-32 path from the master key of seed
+func deriveBIP32(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		var data []byte
+		if index >= 0x80000000 {
+			// Hardened children commit to the private key
+			data = append([]byte{0}, common.LeftPadBytes(key.Bytes(), 32)...)
+		} else {
+			priv, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&priv.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		chainCode = sum[32:]
+	}
+
+	return crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+}
+
+// AddressInfo describes how an address string was written
+type AddressInfo struct {
+	Input    string
+	Address  common.Address
+	Checksum string
+	// ChecksumValid is false when the input mixes case but is not EIP-55 encoded
+	ChecksumValid bool
+}
+
+// InspectAddress parses an address and checks its EIP-55 checksum
+func InspectAddress(input string) (*AddressInfo, error) {
+	if !common.IsHexAddress(input) {
+		return nil, fmt.Errorf("invalid address %q", input)
+	}
+
+	address := common.HexToAddress(input)
+	info := &AddressInfo{Input: input, Address: address, Checksum: address.Hex()}
+
+	hex := strings.TrimPrefix(strings.TrimPrefix(input, "0x"), "0X")
+	mixed := strings.ToLower(hex) != hex && strings.ToUpper(hex) != hex
+	info.ChecksumValid = !mixed || "0x"+hex == info.Checksum
+
+	return info, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testMnemonic is the well-known development mnemonic used by hardhat and anvil
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDeriveHDAccounts(t *testing.T) {
+	accounts, err := DeriveHDAccounts(testMnemonic, "", DefaultHDBasePath, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		path    string
+		address string
+	}{
+		{"m/44'/60'/0'/0/0", "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{"m/44'/60'/0'/0/1", "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{"m/44'/60'/0'/0/2", "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"},
+	}
+	if len(accounts) != len(want) {
+		t.Fatalf("got %d accounts, want %d", len(accounts), len(want))
+	}
+	for i, w := range want {
+		if accounts[i].Path != w.path {
+			t.Errorf("account %d: path %s, want %s", i, accounts[i].Path, w.path)
+		}
+		if accounts[i].Address != common.HexToAddress(w.address) {
+			t.Errorf("account %d: address %s, want %s", i, accounts[i].Address.Hex(), w.address)
+		}
+		if crypto.PubkeyToAddress(accounts[i].PrivateKey.PublicKey) != accounts[i].Address {
+			t.Errorf("account %d: private key does not match address", i)
+		}
+	}
+
+	key := common.Bytes2Hex(crypto.FromECDSA(accounts[0].PrivateKey))
+	if key != "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80" {
+		t.Errorf("account 0: private key %s", key)
+	}
+}
+
+func TestDeriveHDAccountsOffset(t *testing.T) {
+	all, err := DeriveHDAccounts(testMnemonic, "", DefaultHDBasePath, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tail, err := DeriveHDAccounts(testMnemonic, "", DefaultHDBasePath, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, account := range tail {
+		if account.Address != all[2+i].Address || account.Path != all[2+i].Path {
+			t.Errorf("start 2, account %d: got %s at %s, want %s at %s", i, account.Address.Hex(), account.Path, all[2+i].Address.Hex(), all[2+i].Path)
+		}
+	}
+}
+
+func TestDeriveHDAccountsPassphrase(t *testing.T) {
+	plain, err := DeriveHDAccounts(testMnemonic, "", DefaultHDBasePath, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protected, err := DeriveHDAccounts(testMnemonic, "secret", DefaultHDBasePath, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain[0].Address == protected[0].Address {
+		t.Error("passphrase did not change the derived account")
+	}
+}
+
+func TestDeriveHDAccountsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+		path     string
+	}{
+		{"short mnemonic", "test test test", DefaultHDBasePath},
+		{"bad path", testMnemonic, "m/44'/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DeriveHDAccounts(tt.mnemonic, "", tt.path, 0, 1); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDeriveSeedAccounts(t *testing.T) {
+	first, err := DeriveSeedAccounts("run-1", 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := DeriveSeedAccounts("run-1", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, account := range again {
+		if account.Address != first[2+i].Address {
+			t.Errorf("index %d differs between runs", 2+i)
+		}
+		if account.Path != first[2+i].Path {
+			t.Errorf("index %d: path %s, want %s", 2+i, account.Path, first[2+i].Path)
+		}
+	}
+
+	seen := make(map[common.Address]bool)
+	for _, account := range first {
+		if seen[account.Address] {
+			t.Errorf("duplicate account %s", account.Address.Hex())
+		}
+		seen[account.Address] = true
+	}
+
+	other, err := DeriveSeedAccounts("run-2", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other[0].Address == first[0].Address {
+		t.Error("different seeds derived the same account")
+	}
+
+	if _, err := DeriveSeedAccounts("", 0, 1); err == nil {
+		t.Error("expected an error for an empty seed")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert sink kinds accepted by --alert
+const (
+	AlertSinkWebhook = "webhook"
+	AlertSinkSlack   = "slack"
+	AlertSinkDiscord = "discord"
+)
+
+// alertTimeout bounds each delivery to a sink
+const alertTimeout = 10 * time.Second
+
+// Alert is a threshold breach reported by a monitor or a running scenario
+type Alert struct {
+	// Source is the command or monitor that raised the alert, e.g. "bench"
+	Source    string    `json:"source"`
+	Endpoint  string    `json:"endpoint"`
+	Metric    string    `json:"metric"`
+	Value     string    `json:"value"`
+	Threshold string    `json:"threshold"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// key identifies repeats of the same alert for deduplication
+func (a Alert) key() string {
+	return a.Source + "|" + a.Endpoint + "|" + a.Metric
+}
+
+// text renders the alert for chat sinks
+func (a Alert) text() string {
+	return fmt.Sprintf("[%s] %s on %s: %s", tracerName, a.Source, a.Endpoint, a.Message)
+}
+
+// AlertSink delivers alerts to one destination
+type AlertSink interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+// webhookSink posts alerts as JSON: the Alert itself for generic webhooks, or
+// the message payload Slack and Discord incoming webhooks expect
+type webhookSink struct {
+	kind string
+	url  string
+}
+
+// ParseAlertSink parses a KIND=URL sink spec, where KIND is webhook, slack, or discord
+func ParseAlertSink(spec string) (AlertSink, error) {
+	kind, url, ok := strings.Cut(spec, "=")
+	if !ok || url == "" {
+		return nil, fmt.Errorf("alert sink %q is not in KIND=URL form", spec)
+	}
+	switch kind {
+	case AlertSinkWebhook, AlertSinkSlack, AlertSinkDiscord:
+	default:
+		return nil, fmt.Errorf("unknown alert sink %q (want %s, %s, or %s)", kind, AlertSinkWebhook, AlertSinkSlack, AlertSinkDiscord)
+	}
+	// Incoming webhook URLs embed their credentials
+	RegisterSecret(url)
+	return &webhookSink{kind: kind, url: url}, nil
+}
+
+// Send implements AlertSink
+func (s *webhookSink) Send(ctx context.Context, alert Alert) error {
+	// Messages can quote transport errors that embed endpoint credentials
+	alert.Message = Redact(alert.Message)
+	alert.Endpoint = Redact(alert.Endpoint)
+
+	var payload interface{}
+	switch s.kind {
+	case AlertSinkSlack:
+		payload = map[string]string{"text": alert.text()}
+	case AlertSinkDiscord:
+		payload = map[string]string{"content": alert.text()}
+	default:
+		payload = alert
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s alert: %w", s.kind, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s alert rejected with status %s", s.kind, resp.Status)
+	}
+	return nil
+}
+
+// Alerter sends alerts to its sinks, dropping repeats of an alert that was
+// sent less than cooldown ago. A nil *Alerter ignores every alert, so callers
+// need not check.
+type Alerter struct {
+	sinks    []AlertSink
+	cooldown time.Duration
+	logger   *slog.Logger
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// NewAlerter creates an alerter; delivery failures are logged to logger
+func NewAlerter(sinks []AlertSink, cooldown time.Duration, logger *slog.Logger) *Alerter {
+	return &Alerter{
+		sinks:    sinks,
+		cooldown: cooldown,
+		logger:   logger,
+		sent:     make(map[string]time.Time),
+	}
+}
+
+// Fire logs the alert and sends it to every sink unless the same alert was
+// sent within the cooldown window
+func (a *Alerter) Fire(ctx context.Context, alert Alert) {
+	if a == nil {
+		return
+	}
+	if alert.At.IsZero() {
+		alert.At = time.Now().UTC()
+	}
+
+	a.mu.Lock()
+	last, seen := a.sent[alert.key()]
+	if seen && alert.At.Sub(last) < a.cooldown {
+		a.mu.Unlock()
+		a.logger.Debug("alert suppressed by cooldown", "source", alert.Source, "metric", alert.Metric, "last_sent", last)
+		return
+	}
+	a.sent[alert.key()] = alert.At
+	a.mu.Unlock()
+
+	a.logger.Info("sending alert", "source", alert.Source, "endpoint", alert.Endpoint, "metric", alert.Metric,
+		"value", alert.Value, "threshold", alert.Threshold, "message", alert.Message)
+	for _, sink := range a.sinks {
+		if err := sink.Send(ctx, alert); err != nil {
+			a.logger.Warn("failed to deliver alert", "error", err)
+		}
+	}
+}
+
+// Resolve forgets an alert once its condition clears, so the next breach is
+// sent immediately instead of waiting out the cooldown
+func (a *Alerter) Resolve(source, endpoint, metric string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sent, Alert{Source: source, Endpoint: endpoint, Metric: metric}.key())
+}
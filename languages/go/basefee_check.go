@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BaseFeeParams are the EIP-1559 parameters used to predict the next base fee
+type BaseFeeParams struct {
+	ElasticityMultiplier uint64
+	ChangeDenominator    uint64
+	MinBaseFee           *big.Int
+}
+
+// DefaultBaseFeeParams returns the Ethereum mainnet EIP-1559 parameters
+func DefaultBaseFeeParams() BaseFeeParams {
+	return BaseFeeParams{
+		ElasticityMultiplier: 2,
+		ChangeDenominator:    8,
+		MinBaseFee:           new(big.Int),
+	}
+}
+
+// BaseFeeDeviation describes a block whose baseFeePerGas does not follow the formula
+type BaseFeeDeviation struct {
+	Block    uint64
+	Expected *big.Int
+	Actual   *big.Int
+}
+
+// String formats the deviation for reports
+func (d BaseFeeDeviation) String() string {
+	return fmt.Sprintf("block %d: expected base fee %s, got %s", d.Block, d.Expected, d.Actual)
+}
+
+// CalcBaseFee computes the expected base fee of the child of parent
+func (p BaseFeeParams) CalcBaseFee(parent *types.Header) *big.Int {
+	gasTarget := parent.GasLimit / p.ElasticityMultiplier
+	parentBaseFee := parent.BaseFee
+
+	var next *big.Int
+	switch {
+	case parent.GasUsed == gasTarget || gasTarget == 0:
+		next = new(big.Int).Set(parentBaseFee)
+	case parent.GasUsed > gasTarget:
+		delta := new(big.Int).SetUint64(parent.GasUsed - gasTarget)
+		delta.Mul(delta, parentBaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(gasTarget))
+		delta.Div(delta, new(big.Int).SetUint64(p.ChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetUint64(1)
+		}
+		next = delta.Add(delta, parentBaseFee)
+	default:
+		delta := new(big.Int).SetUint64(gasTarget - parent.GasUsed)
+		delta.Mul(delta, parentBaseFee)
+		delta.Div(delta, new(big.Int).SetUint64(gasTarget))
+		delta.Div(delta, new(big.Int).SetUint64(p.ChangeDenominator))
+		next = new(big.Int).Sub(parentBaseFee, delta)
+		if next.Sign() < 0 {
+			next.SetUint64(0)
+		}
+	}
+
+	if p.MinBaseFee != nil && next.Cmp(p.MinBaseFee) < 0 {
+		next.Set(p.MinBaseFee)
+	}
+
+	return next
+}
+
+// VerifyBaseFees walks headers in [from, to] and checks each block's baseFeePerGas
+// against the value predicted from its parent's gas usage
+func (r *RPCClient) VerifyBaseFees(ctx context.Context, from, to uint64, params BaseFeeParams) ([]BaseFeeDeviation, error) {
+	if from == 0 {
+		from = 1
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+
+	parent, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(from-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header %d: %w", from-1, err)
+	}
+
+	var deviations []BaseFeeDeviation
+	for n := from; n <= to; n++ {
+		header, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return deviations, fmt.Errorf("failed to get header %d: %w", n, err)
+		}
+
+		if parent.BaseFee == nil {
+			// Pre-London parent; nothing to predict from
+			parent = header
+			continue
+		}
+		if header.BaseFee == nil {
+			deviations = append(deviations, BaseFeeDeviation{Block: n, Expected: params.CalcBaseFee(parent), Actual: nil})
+			parent = header
+			continue
+		}
+
+		expected := params.CalcBaseFee(parent)
+		if expected.Cmp(header.BaseFee) != 0 {
+			deviations = append(deviations, BaseFeeDeviation{Block: n, Expected: expected, Actual: header.BaseFee})
+		}
+
+		parent = header
+	}
+
+	return deviations, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBaseFeeConfigParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      BaseFeeConfig
+		elasticity  uint64
+		denominator uint64
+		minBaseFee  uint64
+	}{
+		{"defaults", BaseFeeConfig{}, 2, 8, 0},
+		{"elasticity only", BaseFeeConfig{Elasticity: 6}, 6, 8, 0},
+		{"denominator only", BaseFeeConfig{Denominator: 250}, 2, 250, 0},
+		{"all overrides", BaseFeeConfig{Elasticity: 4, Denominator: 50, MinBaseFee: 1000}, 4, 50, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.config.Params()
+			if params.ElasticityMultiplier != tt.elasticity {
+				t.Errorf("elasticity %d, want %d", params.ElasticityMultiplier, tt.elasticity)
+			}
+			if params.ChangeDenominator != tt.denominator {
+				t.Errorf("denominator %d, want %d", params.ChangeDenominator, tt.denominator)
+			}
+			if params.MinBaseFee.Uint64() != tt.minBaseFee {
+				t.Errorf("min base fee %s, want %d", params.MinBaseFee, tt.minBaseFee)
+			}
+		})
+	}
+}
+
+func TestCalcBaseFee(t *testing.T) {
+	const gwei = 1_000_000_000
+
+	tests := []struct {
+		name     string
+		config   BaseFeeConfig
+		gasLimit uint64
+		gasUsed  uint64
+		baseFee  int64
+		want     int64
+	}{
+		{"at target", BaseFeeConfig{}, 30_000_000, 15_000_000, gwei, gwei},
+		{"full block", BaseFeeConfig{}, 30_000_000, 30_000_000, gwei, gwei + gwei/8},
+		{"empty block", BaseFeeConfig{}, 30_000_000, 0, gwei, gwei - gwei/8},
+		{"minimum increase", BaseFeeConfig{}, 30_000_000, 15_000_001, 7, 8},
+		{"no decrease below one", BaseFeeConfig{}, 30_000_000, 14_999_999, 7, 7},
+		{"floored at minimum", BaseFeeConfig{MinBaseFee: 90}, 30_000_000, 0, 100, 90},
+		{"custom parameters", BaseFeeConfig{Elasticity: 4, Denominator: 250}, 40_000_000, 40_000_000, gwei, gwei + 12_000_000},
+		{"zero gas target", BaseFeeConfig{}, 1, 1, 42, 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := &types.Header{GasLimit: tt.gasLimit, GasUsed: tt.gasUsed, BaseFee: big.NewInt(tt.baseFee)}
+			got := tt.config.Params().CalcBaseFee(parent)
+			if got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("CalcBaseFee = %s, want %d", got, tt.want)
+			}
+			if parent.BaseFee.Int64() != tt.baseFee {
+				t.Errorf("parent base fee modified to %s", parent.BaseFee)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// BatchCheck holds the outcome of a batch ordering and id-matching test
+type BatchCheck struct {
+	Size        int
+	Responses   int
+	OutOfOrder  bool
+	Problems    []string
+	PoisonedIDs []string
+}
+
+// Passed reports whether every request got exactly one correctly-shaped response
+func (c BatchCheck) Passed() bool {
+	return len(c.Problems) == 0 && len(c.PoisonedIDs) == 0
+}
+
+// batchEntry is a single request of a test batch and what it should yield
+type batchEntry struct {
+	ID        json.RawMessage `json:"id"`
+	JSONRPC   string          `json:"jsonrpc"`
+	Method    string          `json:"method"`
+	Params    []interface{}   `json:"params"`
+	wantError bool
+}
+
+// CheckBatchOrdering sends a batch of size requests with shuffled numeric and
+// string ids, every failEvery-th entry being an invalid call, and verifies that
+// each response carries a matching id and that error entries don't poison
+// their valid siblings
+func (r *RPCClient) CheckBatchOrdering(ctx context.Context, size, failEvery int) (*BatchCheck, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("batch size must be positive")
+	}
+
+	ids := rand.Perm(size * 10)[:size]
+	entries := make([]batchEntry, size)
+	for i := range entries {
+		var id json.RawMessage
+		if i%2 == 0 {
+			id = json.RawMessage(strconv.Itoa(ids[i] + 1))
+		} else {
+			id = json.RawMessage(strconv.Quote("req-" + strconv.Itoa(ids[i]+1)))
+		}
+
+		entries[i] = batchEntry{ID: id, JSONRPC: "2.0", Method: "eth_blockNumber", Params: []interface{}{}}
+		if failEvery > 0 && i%failEvery == failEvery-1 {
+			entries[i].Method = "eth_doesNotExist"
+			entries[i].wantError = true
+		}
+	}
+
+	payload, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	status, body, err := r.postJSON(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &BatchCheck{Size: size}
+	var responses []rpcResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		check.Problems = append(check.Problems, fmt.Sprintf("status %d, response is not a batch: %v", status, err))
+		return check, nil
+	}
+	check.Responses = len(responses)
+	if len(responses) != size {
+		check.Problems = append(check.Problems, fmt.Sprintf("sent %d requests, got %d responses", size, len(responses)))
+	}
+
+	byID := make(map[string]int, size)
+	for i, e := range entries {
+		byID[string(e.ID)] = i
+	}
+
+	seen := make(map[string]bool, size)
+	for i, resp := range responses {
+		id := string(resp.ID)
+		idx, ok := byID[id]
+		if !ok {
+			check.Problems = append(check.Problems, fmt.Sprintf("response %d has unknown id %s", i, id))
+			continue
+		}
+		if seen[id] {
+			check.Problems = append(check.Problems, fmt.Sprintf("duplicate response for id %s", id))
+			continue
+		}
+		seen[id] = true
+
+		if idx != i {
+			check.OutOfOrder = true
+		}
+
+		switch {
+		case entries[idx].wantError && resp.Error == nil:
+			check.Problems = append(check.Problems, fmt.Sprintf("id %s: expected error, got result", id))
+		case !entries[idx].wantError && resp.Error != nil:
+			check.PoisonedIDs = append(check.PoisonedIDs, id)
+		case !entries[idx].wantError && resp.Result == nil:
+			check.Problems = append(check.Problems, fmt.Sprintf("id %s: missing result", id))
+		}
+	}
+
+	for _, e := range entries {
+		if !seen[string(e.ID)] {
+			check.Problems = append(check.Problems, fmt.Sprintf("no response for id %s", e.ID))
+		}
+	}
+
+	return check, nil
+}
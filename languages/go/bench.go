@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BenchResult summarizes the latency of repeated calls to one method
+type BenchResult struct {
+	Method     string
+	Requests   int
+	Errors     int
+	FirstError string
+	// Unreachable means the first failure was a connection error or timeout
+	Unreachable bool
+	Duration    time.Duration
+	Throughput  float64
+	Min         time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+}
+
+// Bench issues requests calls of method with the given concurrency and
+// measures the latency distribution of the successful ones
+func (r *RPCClient) Bench(ctx context.Context, method string, params []interface{}, requests, concurrency int) BenchResult {
+	return r.BenchLive(ctx, method, params, requests, concurrency, nil)
+}
+
+// BenchLive is Bench that also reports every call to stats as it happens
+func (r *RPCClient) BenchLive(ctx context.Context, method string, params []interface{}, requests, concurrency int, stats *LiveStats) BenchResult {
+	return summarizeBench(method, r.benchSample(ctx, method, params, requests, concurrency, stats))
+}
+
+// benchSample holds the raw measurements of a bench run, so that several
+// runs can be pooled before their percentiles are computed
+type benchSample struct {
+	calls       int
+	latencies   []time.Duration
+	errors      int
+	firstError  string
+	unreachable bool
+	duration    time.Duration
+}
+
+// benchSample issues requests calls of method with the given concurrency and
+// records the latency of each successful one; stats may be nil
+func (r *RPCClient) benchSample(ctx context.Context, method string, params []interface{}, requests, concurrency int, stats *LiveStats) benchSample {
+	return sampleCalls(ctx, r.logger, r.DisplayURL(), method, requests, concurrency, stats, func(ctx context.Context) error {
+		_, err := r.CallRaw(ctx, method, params...)
+		return err
+	})
+}
+
+// sampleCalls runs call requests times with the given concurrency and records
+// the latency of each successful one, whatever protocol call speaks
+func sampleCalls(ctx context.Context, logger *slog.Logger, endpoint, method string, requests, concurrency int, stats *LiveStats, call func(context.Context) error) benchSample {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, span := startSpan(ctx, "bench "+method,
+		attribute.String("rpc.method", method),
+		attribute.Int("bench.requests", requests),
+		attribute.Int("bench.concurrency", concurrency),
+	)
+	defer span.End()
+	logger.Debug("bench started", "method", method, "endpoint", endpoint, "requests", requests, "concurrency", concurrency)
+
+	var next int64
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sample := benchSample{}
+
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Workers stop early once ctx is done, and a call cut short by
+			// that is not counted, so an interrupted run reports what it measured
+			for atomic.AddInt64(&next, 1) <= int64(requests) && ctx.Err() == nil {
+				stats.begin()
+				callStart := time.Now()
+				err := call(ctx)
+				elapsed := time.Since(callStart)
+				stats.end(elapsed, err)
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+
+				mu.Lock()
+				sample.calls++
+				if err != nil {
+					if sample.errors == 0 {
+						sample.firstError = err.Error()
+						sample.unreachable = isUnreachable(err)
+					}
+					sample.errors++
+				} else {
+					sample.latencies = append(sample.latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	sample.duration = time.Since(start)
+	span.SetAttributes(attribute.Int("bench.errors", sample.errors))
+	logger.Debug("bench finished", "method", method, "endpoint", endpoint, "duration", sample.duration, "errors", sample.errors)
+
+	return sample
+}
+
+// summarizeBench computes throughput and latency percentiles from a sample
+func summarizeBench(method string, sample benchSample) BenchResult {
+	latencies := sample.latencies
+	result := BenchResult{
+		Method:      method,
+		Requests:    sample.calls,
+		Errors:      sample.errors,
+		FirstError:  sample.firstError,
+		Unreachable: sample.unreachable,
+		Duration:    sample.duration,
+	}
+	if result.Duration > 0 {
+		result.Throughput = float64(len(latencies)) / result.Duration.Seconds()
+	}
+	if len(latencies) == 0 {
+		return result
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	result.Min = latencies[0]
+	result.Max = latencies[len(latencies)-1]
+	result.Mean = total / time.Duration(len(latencies))
+	result.P50 = percentile(latencies, 50)
+	result.P95 = percentile(latencies, 95)
+	result.P99 = percentile(latencies, 99)
+
+	return result
+}
+
+// errorRate returns the fraction of the run's requests that failed, or 0
+// when it made none
+func errorRate(result BenchResult) float64 {
+	if result.Requests == 0 {
+		return 0
+	}
+	return float64(result.Errors) / float64(result.Requests)
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := len(sorted) * p / 100
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ChainIssue describes an inconsistency found while walking a block range
+type ChainIssue struct {
+	Block  uint64
+	Kind   string
+	Detail string
+}
+
+// String formats the issue for reports
+func (i ChainIssue) String() string {
+	return fmt.Sprintf("block %d: %s: %s", i.Block, i.Kind, i.Detail)
+}
+
+// blockSummary holds the linkage fields of a block as served by the endpoint.
+// Hash is the endpoint's, not one rebuilt from the header, which would miss
+// header fields newer than go-ethereum.
+type blockSummary struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Timestamp  hexutil.Uint64 `json:"timestamp"`
+	Miner      common.Address `json:"miner"`
+}
+
+// getBlockSummary fetches a block by number or hash and decodes its linkage fields
+func (r *RPCClient) getBlockSummary(ctx context.Context, method string, ref interface{}) (*blockSummary, error) {
+	raw, err := r.CallRaw(ctx, method, ref, false)
+	if err != nil {
+		return nil, err
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	var summary blockSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode block: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// VerifyChainContinuity walks the block range [from, to] and checks parentHash
+// linkage, monotonically increasing numbers, and that each block returns the
+// same data when fetched by number and by hash
+func (r *RPCClient) VerifyChainContinuity(ctx context.Context, from, to uint64) ([]ChainIssue, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+
+	var issues []ChainIssue
+	var prev *blockSummary
+
+	for n := from; n <= to; n++ {
+		byNumber, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(n))
+		if err != nil {
+			return issues, err
+		}
+		if byNumber == nil {
+			issues = append(issues, ChainIssue{Block: n, Kind: "missing", Detail: "block not found by number"})
+			prev = nil
+			continue
+		}
+
+		if uint64(byNumber.Number) != n {
+			issues = append(issues, ChainIssue{
+				Block:  n,
+				Kind:   "number mismatch",
+				Detail: fmt.Sprintf("requested %d, got %d", n, uint64(byNumber.Number)),
+			})
+		}
+
+		if prev != nil {
+			if uint64(byNumber.Number) <= uint64(prev.Number) {
+				issues = append(issues, ChainIssue{
+					Block:  n,
+					Kind:   "non-monotonic",
+					Detail: fmt.Sprintf("number %d follows %d", uint64(byNumber.Number), uint64(prev.Number)),
+				})
+			}
+			if byNumber.ParentHash != prev.Hash {
+				issues = append(issues, ChainIssue{
+					Block:  n,
+					Kind:   "broken link",
+					Detail: fmt.Sprintf("parentHash %s, previous block hash %s", byNumber.ParentHash.Hex(), prev.Hash.Hex()),
+				})
+			}
+		}
+
+		byHash, err := r.getBlockSummary(ctx, "eth_getBlockByHash", byNumber.Hash)
+		if err != nil {
+			return issues, err
+		}
+		switch {
+		case byHash == nil:
+			issues = append(issues, ChainIssue{Block: n, Kind: "missing", Detail: fmt.Sprintf("block %s not found by hash", byNumber.Hash.Hex())})
+		case *byHash != *byNumber:
+			issues = append(issues, ChainIssue{
+				Block:  n,
+				Kind:   "hash lookup mismatch",
+				Detail: fmt.Sprintf("by number %+v, by hash %+v", *byNumber, *byHash),
+			})
+		}
+
+		prev = byNumber
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// wrongChainErrors are the error fragments clients use when rejecting a transaction signed for another chain
+var wrongChainErrors = []string{"chain id", "chainid", "invalid sender", "replay", "invalid signature"}
+
+// ChainIDCheck holds the outcome of the replay-protection and chain ID checks
+type ChainIDCheck struct {
+	ChainID        *big.Int
+	NetVersion     string
+	TxChainIDs     map[string]int
+	UnprotectedTxs int
+	// SkippedTxs counts transactions of types the decoder does not know
+	// that carry no chainId field, such as OP Stack deposits
+	SkippedTxs       int
+	WrongChainErr    string
+	WrongChainTested bool
+	Problems         []string
+}
+
+// Passed reports whether every chain ID source agreed and the wrong-chain transaction was rejected
+func (c ChainIDCheck) Passed() bool {
+	return len(c.Problems) == 0
+}
+
+// CheckChainID verifies that eth_chainId, net_version, and the chain ID recovered
+// from sampled transaction signatures agree. When the client has a signer it
+// also verifies that a transaction signed for the wrong chain is rejected.
+func (r *RPCClient) CheckChainID(ctx context.Context, blocks []uint64) (*ChainIDCheck, error) {
+	chainID, err := r.GetChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &ChainIDCheck{ChainID: chainID, TxChainIDs: make(map[string]int)}
+
+	raw, err := r.CallRaw(ctx, "net_version")
+	if err != nil {
+		check.Problems = append(check.Problems, fmt.Sprintf("net_version failed: %v", err))
+	} else {
+		var version string
+		if err := json.Unmarshal(raw, &version); err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("net_version returned non-string %s", raw))
+		} else {
+			check.NetVersion = version
+			if version != chainID.String() {
+				check.Problems = append(check.Problems, fmt.Sprintf("net_version %s differs from eth_chainId %s", version, chainID))
+			}
+		}
+	}
+
+	for _, n := range blocks {
+		block, err := r.CallRaw(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(n), true)
+		if err != nil {
+			return check, err
+		}
+
+		var decoded struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		if err := json.Unmarshal(block, &decoded); err != nil {
+			return check, fmt.Errorf("failed to decode block %d: %w", n, err)
+		}
+
+		for i, txRaw := range decoded.Transactions {
+			tx := new(types.Transaction)
+			var hash common.Hash
+			var txChainID *big.Int
+			if err := tx.UnmarshalJSON(txRaw); err != nil {
+				if !errors.Is(err, types.ErrTxTypeNotSupported) {
+					check.Problems = append(check.Problems, fmt.Sprintf("block %d tx %d: %v", n, i, err))
+					continue
+				}
+				// Types the decoder does not know, such as 7702, still
+				// name their chain in the chainId field
+				var fields struct {
+					Hash    common.Hash  `json:"hash"`
+					ChainID *hexutil.Big `json:"chainId"`
+				}
+				if err := json.Unmarshal(txRaw, &fields); err != nil {
+					check.Problems = append(check.Problems, fmt.Sprintf("block %d tx %d: %v", n, i, err))
+					continue
+				}
+				if fields.ChainID == nil {
+					check.SkippedTxs++
+					continue
+				}
+				hash, txChainID = fields.Hash, fields.ChainID.ToInt()
+			} else {
+				if !tx.Protected() {
+					check.UnprotectedTxs++
+					continue
+				}
+				hash, txChainID = tx.Hash(), tx.ChainId()
+			}
+			check.TxChainIDs[txChainID.String()]++
+			if txChainID.Cmp(chainID) != 0 {
+				check.Problems = append(check.Problems, fmt.Sprintf("block %d tx %s signed for chain %s", n, hash.Hex(), txChainID))
+			}
+		}
+	}
+
+	if r.signer != nil {
+		if err := r.checkWrongChainRejected(ctx, chainID, check); err != nil {
+			return check, err
+		}
+	}
+
+	return check, nil
+}
+
+// checkWrongChainRejected signs a zero-value self transfer for chainID+1 and verifies the endpoint rejects it
+func (r *RPCClient) checkWrongChainRejected(ctx context.Context, chainID *big.Int, check *ChainIDCheck) error {
+	nonce, err := r.client.PendingNonceAt(ctx, r.address)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	gasPrice, err := r.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	wrongChain := new(big.Int).Add(chainID, big.NewInt(1))
+	tx := types.NewTransaction(nonce, r.address, big.NewInt(0), 21000, gasPrice, nil)
+	signedTx, err := r.signer.SignTx(ctx, tx, wrongChain)
+	if err != nil {
+		return fmt.Errorf("failed to sign a transaction for chain %s with the configured signer: %w", wrongChain, err)
+	}
+
+	check.WrongChainTested = true
+	err = r.client.SendTransaction(ctx, signedTx)
+	if err == nil {
+		check.Problems = append(check.Problems, fmt.Sprintf("transaction signed for chain %s was accepted", wrongChain))
+		return nil
+	}
+
+	check.WrongChainErr = err.Error()
+	lower := strings.ToLower(err.Error())
+	for _, fragment := range wrongChainErrors {
+		if strings.Contains(lower, fragment) {
+			return nil
+		}
+	}
+	check.Problems = append(check.Problems, fmt.Sprintf("wrong-chain transaction rejected with unclear error: %s", err))
+
+	return nil
+}
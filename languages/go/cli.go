@@ -0,0 +1,688 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEndpoint is used when neither a flag nor a config file names an endpoint
+const defaultEndpoint = "https://eth.llamarpc.com"
+
+// defaultAPIKeyHeader carries the API key when no header name is configured
+const defaultAPIKeyHeader = "X-API-Key"
+
+// Environment variables consulted between command-line flags and the config file
+const (
+	envRPCURL       = "RPC_URL"
+	envPrivateKey   = "PRIVATE_KEY"
+	envAPIKey       = "RPC_API_KEY"
+	envAPIKeyHeader = "RPC_API_KEY_HEADER"
+	envHeaders      = "RPC_HEADERS"
+)
+
+// Config is the on-disk configuration read with --config, in YAML or TOML
+type Config struct {
+	Endpoint   string                    `yaml:"endpoint" toml:"endpoint"`
+	PrivateKey string                    `yaml:"private_key" toml:"private_key"`
+	Timeout    time.Duration             `yaml:"timeout" toml:"timeout"`
+	Network    string                    `yaml:"network" toml:"network"`
+	Networks   map[string]NetworkProfile `yaml:"networks" toml:"networks"`
+
+	// CosmosChains are the Cosmos chains the cosmos ibc command checks
+	CosmosChains map[string]CosmosChain `yaml:"cosmos_chains" toml:"cosmos_chains"`
+
+	APIKey       string            `yaml:"api_key" toml:"api_key"`
+	APIKeyHeader string            `yaml:"api_key_header" toml:"api_key_header"`
+	Headers      map[string]string `yaml:"headers" toml:"headers"`
+}
+
+// LoadConfig reads a configuration file, choosing TOML or YAML by extension
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		if _, err := toml.Decode(string(data), &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	default:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	for name, profile := range cfg.Networks {
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", name, err)
+		}
+	}
+	for name, chain := range cfg.CosmosChains {
+		if err := chain.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid cosmos chain %q: %w", name, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// globalOptions holds the flags shared by every subcommand
+type globalOptions struct {
+	endpoint   string
+	privateKey string
+	configPath string
+	network    string
+	timeout    time.Duration
+	headerArgs []string
+	output     string
+	// ledger signs with a Ledger device at ledgerPath instead of --key
+	ledger     bool
+	ledgerPath string
+	// ledgerSigner is opened on first use and shared by every client
+	ledgerSigner *LedgerSigner
+	// nodeAccount signs with one of the node's own accounts, by index or address
+	nodeAccount string
+	// transitKey and transitMount name a Vault transit key to sign with
+	transitKey   string
+	transitMount string
+	// transitSigner is connected on first use and shared by every client
+	transitSigner *TransitSigner
+	// dryRun makes mutating commands simulate transactions instead of sending them
+	dryRun bool
+	// quiet silences stderr and forces JSON output, for scripts
+	quiet bool
+	// otlpEndpoint is the OpenTelemetry collector that receives call traces
+	otlpEndpoint string
+	logLevel     string
+	logFormat    string
+	// debugAddr serves pprof and runtime stats while the command runs
+	debugAddr string
+	// slowThreshold and slowSample configure slow-call logging
+	slowThreshold time.Duration
+	slowSample    float64
+	alertSpecs    []string
+	alertCooldown time.Duration
+	statsdAddr    string
+	statsdPrefix  string
+	statsdFormat  string
+	// metricsAddr serves OpenMetrics latency histograms while the command runs
+	metricsAddr string
+	// scoreboardPath holds endpoint health history; noScoreboard stops recording
+	scoreboardPath string
+	noScoreboard   bool
+
+	// eventLogPath receives every lifecycle event as JSON lines
+	eventLogPath string
+
+	// alerter delivers threshold breaches; nil unless --alert is given
+	alerter *Alerter
+
+	// events carries lifecycle events from commands to the logger, alerter,
+	// event log, and dashboard; it is set by resolve
+	events *EventBus
+
+	// logger writes diagnostics to stderr; it is set by resolve
+	logger *slog.Logger
+
+	// headers are sent on every request, after merging config, env, and flags
+	headers http.Header
+
+	// profile is the selected network, nil when none was chosen
+	profile  *NetworkProfile
+	networks map[string]NetworkProfile
+	// cosmosChains are the configured Cosmos chains, by name
+	cosmosChains map[string]CosmosChain
+
+	// rateLimitPath is a profile written by ratelimit; rateLimit is it loaded
+	rateLimitPath string
+	rateLimit     *RateLimitProfile
+}
+
+// resolve fills options not set on the command line from the environment,
+// the config file, and the selected network profile, in that order
+func (o *globalOptions) resolve(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	// Tracing and metrics must be up before any client is created so calls are instrumented
+	if o.otlpEndpoint != "" || os.Getenv(envOTLPEndpoint) != "" {
+		if err := InitTracing(context.Background(), o.otlpEndpoint); err != nil {
+			return err
+		}
+	}
+	if o.statsdAddr != "" {
+		sink, err := NewStatsD(o.statsdAddr, o.statsdPrefix, o.statsdFormat)
+		if err != nil {
+			return err
+		}
+		metricsSink = sink
+	}
+	if o.metricsAddr != "" {
+		histograms := NewLatencyHistograms()
+		if err := StartMetricsServer(o.metricsAddr, histograms); err != nil {
+			return err
+		}
+		latencyHistograms = histograms
+	}
+	if o.quiet {
+		o.output = OutputJSON
+		cmd.Root().SetErr(io.Discard)
+	}
+	if o.slowSample < 0 || o.slowSample > 1 {
+		return fmt.Errorf("--slow-sample must be between 0 and 1")
+	}
+	logger, err := NewLogger(cmd.ErrOrStderr(), o.logLevel, o.logFormat)
+	if err != nil {
+		return err
+	}
+	o.logger = logger
+	slog.SetDefault(logger)
+	if len(o.alertSpecs) > 0 {
+		sinks := make([]AlertSink, len(o.alertSpecs))
+		for i, spec := range o.alertSpecs {
+			sink, err := ParseAlertSink(spec)
+			if err != nil {
+				return err
+			}
+			sinks[i] = sink
+		}
+		o.alerter = NewAlerter(sinks, o.alertCooldown, logger)
+	}
+	o.events = NewEventBus()
+	o.events.Subscribe(logEvents(logger))
+	if o.alerter != nil {
+		o.events.Subscribe(alertEvents(o.alerter), EventThresholdBreached, EventThresholdCleared)
+	}
+	if o.eventLogPath != "" {
+		eventLog, err := OpenEventLog(o.eventLogPath, logger)
+		if err != nil {
+			return err
+		}
+		o.events.Subscribe(eventLog.Handle)
+	}
+	if o.debugAddr != "" {
+		if err := StartDebugServer(o.debugAddr); err != nil {
+			return err
+		}
+		logger.Info("debug server listening", "pprof", "http://"+o.debugAddr+"/debug/pprof/", "stats", "http://"+o.debugAddr+"/debug/stats")
+	}
+	if err := validateOutputFormat(o.output); err != nil {
+		return err
+	}
+
+	cfg := &Config{}
+
+	if o.configPath != "" {
+		loaded, err := LoadConfig(o.configPath)
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+
+	if !flags.Changed("network") && cfg.Network != "" {
+		o.network = cfg.Network
+	}
+	if !flags.Changed("endpoint") {
+		// An explicit --network outranks an endpoint from the environment or
+		// pinned in the config
+		switch {
+		case flags.Changed("network"):
+		case os.Getenv(envRPCURL) != "":
+			o.endpoint = os.Getenv(envRPCURL)
+		case cfg.Endpoint != "":
+			o.endpoint = cfg.Endpoint
+		}
+	}
+	if !flags.Changed("key") {
+		if key := os.Getenv(envPrivateKey); key != "" {
+			o.privateKey = key
+		} else if cfg.PrivateKey != "" {
+			o.privateKey = cfg.PrivateKey
+		}
+	}
+	if !flags.Changed("timeout") && cfg.Timeout > 0 {
+		o.timeout = cfg.Timeout
+	}
+
+	if o.rateLimitPath != "" {
+		if o.rateLimit, err = LoadRateLimitProfile(o.rateLimitPath); err != nil {
+			return err
+		}
+	}
+
+	o.networks = mergeNetworks(cfg.Networks)
+	o.cosmosChains = cfg.CosmosChains
+	for _, chain := range o.cosmosChains {
+		registerURLSecrets(chain.RPCURL)
+	}
+	if o.network != "" {
+		profile, ok := o.networks[o.network]
+		if !ok {
+			return fmt.Errorf("unknown network %q (available: %s)", o.network, strings.Join(networkNames(o.networks), ", "))
+		}
+		o.profile = &profile
+		if o.endpoint == "" {
+			o.endpoint = profile.RPCURL
+		}
+	}
+
+	if o.endpoint == "" {
+		o.endpoint = defaultEndpoint
+	}
+
+	headers, err := o.resolveHeaders(cfg)
+	if err != nil {
+		return err
+	}
+	o.headers = headers
+
+	RegisterSecret(strings.TrimPrefix(o.privateKey, "0x"))
+	registerURLSecrets(o.endpoint)
+	registerHeaderSecrets(o.headers)
+
+	return nil
+}
+
+// resolveHeaders layers request headers from the config, then the
+// environment, then --header flags, with later sources replacing earlier ones
+func (o *globalOptions) resolveHeaders(cfg *Config) (http.Header, error) {
+	headers := http.Header{}
+
+	for name, value := range cfg.Headers {
+		headers.Set(name, value)
+	}
+
+	apiKey, apiKeyHeader := cfg.APIKey, cfg.APIKeyHeader
+	if v := os.Getenv(envAPIKey); v != "" {
+		apiKey = v
+	}
+	if v := os.Getenv(envAPIKeyHeader); v != "" {
+		apiKeyHeader = v
+	}
+	if apiKey != "" {
+		if apiKeyHeader == "" {
+			apiKeyHeader = defaultAPIKeyHeader
+		}
+		headers.Set(apiKeyHeader, apiKey)
+		// The header may have any name, so the key is registered here
+		RegisterSecret(apiKey)
+	}
+
+	if v := os.Getenv(envHeaders); v != "" {
+		for _, entry := range strings.Split(v, ";") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			name, value, err := parseHeader(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", envHeaders, err)
+			}
+			headers.Set(name, value)
+		}
+	}
+
+	for _, entry := range o.headerArgs {
+		name, value, err := parseHeader(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --header: %w", err)
+		}
+		headers.Set(name, value)
+	}
+
+	return headers, nil
+}
+
+// parseHeader splits a "Name: value" header
+func parseHeader(entry string) (string, string, error) {
+	name, value, ok := strings.Cut(entry, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("header %q is not in Name: value form", entry)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// parseParam reads a command-line RPC parameter, sending JSON literals
+// as-is and anything else as a string
+func parseParam(arg string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(arg), &v); err == nil {
+		return v
+	}
+	return arg
+}
+
+// client connects to the configured endpoint
+func (o *globalOptions) client() (*RPCClient, error) {
+	client, err := NewRPCClientWithHeaders(o.endpoint, o.privateKey, o.headers)
+	if err != nil {
+		return nil, err
+	}
+	o.instrument(client)
+	client.SetProfile(o.profile)
+	if err := o.attachSigner(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// clientFor connects to target, which is a URL or a network profile name,
+// with the configured headers and key; an empty target is the configured endpoint
+func (o *globalOptions) clientFor(target string) (*RPCClient, error) {
+	if target == "" {
+		return o.client()
+	}
+	rpcURL := target
+	profile, named := o.networks[target]
+	if named {
+		rpcURL = profile.RPCURL
+	}
+	registerURLSecrets(rpcURL)
+	client, err := NewRPCClientWithHeaders(rpcURL, o.privateKey, o.headers)
+	if err != nil {
+		return nil, err
+	}
+	o.instrument(client)
+	if named {
+		client.SetProfile(&profile)
+	}
+	if err := o.attachSigner(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// hasSigner reports whether transactions can be signed, with --key,
+// --ledger, --node-account, or --transit-key
+func (o *globalOptions) hasSigner() bool {
+	return o.privateKey != "" || o.ledger || o.nodeAccount != "" || o.transitKey != ""
+}
+
+// signerAddress returns the account of the selected signer
+func (o *globalOptions) signerAddress() (common.Address, error) {
+	if o.transitKey != "" {
+		signer, err := o.openTransit()
+		if err != nil {
+			return common.Address{}, err
+		}
+		return signer.Address(), nil
+	}
+	if o.nodeAccount != "" {
+		client, err := o.client()
+		if err != nil {
+			return common.Address{}, err
+		}
+		defer client.Close()
+		return client.GetAddress(), nil
+	}
+	if o.ledger {
+		signer, err := o.openLedger()
+		if err != nil {
+			return common.Address{}, err
+		}
+		return signer.Address(), nil
+	}
+	return keyAddress(o.privateKey)
+}
+
+// openLedger connects to the Ledger once per run
+func (o *globalOptions) openLedger() (*LedgerSigner, error) {
+	if o.ledgerSigner == nil {
+		signer, err := OpenLedger(o.ledgerPath, false)
+		if err != nil {
+			return nil, err
+		}
+		o.logger.Info("using Ledger", "address", signer.Address().Hex(), "path", signer.Path())
+		o.ledgerSigner = signer
+	}
+	return o.ledgerSigner, nil
+}
+
+// openTransit connects to the Vault transit key once per run
+func (o *globalOptions) openTransit() (*TransitSigner, error) {
+	if o.transitSigner == nil {
+		cfg := TransitConfig{
+			Addr:      os.Getenv(envVaultAddr),
+			Mount:     o.transitMount,
+			Key:       o.transitKey,
+			Namespace: os.Getenv(envVaultNamespace),
+			Token:     os.Getenv(envVaultToken),
+			RoleID:    os.Getenv(envVaultRoleID),
+			SecretID:  os.Getenv(envVaultSecretID),
+		}
+		RegisterSecret(cfg.Token)
+		RegisterSecret(cfg.SecretID)
+		ctx, cancel := o.context()
+		defer cancel()
+		signer, err := NewTransitSigner(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		o.logger.Info("using Vault transit key", "key", o.transitKey, "address", signer.Address().Hex())
+		o.transitSigner = signer
+	}
+	return o.transitSigner, nil
+}
+
+// attachSigner makes a new client sign with the Ledger, a node account, or
+// a Vault transit key when one is selected
+func (o *globalOptions) attachSigner(client *RPCClient) error {
+	selected := 0
+	for _, set := range []bool{o.privateKey != "", o.ledger, o.nodeAccount != "", o.transitKey != ""} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return fmt.Errorf("--key, --ledger, --node-account, and --transit-key are mutually exclusive")
+	}
+
+	if o.transitKey != "" {
+		signer, err := o.openTransit()
+		if err != nil {
+			return err
+		}
+		client.SetSigner(signer)
+		return nil
+	}
+
+	if o.nodeAccount != "" {
+		ctx, cancel := o.context()
+		defer cancel()
+		signer, err := NewNodeSigner(ctx, client, o.nodeAccount)
+		if err != nil {
+			return err
+		}
+		client.SetSigner(signer)
+		return nil
+	}
+	if !o.ledger {
+		return nil
+	}
+	signer, err := o.openLedger()
+	if err != nil {
+		return err
+	}
+	client.SetSigner(signer)
+	return nil
+}
+
+// instrument applies the logging and rate-limit options to a new client
+func (o *globalOptions) instrument(client *RPCClient) {
+	client.SetLogger(o.logger)
+	client.SetSlowLog(o.slowThreshold, o.slowSample)
+	// A profile describes one endpoint's limit, so other endpoints ignore it
+	if o.rateLimit != nil && o.rateLimit.Endpoint == client.DisplayURL() {
+		client.SetRateLimit(o.rateLimit)
+	}
+}
+
+// endpointFilter maps ENDPOINT arguments, each a URL or a network profile
+// name, to the redacted URLs that reports and history files key on
+func (o *globalOptions) endpointFilter(args []string) map[string]bool {
+	wanted := make(map[string]bool, len(args))
+	for _, arg := range args {
+		rpcURL := arg
+		if profile, ok := o.networks[arg]; ok {
+			rpcURL = profile.RPCURL
+		}
+		registerURLSecrets(rpcURL)
+		wanted[RedactURL(rpcURL)] = true
+	}
+	return wanted
+}
+
+// scoreboard returns the path of the endpoint health scoreboard
+func (o *globalOptions) scoreboard() (string, error) {
+	if o.scoreboardPath != "" {
+		return o.scoreboardPath, nil
+	}
+	return defaultScoreboardPath()
+}
+
+// recordHealth adds a sample to the scoreboard. Failing to record is logged
+// rather than failing the run that produced the sample.
+func (o *globalOptions) recordHealth(endpoint string, sample HealthSample) {
+	if o.noScoreboard {
+		return
+	}
+	path, err := o.scoreboard()
+	if err == nil {
+		var board *Scoreboard
+		if board, err = LoadScoreboard(path); err == nil {
+			board.Record(endpoint, sample)
+			err = board.Save(path)
+		}
+	}
+	if err != nil {
+		o.logger.Warn("failed to record endpoint health", "error", err)
+	}
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// With --quiet there is nobody to ask, so the answer is no.
+func confirm(cmd *cobra.Command, question string) bool {
+	if quiet, _ := cmd.Flags().GetBool("quiet"); quiet {
+		return false
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// write renders command results in the format selected with --output
+func (o *globalOptions) write(cmd *cobra.Command, raw []byte, records ...Record) error {
+	return WriteRecords(cmd.OutOrStdout(), o.output, records, raw)
+}
+
+// context returns a context bounded by the configured timeout
+func (o *globalOptions) context() (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), o.timeout)
+}
+
+// newRootCommand builds the megaeth-rpc-tester command tree
+func newRootCommand() *cobra.Command {
+	opts := &globalOptions{}
+
+	root := &cobra.Command{
+		Use:   "megaeth-rpc-tester",
+		Short: "Test, benchmark, and verify Ethereum JSON-RPC endpoints",
+		Long: `megaeth-rpc-tester queries, benchmarks, and checks the conformance of
+Ethereum JSON-RPC endpoints such as MegaETH, geth, and reth.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.resolve(cmd)
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVarP(&opts.endpoint, "endpoint", "e", "", "JSON-RPC endpoint URL (default "+defaultEndpoint+")")
+	flags.StringVarP(&opts.privateKey, "key", "k", "", "hex private key used for signing")
+	flags.StringVarP(&opts.configPath, "config", "c", "", "path to a YAML or TOML config file")
+	flags.StringVar(&opts.network, "network", "", "named network profile to target (see networks)")
+	flags.StringVarP(&opts.output, "output", "o", OutputTable, "output format: "+strings.Join(OutputFormats, "|"))
+	flags.StringArrayVarP(&opts.headerArgs, "header", "H", nil, "extra HTTP header as \"Name: value\" (repeatable)")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "overall timeout for a command (0 disables)")
+	flags.BoolVar(&opts.ledger, "ledger", false, "sign with a Ledger device's Ethereum app instead of --key")
+	flags.StringVar(&opts.ledgerPath, "ledger-path", DefaultLedgerPath, "derivation path of the Ledger account")
+	flags.StringVar(&opts.nodeAccount, "node-account", "", "sign with the node's unlocked account at this eth_accounts index or address (eth_signTransaction)")
+	flags.StringVar(&opts.transitKey, "transit-key", "", "sign with this secp256k1 key in a Vault transit engine ("+envVaultAddr+", and "+envVaultToken+" or "+envVaultRoleID+"/"+envVaultSecretID+")")
+	flags.StringVar(&opts.transitMount, "transit-mount", "transit", "mount path of the Vault transit engine; it must support secp256k1 keys, which stock transit does not")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "build and simulate transactions without signing or sending them")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "print only the JSON result; progress and prompts are suppressed")
+	flags.StringVar(&opts.logLevel, "log-level", "info", "log level: debug|info|warn|error (debug logs every RPC call)")
+	flags.StringVar(&opts.logFormat, "log-format", LogFormatText, "log format on stderr: "+LogFormatText+"|"+LogFormatJSON)
+	flags.DurationVar(&opts.slowThreshold, "slow-threshold", 0, "log calls slower than this at warn level (0 disables)")
+	flags.Float64Var(&opts.slowSample, "slow-sample", 1, "fraction (0-1) of slow calls logged with full request and response bodies")
+	flags.StringArrayVar(&opts.alertSpecs, "alert", nil, "send threshold breaches to KIND=URL, where KIND is webhook, slack, or discord (repeatable)")
+	flags.DurationVar(&opts.alertCooldown, "alert-cooldown", 5*time.Minute, "minimum time between repeats of the same alert")
+	flags.StringVar(&opts.eventLogPath, "events", "", "append run lifecycle events (phases, confirmations, breaches) to this file as JSON lines")
+	flags.StringVar(&opts.scoreboardPath, "scoreboard", "", "endpoint health history file (default ~/"+scoreboardFile+")")
+	flags.BoolVar(&opts.noScoreboard, "no-scoreboard", false, "do not record this run in the endpoint health history")
+	flags.StringVar(&opts.debugAddr, "debug-addr", "", "serve pprof and runtime stats on this address, e.g. 127.0.0.1:6060")
+	flags.StringVar(&opts.statsdAddr, "statsd", "", "send per-method call counts and timings to this StatsD agent, e.g. 127.0.0.1:8125")
+	flags.StringVar(&opts.statsdPrefix, "statsd-prefix", "rpc_tester", "prefix for StatsD metric names")
+	flags.StringVar(&opts.statsdFormat, "statsd-format", StatsDFormatDog, "StatsD dialect: "+StatsDFormatDog+" (tags) or "+StatsDFormatPlain+" (dimensions in names)")
+	flags.StringVar(&opts.metricsAddr, "metrics-addr", "", "serve OpenMetrics call latency histograms, with trace exemplars, at /metrics on this address")
+	flags.StringVar(&opts.rateLimitPath, "rate-limit-profile", "", "retry calls the endpoint rate limits, as the profile written by ratelimit --save describes")
+	flags.StringVar(&opts.otlpEndpoint, "otlp-endpoint", "", "export a span per RPC call to this OTLP/HTTP collector, e.g. http://localhost:4318 (or set "+envOTLPEndpoint+")")
+
+	root.AddCommand(
+		newQueryCommand(opts),
+		newSendCommand(opts),
+		newBenchCommand(opts),
+		newConformanceCommand(opts),
+		newWatchCommand(opts),
+		newNetworksCommand(opts),
+		newReplCommand(opts),
+		newAccountCommand(opts),
+		newCallCommand(opts),
+		newCompareCommand(opts),
+		newProxyCommand(opts),
+		newDecodeCommand(opts),
+		newRunCommand(opts),
+		newServeCommand(opts),
+		newFundCommand(opts),
+		newSweepCommand(opts),
+		newStatusCommand(opts),
+		newMonitorCommand(opts),
+		newSoakCommand(opts),
+		newRateLimitCommand(opts),
+		newFinalityCommand(opts),
+		newCosmosCommand(opts),
+	)
+
+	return root
+}
+
+func main() {
+	err := newRootCommand().Execute()
+	ShutdownTracing()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", Redact(err.Error()))
+		os.Exit(ExitCode(err))
+	}
+}
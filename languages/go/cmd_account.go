@@ -0,0 +1,562 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+// Environment variables that keep secrets out of shell history
+const (
+	envMnemonic         = "MNEMONIC"
+	envKeystorePassword = "KEYSTORE_PASSWORD"
+	envRunSeed          = "RUN_SEED"
+)
+
+// newAccountCommand builds the account subcommand for key management
+func newAccountCommand(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "account",
+		Short: "Generate, derive, inspect, and store keys",
+	}
+
+	cmd.AddCommand(
+		newAccountNewCommand(opts),
+		newAccountDeriveCommand(opts),
+		newAccountPoolCommand(opts),
+		newAccountShowCommand(opts),
+		newAccountInspectCommand(opts),
+		newAccountExportCommand(opts),
+		newAccountImportCommand(opts),
+		newAccountVaultCommand(opts),
+		newAccountLedgerCommand(opts),
+	)
+
+	return cmd
+}
+
+// newAccountNewCommand generates fresh random keys
+func newAccountNewCommand(opts *globalOptions) *cobra.Command {
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "new",
+		Short: "Generate random private keys",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records := make([]Record, 0, count)
+			for i := 0; i < count; i++ {
+				key, err := crypto.GenerateKey()
+				if err != nil {
+					return fmt.Errorf("failed to generate key: %w", err)
+				}
+				records = append(records, Record{
+					{"address", crypto.PubkeyToAddress(key.PublicKey).Hex()},
+					{"private_key", hex.EncodeToString(crypto.FromECDSA(key))},
+				})
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	cmd.Flags().IntVarP(&count, "count", "n", 1, "number of keys to generate")
+
+	return cmd
+}
+
+// newAccountDeriveCommand derives HD accounts from a mnemonic
+func newAccountDeriveCommand(opts *globalOptions) *cobra.Command {
+	var mnemonic, passphrase, path string
+	var start, count int
+	var showKeys bool
+
+	cmd := &cobra.Command{
+		Use:   "derive",
+		Short: "Derive HD accounts from a BIP-39 mnemonic",
+		Long: `Derive accounts from a BIP-39 mnemonic along a BIP-44 path. The mnemonic is read
+from --mnemonic or the ` + envMnemonic + ` environment variable.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if mnemonic == "" {
+				mnemonic = os.Getenv(envMnemonic)
+			}
+			if mnemonic == "" {
+				return fmt.Errorf("a mnemonic is required; pass --mnemonic or set %s", envMnemonic)
+			}
+			RegisterSecret(mnemonic)
+
+			derived, err := DeriveHDAccounts(mnemonic, passphrase, path, start, count)
+			if err != nil {
+				return err
+			}
+
+			records := make([]Record, 0, len(derived))
+			for _, account := range derived {
+				rec := Record{
+					{"path", account.Path},
+					{"address", account.Address.Hex()},
+				}
+				if showKeys {
+					rec = append(rec, Field{"private_key", hex.EncodeToString(crypto.FromECDSA(account.PrivateKey))})
+				}
+				records = append(records, rec)
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&mnemonic, "mnemonic", "", "BIP-39 mnemonic (prefer the "+envMnemonic+" environment variable)")
+	flags.StringVar(&passphrase, "passphrase", "", "optional BIP-39 passphrase")
+	flags.StringVar(&path, "path", DefaultHDBasePath, "base derivation path; the account index is appended")
+	flags.IntVar(&start, "start", 0, "first account index")
+	flags.IntVarP(&count, "count", "n", 5, "number of accounts to derive")
+	flags.BoolVar(&showKeys, "show-keys", false, "also print the private keys")
+
+	return cmd
+}
+
+// newAccountPoolCommand derives a worker's accounts from a run seed
+func newAccountPoolCommand(opts *globalOptions) *cobra.Command {
+	var seed string
+	var start, count, worker int
+	var showKeys bool
+
+	cmd := &cobra.Command{
+		Use:   "pool",
+		Short: "Derive a reproducible account pool from a run seed",
+		Long: `Derive accounts from a run seed, so distributed workers compute the same pool
+without shipping key files. Account i's key is the first valid secp256k1 scalar
+among keccak256("` + seedPoolDomain + `" || seed || uint64be(i) || uint32be(attempt))
+for attempt = 0, 1, .... Worker W takes indexes start+W*count up to
+start+(W+1)*count, disjoint from every other worker's. The seed is read from
+--seed or the ` + envRunSeed + ` environment variable; fund and sweep accept the same
+--seed and --worker flags.
+
+  RUN_SEED=run-42 megaeth-rpc-tester account pool --worker 3 --count 100`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if seed == "" {
+				seed = os.Getenv(envRunSeed)
+			}
+			if seed == "" {
+				return fmt.Errorf("a seed is required; pass --seed or set %s", envRunSeed)
+			}
+			if worker < 0 {
+				return fmt.Errorf("invalid --worker %d", worker)
+			}
+			RegisterSecret(seed)
+
+			derived, err := DeriveSeedAccounts(seed, start+worker*count, count)
+			if err != nil {
+				return err
+			}
+
+			records := make([]Record, 0, len(derived))
+			for _, account := range derived {
+				rec := Record{
+					{"path", account.Path},
+					{"address", account.Address.Hex()},
+				}
+				if showKeys {
+					rec = append(rec, Field{"private_key", hex.EncodeToString(crypto.FromECDSA(account.PrivateKey))})
+				}
+				records = append(records, rec)
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&seed, "seed", "", "run seed (prefer the "+envRunSeed+" environment variable)")
+	flags.IntVar(&start, "start", 0, "first account index")
+	flags.IntVarP(&count, "count", "n", 5, "number of accounts per worker")
+	flags.IntVar(&worker, "worker", 0, "worker index; selects the worker's own block of --count accounts")
+	flags.BoolVar(&showKeys, "show-keys", false, "also print the private keys")
+
+	return cmd
+}
+
+// newAccountShowCommand prints the checksummed form of an address or of --key
+func newAccountShowCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [ADDRESS]",
+		Short: "Show the checksummed address and validate EIP-55 casing",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input := ""
+			if len(args) == 1 {
+				input = args[0]
+			} else {
+				address, err := keyAddress(opts.privateKey)
+				if err != nil {
+					return err
+				}
+				input = address.Hex()
+			}
+
+			info, err := InspectAddress(input)
+			if err != nil {
+				return err
+			}
+			if err := opts.write(cmd, nil, Record{
+				{"input", info.Input},
+				{"checksum_address", info.Checksum},
+				{"lowercase", strings.ToLower(info.Checksum)},
+				{"checksum_valid", info.ChecksumValid},
+			}); err != nil {
+				return err
+			}
+			if !info.ChecksumValid {
+				return fmt.Errorf("%s has an invalid EIP-55 checksum", input)
+			}
+			return nil
+		},
+	}
+}
+
+// newAccountInspectCommand reports balances and nonces across networks
+func newAccountInspectCommand(opts *globalOptions) *cobra.Command {
+	var networks []string
+
+	cmd := &cobra.Command{
+		Use:   "inspect [ADDRESS]",
+		Short: "Show the balance and nonce of an address on each network",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var address common.Address
+			if len(args) == 1 {
+				if !common.IsHexAddress(args[0]) {
+					return fmt.Errorf("invalid address %q", args[0])
+				}
+				address = common.HexToAddress(args[0])
+			} else {
+				var err error
+				if address, err = keyAddress(opts.privateKey); err != nil {
+					return err
+				}
+			}
+
+			if len(networks) == 0 {
+				networks = networkNames(opts.networks)
+			}
+			for _, name := range networks {
+				if _, ok := opts.networks[name]; !ok {
+					return fmt.Errorf("unknown network %q", name)
+				}
+			}
+
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			records := make([]Record, len(networks))
+			var wg sync.WaitGroup
+			for i, name := range networks {
+				wg.Add(1)
+				go func(i int, name string) {
+					defer wg.Done()
+					records[i] = inspectOnNetwork(ctx, opts, name, address)
+				}(i, name)
+			}
+			wg.Wait()
+
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&networks, "networks", nil, "networks to query (default all known profiles)")
+
+	return cmd
+}
+
+// inspectOnNetwork fetches the balance and nonce of address on one network
+func inspectOnNetwork(ctx context.Context, opts *globalOptions, name string, address common.Address) Record {
+	rec := Record{{"network", name}, {"address", address.Hex()}}
+	fail := func(err error) Record {
+		return append(rec, Field{"balance_wei", ""}, Field{"nonce", ""}, Field{"error", Redact(err.Error())})
+	}
+
+	client, err := NewRPCClientWithHeaders(opts.networks[name].RPCURL, "", opts.headers)
+	if err != nil {
+		return fail(err)
+	}
+	defer client.Close()
+
+	balance, err := client.GetBalance(ctx, address)
+	if err != nil {
+		return fail(err)
+	}
+	nonce, err := client.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get nonce: %w", err))
+	}
+
+	return append(rec, Field{"balance_wei", balance.String()}, Field{"nonce", nonce}, Field{"error", ""})
+}
+
+// newAccountExportCommand writes --key to an encrypted keystore file
+func newAccountExportCommand(opts *globalOptions) *cobra.Command {
+	var dir, passwordFile string
+	var light bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Encrypt --key into a Web3 Secret Storage keystore file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.privateKey == "" {
+				return fmt.Errorf("a private key is required; pass --key")
+			}
+			key, err := crypto.HexToECDSA(strings.TrimPrefix(opts.privateKey, "0x"))
+			if err != nil {
+				return fmt.Errorf("invalid private key: %w", err)
+			}
+			password, err := readPassword(passwordFile, true)
+			if err != nil {
+				return err
+			}
+
+			scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+			if light {
+				scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+			}
+			account, err := keystore.NewKeyStore(dir, scryptN, scryptP).ImportECDSA(key, password)
+			if err != nil {
+				return fmt.Errorf("failed to write keystore: %w", err)
+			}
+
+			return opts.write(cmd, nil, Record{
+				{"address", account.Address.Hex()},
+				{"file", account.URL.Path},
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&dir, "dir", "keystore", "directory to write the keystore file into")
+	flags.StringVar(&passwordFile, "password-file", "", "file holding the password (default "+envKeystorePassword+" or a prompt)")
+	flags.BoolVar(&light, "light", false, "use light scrypt parameters (faster, weaker)")
+
+	return cmd
+}
+
+// newAccountImportCommand decrypts a keystore file
+func newAccountImportCommand(opts *globalOptions) *cobra.Command {
+	var passwordFile string
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Decrypt a keystore file and print its private key for use with --key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read keystore: %w", err)
+			}
+			password, err := readPassword(passwordFile, false)
+			if err != nil {
+				return err
+			}
+
+			key, err := keystore.DecryptKey(data, password)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt keystore: %w", err)
+			}
+
+			return opts.write(cmd, nil, Record{
+				{"address", key.Address.Hex()},
+				{"private_key", hex.EncodeToString(crypto.FromECDSA(key.PrivateKey))},
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&passwordFile, "password-file", "", "file holding the password (default "+envKeystorePassword+" or a prompt)")
+
+	return cmd
+}
+
+// newAccountVaultCommand builds the vault subcommands for encrypted key pools
+func newAccountVaultCommand(opts *globalOptions) *cobra.Command {
+	var passwordFile string
+	var count int
+	var light bool
+
+	create := &cobra.Command{
+		Use:   "new FILE",
+		Short: "Generate random keys into an encrypted vault, adding to it if it exists",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count <= 0 {
+				return fmt.Errorf("--count must be positive")
+			}
+			// An existing vault is extended, keeping its password and strength
+			_, err := os.Stat(args[0])
+			password, err := readPassword(passwordFile, errors.Is(err, os.ErrNotExist))
+			if err != nil {
+				return err
+			}
+			added, err := GrowVault(args[0], password, count, light)
+			if err != nil {
+				return err
+			}
+			opts.logger.Info("vault saved", "file", args[0], "added", count)
+
+			records := make([]Record, len(added))
+			for i, account := range added {
+				records[i] = Record{
+					{"path", account.Path},
+					{"address", account.Address.Hex()},
+				}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+	create.Flags().IntVarP(&count, "count", "n", 100, "number of keys to generate")
+	create.Flags().BoolVar(&light, "light", false, "use light scrypt parameters for a new vault (faster, weaker)")
+
+	list := &cobra.Command{
+		Use:   "list FILE",
+		Short: "List the addresses in a vault without unlocking it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vault, err := LoadVault(args[0])
+			if err != nil {
+				return err
+			}
+			records := make([]Record, len(vault.Addresses))
+			for i, address := range vault.Addresses {
+				records[i] = Record{
+					{"path", fmt.Sprintf("vault[%d]", i)},
+					{"address", address},
+				}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Keep load-test keys in an encrypted vault file",
+		Long: `Keep the throwaway keys of a load-test account pool in one file encrypted with
+a password (scrypt and XChaCha20-Poly1305) rather than as plaintext. The
+addresses stay readable, so list needs no password. fund and sweep take
+--vault FILE in place of a mnemonic and unlock it once per run. The password
+comes from --password-file, ` + envKeystorePassword + `, or a prompt.
+
+  megaeth-rpc-tester account vault new pool.vault -n 1000
+  megaeth-rpc-tester fund --vault pool.vault --key $PRIVATE_KEY --amount 1000000000000000`,
+	}
+	cmd.PersistentFlags().StringVar(&passwordFile, "password-file", "", "file holding the password (default "+envKeystorePassword+" or a prompt)")
+
+	cmd.AddCommand(create, list)
+	return cmd
+}
+
+// newAccountLedgerCommand lists Ledger addresses to pick a --ledger-path
+func newAccountLedgerCommand(opts *globalOptions) *cobra.Command {
+	var base string
+	var start, count int
+	var confirm bool
+
+	cmd := &cobra.Command{
+		Use:   "ledger [PATH...]",
+		Short: "List the addresses of a Ledger device to choose a --ledger-path",
+		Long: `List addresses from the Ethereum app of a connected Ledger. With PATH arguments
+each path is read; otherwise --count accounts from --start are read, with the
+index substituted for {i} in --path. The default path follows Ledger Live; use
+"m/44'/60'/0'/{i}" for the legacy MEW scheme. --confirm shows each address on
+the device and waits for approval, to check it against the screen.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := args
+			if len(paths) == 0 {
+				if !strings.Contains(base, "{i}") {
+					return fmt.Errorf("--path must contain {i}")
+				}
+				for i := start; i < start+count; i++ {
+					paths = append(paths, strings.ReplaceAll(base, "{i}", fmt.Sprint(i)))
+				}
+			}
+
+			ledger, err := OpenLedger(paths[0], false)
+			if err != nil {
+				return err
+			}
+			defer ledger.Close()
+
+			records := make([]Record, 0, len(paths))
+			for _, path := range paths {
+				address, err := ledger.DeriveAddress(path, confirm)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				records = append(records, Record{
+					{"path", path},
+					{"address", address.Hex()},
+				})
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "path", "m/44'/60'/{i}'/0/0", "derivation path template; {i} is the account index")
+	flags.IntVar(&start, "start", 0, "first account index")
+	flags.IntVarP(&count, "count", "n", 5, "number of accounts")
+	flags.BoolVar(&confirm, "confirm", false, "show each address on the device for approval")
+
+	return cmd
+}
+
+// keyAddress returns the address of a hex private key
+func keyAddress(privateKeyHex string) (common.Address, error) {
+	if privateKeyHex == "" {
+		return common.Address{}, fmt.Errorf("pass an address or --key")
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid private key: %w", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+// readPassword reads a keystore password from a file, the environment, or
+// an interactive prompt, asking twice when confirm is set
+func readPassword(path string, confirm bool) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if password := os.Getenv(envKeystorePassword); password != "" {
+		return password, nil
+	}
+
+	line := liner.NewLiner()
+	defer line.Close()
+	password, err := line.PasswordPrompt("Password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if confirm {
+		again, err := line.PasswordPrompt("Repeat password: ")
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		if again != password {
+			return "", fmt.Errorf("passwords do not match")
+		}
+	}
+	return password, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newBenchCommand builds the bench subcommand
+func newBenchCommand(opts *globalOptions) *cobra.Command {
+	var requests, concurrency, warmup int
+	var tui bool
+	var maxP95, maxP99, duration time.Duration
+	var maxErrorRate float64
+
+	cmd := &cobra.Command{
+		Use:   "bench [METHOD [PARAMS...]]",
+		Short: "Benchmark a single RPC method's latency and throughput",
+		Long: `Fire a fixed number of requests for one method at a given concurrency and
+report throughput and latency percentiles. METHOD defaults to eth_blockNumber.
+Parameters that parse as JSON are sent as-is; anything else is sent as a string:
+
+  megaeth-rpc-tester bench eth_getBalance 0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045 latest -n 1000 -C 50
+
+The run is not bound by --timeout, which limits each request instead.
+--duration stops it after that long even if requests remain, and an
+interrupt stops it early; either way the requests made so far are reported:
+
+  megaeth-rpc-tester bench -n 100000000 -C 50 --duration 2h --tui
+
+--max-p95, --max-p99, and --max-error-rate turn the run into a check that
+exits with status 2 when any limit is exceeded, alerting the --alert sinks.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if requests <= 0 {
+				return fmt.Errorf("--requests must be positive")
+			}
+
+			method := "eth_blockNumber"
+			var params []interface{}
+			if len(args) > 0 {
+				method = args[0]
+				for _, arg := range args[1:] {
+					params = append(params, parseParam(arg))
+				}
+			}
+
+			if duration < 0 {
+				return fmt.Errorf("--duration must not be negative")
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			// The run lasts as long as it takes, up to --duration, so
+			// --timeout bounds each request rather than the whole run
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if duration > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, duration)
+				defer cancel()
+			}
+			call := func(ctx context.Context) error {
+				if opts.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+					defer cancel()
+				}
+				_, err := client.CallRaw(ctx, method, params...)
+				return err
+			}
+
+			source := "bench " + method
+			opts.events.Publish(Event{Kind: EventRunStarted, Source: source, Endpoint: client.DisplayURL()})
+			var stats *LiveStats
+			stopDashboard := func() {}
+			if tui {
+				stats = NewLiveStats(requests)
+				unsubscribe := opts.events.Subscribe(func(e Event) { stats.SetPhase(e.Phase) }, EventPhaseChanged)
+				dashCtx, cancelDashboard := context.WithCancel(context.Background())
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					RunDashboard(dashCtx, cmd.ErrOrStderr(), source+" on "+client.DisplayURL(), stats, client, time.Second)
+				}()
+				stopDashboard = func() {
+					unsubscribe()
+					cancelDashboard()
+					<-done
+				}
+			}
+			if warmup > 0 {
+				opts.events.Publish(Event{Kind: EventPhaseChanged, Source: source, Endpoint: client.DisplayURL(), Phase: "warmup"})
+				sampleCalls(ctx, opts.logger, client.DisplayURL(), method, warmup, concurrency, nil, call)
+			}
+			opts.events.Publish(Event{Kind: EventPhaseChanged, Source: source, Endpoint: client.DisplayURL(), Phase: "measure"})
+			result := summarizeBench(method, sampleCalls(ctx, opts.logger, client.DisplayURL(), method, requests, concurrency, stats, call))
+			stopDashboard()
+			opts.events.Publish(Event{
+				Kind:     EventRunFinished,
+				Source:   source,
+				Endpoint: client.DisplayURL(),
+				Message:  fmt.Sprintf("%d requests, %d errors", result.Requests, result.Errors),
+			})
+			if result.Requests == 0 {
+				return fmt.Errorf("stopped before any request was sent")
+			}
+			opts.recordHealth(client.DisplayURL(), HealthSample{
+				Source:    "bench",
+				Reachable: result.Errors < result.Requests,
+				Requests:  result.Requests,
+				Errors:    result.Errors,
+				P95Ms:     durationMillis(result.P95),
+			})
+			// Limits are checked before anything else so a run where every
+			// request failed still breaches --max-error-rate and alerts
+			var breaches []Alert
+			breach := func(metric, value, threshold string) {
+				breaches = append(breaches, Alert{
+					Source:    source,
+					Endpoint:  client.DisplayURL(),
+					Metric:    metric,
+					Value:     value,
+					Threshold: threshold,
+					Message:   fmt.Sprintf("%s %s exceeds %s", metric, value, threshold),
+				})
+			}
+			if maxP95 > 0 && result.P95 > maxP95 {
+				breach("p95 latency", result.P95.String(), maxP95.String())
+			}
+			if maxP99 > 0 && result.P99 > maxP99 {
+				breach("p99 latency", result.P99.String(), maxP99.String())
+			}
+			if rate := errorRate(result); maxErrorRate >= 0 && rate > maxErrorRate {
+				breach("error rate", fmt.Sprintf("%.4f", rate), fmt.Sprintf("%.4f", maxErrorRate))
+			}
+			messages := make([]string, len(breaches))
+			for i, b := range breaches {
+				b := b
+				opts.events.Publish(Event{Kind: EventThresholdBreached, Source: source, Endpoint: b.Endpoint, Alert: &b, Message: b.Message})
+				messages[i] = b.Message
+			}
+
+			if result.Errors == result.Requests {
+				err := fmt.Errorf("all %d requests failed: %s", result.Requests, result.FirstError)
+				if len(breaches) > 0 {
+					return withExitCode(ExitThresholdBreach, err)
+				}
+				if result.Unreachable {
+					return withExitCode(ExitUnreachable, err)
+				}
+				return err
+			}
+			if err := opts.write(cmd, nil, benchRecord(result, concurrency)); err != nil {
+				return err
+			}
+			if len(breaches) == 0 {
+				return nil
+			}
+			return withExitCode(ExitThresholdBreach, errors.New(strings.Join(messages, "; ")))
+		},
+	}
+
+	cmd.Flags().IntVarP(&requests, "requests", "n", 100, "number of requests to send")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "C", 10, "number of concurrent workers")
+	cmd.Flags().IntVar(&warmup, "warmup", 0, "requests to send before measuring")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "stop after this long even if requests remain (0 runs every request)")
+	cmd.Flags().BoolVar(&tui, "tui", false, "show a live dashboard on stderr while the benchmark runs")
+	cmd.Flags().DurationVar(&maxP95, "max-p95", 0, "fail with status 2 when p95 latency exceeds this (0 disables)")
+	cmd.Flags().DurationVar(&maxP99, "max-p99", 0, "fail with status 2 when p99 latency exceeds this (0 disables)")
+	cmd.Flags().Float64Var(&maxErrorRate, "max-error-rate", -1, "fail with status 2 when the error rate (0-1) exceeds this (negative disables)")
+
+	return cmd
+}
+
+// benchRecord reports a bench result, in milliseconds with microsecond
+// precision so tables stay readable
+func benchRecord(result BenchResult, concurrency int) Record {
+	ms := func(d time.Duration) float64 { return math.Round(float64(d)/float64(time.Microsecond)) / 1000 }
+	return Record{
+		{"method", result.Method},
+		{"requests", result.Requests},
+		{"concurrency", concurrency},
+		{"errors", result.Errors},
+		{"duration_ms", ms(result.Duration)},
+		{"throughput_rps", math.Round(result.Throughput*10) / 10},
+		{"min_ms", ms(result.Min)},
+		{"mean_ms", ms(result.Mean)},
+		{"p50_ms", ms(result.P50)},
+		{"p95_ms", ms(result.P95)},
+		{"p99_ms", ms(result.P99)},
+		{"max_ms", ms(result.Max)},
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// newCallCommand builds the call subcommand for ABI-encoded contract reads
+func newCallCommand(opts *globalOptions) *cobra.Command {
+	var abiPath, to, from, block string
+
+	cmd := &cobra.Command{
+		Use:   "call METHOD [ARGS...]",
+		Short: "Call a contract method with eth_call using an ABI file",
+		Long: `Encode METHOD and ARGS with the ABI in --abi, run eth_call against --to, and
+print the decoded return values. Integers accept decimal or 0x hex and array
+arguments are written as JSON arrays:
+
+  megaeth-rpc-tester call --abi erc20.json --to 0xToken balanceOf 0xHolder`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !common.IsHexAddress(to) {
+				return fmt.Errorf("--to must be a contract address")
+			}
+			var sender common.Address
+			if from != "" {
+				if !common.IsHexAddress(from) {
+					return fmt.Errorf("invalid --from address %q", from)
+				}
+				sender = common.HexToAddress(from)
+			}
+			var blockNumber *big.Int
+			if block != "latest" {
+				n, err := strconv.ParseUint(block, 0, 64)
+				if err != nil {
+					return fmt.Errorf("invalid --block %q", block)
+				}
+				blockNumber = new(big.Int).SetUint64(n)
+			}
+
+			contract, err := LoadABI(abiPath)
+			if err != nil {
+				return err
+			}
+			calldata, err := PackCall(contract, args[0], args[1:])
+			if err != nil {
+				return err
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			result, err := client.CallContract(ctx, sender, common.HexToAddress(to), calldata, blockNumber)
+			if err != nil {
+				return err
+			}
+			rec, err := UnpackResult(contract, args[0], result)
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, rec)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&abiPath, "abi", "", "path to the contract ABI or build artifact")
+	flags.StringVar(&to, "to", "", "contract address")
+	flags.StringVar(&from, "from", "", "caller address (default zero address)")
+	flags.StringVar(&block, "block", "latest", "block number to call at")
+	cmd.MarkFlagRequired("abi")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// newCompareCommand builds the compare subcommand
+func newCompareCommand(opts *globalOptions) *cobra.Command {
+	var scenarioPath, reportPath string
+	var requests, concurrency int
+	var traces []string
+	var logs bool
+
+	cmd := &cobra.Command{
+		Use:   "compare ENDPOINT ENDPOINT [ENDPOINT...]",
+		Short: "Benchmark and diff several endpoints on the same scenario",
+		Long: `Run the same request scenario against two or more endpoints, rank them by
+error rate and p95 latency, and diff every endpoint's responses against the
+first. ENDPOINT is a URL or a network profile name. --scenario reads a YAML
+or JSON list of {method, params} entries; without it a set of common read
+methods at the reference endpoint's head block is used:
+
+  megaeth-rpc-tester compare megaeth-testnet https://rpc.example.org --scenario reads.yaml -n 50
+
+--logs, on by default, also runs the eth_getLogs boundary cases (topic and
+address lists, blockHash filters, reversed ranges) against every endpoint and
+reports each endpoint that answers one differently from the first.
+
+--trace also diffs the debug_traceTransaction call trees of the given
+transactions, frame by frame: call order, gas, and reverts. Every endpoint
+must serve the debug namespace.
+
+  megaeth-rpc-tester compare https://geth.example.org https://reth.example.org --trace 0xTxHash`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if requests <= 0 {
+				return fmt.Errorf("--requests must be positive")
+			}
+			txHashes := make([]common.Hash, len(traces))
+			for i, t := range traces {
+				b, err := hexutil.Decode(t)
+				if err != nil || len(b) != common.HashLength {
+					return fmt.Errorf("invalid --trace transaction hash %q", t)
+				}
+				txHashes[i] = common.BytesToHash(b)
+			}
+
+			clients := make([]*RPCClient, 0, len(args))
+			defer func() {
+				for _, c := range clients {
+					c.Close()
+				}
+			}()
+			for _, arg := range args {
+				client, err := opts.clientFor(arg)
+				if err != nil {
+					return fmt.Errorf("failed to connect to %s: %w", arg, err)
+				}
+				clients = append(clients, client)
+			}
+
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			var scenario []DiffRequest
+			if scenarioPath != "" {
+				loaded, err := LoadScenario(scenarioPath)
+				if err != nil {
+					return err
+				}
+				scenario = loaded
+			} else {
+				head, err := clients[0].GetBlockNumber(ctx)
+				if err != nil {
+					return err
+				}
+				scenario = DefaultDiffRequests(head.Uint64())
+			}
+
+			report, err := CompareEndpoints(ctx, clients, scenario, requests, concurrency)
+			if err != nil {
+				return err
+			}
+			if logs {
+				if err := report.DiffLogs(ctx, clients, 5); err != nil {
+					return err
+				}
+			}
+			if len(txHashes) > 0 {
+				if err := report.DiffTraces(ctx, clients, txHashes); err != nil {
+					return err
+				}
+			}
+			for _, s := range report.Scores {
+				opts.recordHealth(s.Endpoint, HealthSample{
+					Source:    "compare",
+					Reachable: s.Errors < s.Requests,
+					Requests:  s.Requests,
+					Errors:    s.Errors,
+					P95Ms:     s.P95Ms,
+				})
+			}
+
+			records := make([]Record, len(report.Scores))
+			for i, s := range report.Scores {
+				records[i] = Record{
+					{"rank", s.Rank},
+					{"endpoint", s.Endpoint},
+					{"requests", s.Requests},
+					{"errors", s.Errors},
+					{"throughput_rps", s.ThroughputRPS},
+					{"p50_ms", s.P50Ms},
+					{"p95_ms", s.P95Ms},
+					{"p99_ms", s.P99Ms},
+					{"differences", s.Differences},
+				}
+			}
+			if err := opts.write(cmd, nil, records...); err != nil {
+				return err
+			}
+
+			stderr := cmd.ErrOrStderr()
+			for _, client := range clients[1:] {
+				diffs := report.Differences[client.DisplayURL()]
+				if len(diffs) == 0 {
+					continue
+				}
+				fmt.Fprintf(stderr, "%s differs from %s in %d places:\n  %s\n",
+					client.DisplayURL(), report.Reference, len(diffs), strings.Join(diffs, "\n  "))
+			}
+
+			if reportPath != "" {
+				return report.Save(reportPath)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&scenarioPath, "scenario", "", "YAML or JSON file listing the requests to run")
+	flags.IntVarP(&requests, "requests", "n", 20, "number of times to send each scenario request")
+	flags.IntVarP(&concurrency, "concurrency", "C", 5, "number of concurrent workers per endpoint")
+	flags.StringVar(&reportPath, "report", "", "write a JSON report to this path")
+	flags.BoolVar(&logs, "logs", true, "also compare answers to the eth_getLogs boundary cases")
+	flags.StringSliceVar(&traces, "trace", nil, "transaction hashes whose call traces to diff as well")
+
+	return cmd
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newConformanceCommand builds the conformance subcommand
+func newConformanceCommand(opts *globalOptions) *cobra.Command {
+	var filter []string
+	var reportPath string
+	var checkTimeout time.Duration
+	var list bool
+	var daemon bool
+	var interval time.Duration
+	var subset, window int
+	var listen string
+	var goldenDir, recordGolden, golden string
+
+	cmd := &cobra.Command{
+		Use:   "conformance [ENDPOINT...]",
+		Short: "Run the conformance and consistency checks against the endpoint",
+		Long: `Run the conformance suite and print a summary table. --checks selects checks by
+name or group (errors, schema, logs, semantics, consistency, transactions),
+for example --checks schema,errors,proofs,tags. --report writes the full
+results as JSON. Checks that need something the endpoint's network profile
+does not give, such as the WebSocket URL resubscribe needs, are reported as
+skipped. The transactions group sends a zero-value transfer to the signer's
+own address and runs only when a signer is configured.
+
+--daemon keeps running instead: every --interval it runs the next --subset of
+the selected checks against each ENDPOINT (default the global endpoint),
+scores each endpoint over its last --window outcomes, serves the scores as
+Prometheus metrics on --listen, and sends failed checks to the --alert sinks.
+
+--record-golden VERSION saves the endpoint's responses to a pinned set of
+requests at its finalized block as golden-VERSION.json in --golden-dir, and
+runs no checks. --golden VERSION then adds a golden check that replays the set
+and fails on any response that changed, such as after a client upgrade.
+
+  megaeth-rpc-tester conformance --daemon megaeth-testnet https://rpc.example.org --interval 5m --subset 5 --listen :9465
+  megaeth-rpc-tester conformance --record-golden v1.4.0
+  megaeth-rpc-tester conformance --golden v1.4.0 --checks golden`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if recordGolden != "" {
+				if daemon || golden != "" {
+					return fmt.Errorf("--record-golden cannot be combined with --daemon or --golden")
+				}
+				return recordGoldens(cmd, opts, goldenDir, recordGolden)
+			}
+			all := DefaultConformanceChecks()
+			if golden != "" {
+				path := GoldenPath(goldenDir, golden)
+				if _, err := LoadGoldens(path); err != nil {
+					return err
+				}
+				all = append(all, GoldenCheck(path))
+			}
+			checks, err := SelectChecks(all, filter)
+			if err != nil {
+				return err
+			}
+			if daemon {
+				if interval <= 0 {
+					return fmt.Errorf("--interval must be positive")
+				}
+				return runConformanceDaemon(opts, args, checks, checkTimeout, interval, subset, window, listen)
+			}
+			if len(args) > 0 {
+				return fmt.Errorf("endpoints are only accepted with --daemon; use --endpoint or --network")
+			}
+			if list {
+				records := make([]Record, len(checks))
+				for i, c := range checks {
+					records[i] = Record{{"name", c.Name}, {"group", c.Group}}
+				}
+				return opts.write(cmd, nil, records...)
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			// Each check gets its own timeout instead of sharing the global one
+			report := RunConformance(context.Background(), client, checks, checkTimeout)
+			ran := len(report.Results) - report.Skipped
+			opts.recordHealth(client.DisplayURL(), HealthSample{
+				Source:       "conformance",
+				Reachable:    report.Errored < ran,
+				Checks:       ran,
+				ChecksFailed: report.Failed + report.Errored,
+			})
+
+			records := make([]Record, len(report.Results))
+			for i, r := range report.Results {
+				records[i] = Record{
+					{"name", r.Name},
+					{"group", r.Group},
+					{"status", r.Status},
+					{"duration_ms", r.DurationMs},
+					{"detail", r.Detail},
+				}
+			}
+			if err := opts.write(cmd, nil, records...); err != nil {
+				return err
+			}
+			opts.logger.Info("conformance finished", "passed", report.Passed, "failed", report.Failed, "errored", report.Errored, "skipped", report.Skipped)
+
+			if reportPath != "" {
+				if err := report.Save(reportPath); err != nil {
+					return err
+				}
+			}
+			if ran > 0 && report.Unreachable == ran {
+				return withExitCode(ExitUnreachable, fmt.Errorf("%s is unreachable: every check failed to connect", client.DisplayURL()))
+			}
+			if !report.OK() {
+				return withExitCode(ExitCheckFailure, fmt.Errorf("%d of %d checks did not pass", report.Failed+report.Errored, ran))
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&filter, "checks", nil, "checks or groups to run (default all)")
+	flags.StringVar(&reportPath, "report", "", "write a JSON report to this path")
+	flags.DurationVar(&checkTimeout, "check-timeout", 2*time.Minute, "timeout for each check")
+	flags.BoolVar(&list, "list", false, "list the selected checks without running them")
+	flags.BoolVar(&daemon, "daemon", false, "run checks on a schedule until interrupted, serving health scores")
+	flags.DurationVar(&interval, "interval", 5*time.Minute, "time between daemon rounds")
+	flags.IntVar(&subset, "subset", 0, "checks per endpoint in each daemon round, rotating through the selection (default all)")
+	flags.IntVar(&window, "window", 100, "check outcomes each daemon health score covers")
+	flags.StringVar(&listen, "listen", "", "serve the daemon's Prometheus metrics on this address")
+	flags.StringVar(&goldenDir, "golden-dir", "goldens", "directory golden files are kept in")
+	flags.StringVar(&recordGolden, "record-golden", "", "record golden responses under this version instead of running checks")
+	flags.StringVar(&golden, "golden", "", "add a check that replays the golden responses recorded under this version")
+
+	return cmd
+}
+
+// recordGoldens saves the endpoint's responses to the default request set as
+// a golden file under version
+func recordGoldens(cmd *cobra.Command, opts *globalOptions, dir, version string) error {
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	block, err := client.goldenBlock(ctx)
+	if err != nil {
+		return err
+	}
+	requests := DefaultDiffRequests(block)
+	path, err := client.RecordGoldens(ctx, dir, version, requests)
+	if err != nil {
+		return err
+	}
+	opts.logger.Info("recorded goldens", "path", path, "block", block)
+	return opts.write(cmd, nil, Record{
+		{"version", version},
+		{"path", path},
+		{"block", block},
+		{"requests", len(requests)},
+	})
+}
+
+// runConformanceDaemon runs checks against the targets on a schedule until
+// interrupted, alerting on every failed check
+func runConformanceDaemon(opts *globalOptions, targets []string, checks []ConformanceCheck, checkTimeout, interval time.Duration, subset, window int, listen string) error {
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+	clients := make([]*RPCClient, 0, len(targets))
+	defer func() {
+		for _, c := range clients {
+			c.Close()
+		}
+	}()
+	for _, target := range targets {
+		client, err := opts.clientFor(target)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", target, err)
+		}
+		clients = append(clients, client)
+	}
+
+	daemon := NewConformanceDaemon(clients, checks, interval, subset, window)
+	daemon.SetCheckTimeout(checkTimeout)
+	daemon.OnAlert(func(outcome CheckOutcome, score float64) {
+		opts.logger.Warn("conformance check failed", "endpoint", outcome.Endpoint, "check", outcome.Check, "score", score, "detail", Redact(outcome.Detail))
+		alert := &Alert{
+			Source:    "conformance",
+			Endpoint:  outcome.Endpoint,
+			Metric:    "check " + outcome.Check,
+			Value:     "fail",
+			Threshold: "pass",
+			Message:   fmt.Sprintf("%s failed (health score %.2f): %s", outcome.Check, score, Redact(outcome.Detail)),
+			At:        outcome.At,
+		}
+		opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "conformance", Endpoint: outcome.Endpoint, Alert: alert, Message: alert.Message, At: outcome.At})
+	})
+
+	// The daemon runs until interrupted, so the global timeout does not apply
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if listen != "" {
+		go func() {
+			if err := ServeUntilDone(ctx, listen, daemon); err != nil {
+				opts.logger.Error("metrics server stopped", "error", err)
+			}
+		}()
+		opts.logger.Info("serving conformance metrics", "listen", listen)
+	}
+	opts.logger.Info("conformance daemon started", "endpoints", len(clients), "checks", len(checks), "interval", interval)
+
+	if err := daemon.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
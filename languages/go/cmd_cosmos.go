@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// envCometRPCURL sets the default CometBFT endpoint of the cosmos commands
+const envCometRPCURL = "COMET_RPC_URL"
+
+// cosmosOptions are shared by the cosmos subcommands
+type cosmosOptions struct {
+	node string
+}
+
+// client connects to the CometBFT endpoint, instrumented like the Ethereum
+// client
+func (c *cosmosOptions) client(opts *globalOptions) (*CometClient, error) {
+	node := c.node
+	if node == "" {
+		node = os.Getenv(envCometRPCURL)
+	}
+	if node == "" {
+		node = DefaultCometEndpoint
+	}
+	registerURLSecrets(node)
+	client, err := NewCometClient(node, opts.headers)
+	if err != nil {
+		return nil, err
+	}
+	client.SetLogger(opts.logger)
+	client.SetSlowLog(opts.slowThreshold, opts.slowSample)
+	return client, nil
+}
+
+// newCosmosCommand builds the cosmos subcommand for CometBFT endpoints
+func newCosmosCommand(opts *globalOptions) *cobra.Command {
+	copts := &cosmosOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "cosmos",
+		Short: "Query and benchmark Cosmos chains over CometBFT RPC and gRPC",
+		Long: `Query and benchmark the CometBFT RPC that Cosmos SDK nodes serve, usually on
+port 26657. The endpoint comes from --node, the ` + envCometRPCURL + ` environment variable,
+or defaults to ` + DefaultCometEndpoint + `. --header, logging, tracing, and metrics apply
+as they do to Ethereum endpoints.`,
+	}
+	cmd.PersistentFlags().StringVar(&copts.node, "node", "", "CometBFT RPC URL (default $"+envCometRPCURL+" or "+DefaultCometEndpoint+")")
+
+	cmd.AddCommand(
+		newCosmosStatusCommand(opts, copts),
+		newCosmosBlockCommand(opts, copts),
+		newCosmosBlockResultsCommand(opts, copts),
+		newCosmosTxSearchCommand(opts, copts),
+		newCosmosSubscribeCommand(opts, copts),
+		newCosmosBenchCommand(opts, copts),
+		newCosmosGRPCCommand(opts),
+		newCosmosTxCommand(opts, copts),
+		newCosmosIBCCommand(opts),
+	)
+	return cmd
+}
+
+// parseHeightArg parses an optional HEIGHT argument; zero means latest
+func parseHeightArg(args []string) (int64, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	height, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || height <= 0 {
+		return 0, fmt.Errorf("invalid height %q", args[0])
+	}
+	return height, nil
+}
+
+// newCosmosStatusCommand reports the node's identity and sync state
+func newCosmosStatusCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the node's chain, version, and sync state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			status, err := client.Status(ctx)
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, Record{
+				{"network", status.NodeInfo.Network},
+				{"moniker", status.NodeInfo.Moniker},
+				{"version", status.NodeInfo.Version},
+				{"node_id", status.NodeInfo.ID},
+				{"latest_height", status.SyncInfo.LatestBlockHeight},
+				{"latest_time", status.SyncInfo.LatestBlockTime.UTC().Format(time.RFC3339)},
+				{"block_age_s", time.Since(status.SyncInfo.LatestBlockTime).Round(time.Second).Seconds()},
+				{"earliest_height", status.SyncInfo.EarliestBlockHeight},
+				{"catching_up", status.SyncInfo.CatchingUp},
+			})
+		},
+	}
+}
+
+// newCosmosBlockCommand shows a block header and its transaction count
+func newCosmosBlockCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "block [HEIGHT]",
+		Short: "Show a block, the latest by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			height, err := parseHeightArg(args)
+			if err != nil {
+				return err
+			}
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			block, err := client.Block(ctx, height)
+			if err != nil {
+				return err
+			}
+			header := block.Block.Header
+			return opts.write(cmd, nil, Record{
+				{"height", header.Height},
+				{"hash", block.BlockID.Hash},
+				{"chain_id", header.ChainID},
+				{"time", header.Time.UTC().Format(time.RFC3339Nano)},
+				{"proposer", header.ProposerAddress},
+				{"txs", len(block.Block.Data.Txs)},
+			})
+		},
+	}
+}
+
+// newCosmosBlockResultsCommand summarizes the execution results of a block
+func newCosmosBlockResultsCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "block-results [HEIGHT]",
+		Short: "Summarize a block's transaction results, the latest by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			height, err := parseHeightArg(args)
+			if err != nil {
+				return err
+			}
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			results, err := client.BlockResults(ctx, height)
+			if err != nil {
+				return err
+			}
+			var failed int
+			var wanted, used int64
+			for _, r := range results.TxsResults {
+				if r.Code != 0 {
+					failed++
+				}
+				wanted += r.GasWanted
+				used += r.GasUsed
+			}
+			return opts.write(cmd, nil, Record{
+				{"height", results.Height},
+				{"txs", len(results.TxsResults)},
+				{"failed", failed},
+				{"gas_wanted", wanted},
+				{"gas_used", used},
+			})
+		},
+	}
+}
+
+// newCosmosTxSearchCommand lists the transactions matching an event query
+func newCosmosTxSearchCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	var page, perPage int
+	var ascending bool
+
+	cmd := &cobra.Command{
+		Use:   "tx-search QUERY",
+		Short: "Find transactions by event query",
+		Long: `Find transactions whose events match QUERY, for example
+
+  megaeth-rpc-tester cosmos tx-search "transfer.recipient='cosmos1...'" --per-page 10
+
+The node must index transactions (tx_index in config.toml).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if page <= 0 || perPage <= 0 {
+				return fmt.Errorf("--page and --per-page must be positive")
+			}
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			result, err := client.TxSearch(ctx, args[0], page, perPage, ascending)
+			if err != nil {
+				return err
+			}
+			opts.logger.Info("transactions found", "total", result.TotalCount, "page", page)
+
+			records := make([]Record, len(result.Txs))
+			for i, tx := range result.Txs {
+				records[i] = Record{
+					{"hash", tx.Hash},
+					{"height", tx.Height},
+					{"index", tx.Index},
+					{"code", tx.TxResult.Code},
+					{"gas_wanted", tx.TxResult.GasWanted},
+					{"gas_used", tx.TxResult.GasUsed},
+				}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&page, "page", 1, "result page, counting from 1")
+	flags.IntVar(&perPage, "per-page", 30, "results per page (the node caps this at 100)")
+	flags.BoolVar(&ascending, "asc", false, "oldest first instead of newest first")
+
+	return cmd
+}
+
+// newCosmosSubscribeCommand streams events from a WebSocket subscription
+func newCosmosSubscribeCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	var count int
+
+	cmd := &cobra.Command{
+		Use:   "subscribe [QUERY]",
+		Short: "Stream events over WebSocket (new blocks by default)",
+		Long: `Subscribe to the events matching QUERY over the node's /websocket endpoint and
+print one record per event until interrupted or --count events arrive. QUERY
+defaults to new blocks; "tm.event='Tx'" streams transactions. Latency is
+measured from the block time to when the event was received.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := "tm.event='NewBlock'"
+			if len(args) > 0 {
+				query = args[0]
+			}
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+
+			// subscribe runs until interrupted, so the global timeout does not apply
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			stream := NewRecordStream(cmd.OutOrStdout(), opts.output)
+			seen := 0
+			var writeErr error
+			err = client.Subscribe(ctx, query, func(e CometEvent) {
+				height, blockTime := cometEventBlock(e)
+				rec := Record{
+					{"type", strings.TrimPrefix(e.Data.Type, "tendermint/event/")},
+					{"height", height},
+					{"received", time.Now().UTC().Format(time.RFC3339Nano)},
+				}
+				if !blockTime.IsZero() {
+					rec = append(rec, Field{"latency_ms", time.Since(blockTime).Milliseconds()})
+				}
+				if writeErr = stream.Write(rec); writeErr != nil {
+					cancel()
+					return
+				}
+				if seen++; count > 0 && seen >= count {
+					cancel()
+				}
+			})
+			if writeErr != nil {
+				return writeErr
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().IntVarP(&count, "count", "n", 0, "stop after this many events (default until interrupted)")
+	return cmd
+}
+
+// cometEventBlock extracts the height and, for new blocks, the block time of
+// an event; both are zero when the event carries neither
+func cometEventBlock(e CometEvent) (int64, time.Time) {
+	var value struct {
+		Block *struct {
+			Header struct {
+				Height int64     `json:"height,string"`
+				Time   time.Time `json:"time"`
+			} `json:"header"`
+		} `json:"block"`
+		TxResult *struct {
+			Height int64 `json:"height,string"`
+		} `json:"TxResult"`
+	}
+	if json.Unmarshal(e.Data.Value, &value) != nil {
+		return 0, time.Time{}
+	}
+	switch {
+	case value.Block != nil:
+		return value.Block.Header.Height, value.Block.Header.Time
+	case value.TxResult != nil:
+		return value.TxResult.Height, time.Time{}
+	}
+	return 0, time.Time{}
+}
+
+// newCosmosBenchCommand benchmarks one CometBFT method
+func newCosmosBenchCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	var requests, concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "bench [METHOD [NAME=VALUE...]]",
+		Short: "Benchmark a CometBFT RPC method's latency and throughput",
+		Long: `Fire a fixed number of requests for one CometBFT method at a given concurrency
+and report throughput and latency percentiles, as bench does for Ethereum
+methods. METHOD defaults to status. Params are named; true and false are sent
+as booleans and anything else as a string, as CometBFT expects:
+
+  megaeth-rpc-tester cosmos bench block_results height=1000 -n 500 -C 20`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if requests <= 0 {
+				return fmt.Errorf("--requests must be positive")
+			}
+			method := "status"
+			params := map[string]interface{}{}
+			if len(args) > 0 {
+				method = args[0]
+				for _, arg := range args[1:] {
+					name, value, ok := strings.Cut(arg, "=")
+					if !ok || name == "" {
+						return fmt.Errorf("param %q is not in NAME=VALUE form", arg)
+					}
+					params[name] = cometParam(value)
+				}
+			}
+
+			client, err := copts.client(opts)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			source := "cosmos bench " + method
+			opts.events.Publish(Event{Kind: EventRunStarted, Source: source, Endpoint: client.DisplayURL()})
+			result := client.Bench(ctx, method, params, requests, concurrency, nil)
+			opts.events.Publish(Event{
+				Kind:     EventRunFinished,
+				Source:   source,
+				Endpoint: client.DisplayURL(),
+				Message:  fmt.Sprintf("%d requests, %d errors", result.Requests, result.Errors),
+			})
+			opts.recordHealth(client.DisplayURL(), HealthSample{
+				Source:    "cosmos bench",
+				Reachable: result.Errors < result.Requests,
+				Requests:  result.Requests,
+				Errors:    result.Errors,
+				P95Ms:     durationMillis(result.P95),
+			})
+			if result.Errors == result.Requests {
+				return fmt.Errorf("all %d requests failed: %s", result.Requests, result.FirstError)
+			}
+			return opts.write(cmd, nil, benchRecord(result, concurrency))
+		},
+	}
+
+	cmd.Flags().IntVarP(&requests, "requests", "n", 100, "number of requests to send")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "C", 10, "number of concurrent workers")
+
+	return cmd
+}
+
+// cometParam converts a NAME=VALUE value: CometBFT takes integers as
+// strings, so only booleans are sent unquoted
+func cometParam(value string) interface{} {
+	if b, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		return b
+	}
+	return value
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// envCosmosGRPC sets the default gRPC endpoint of the cosmos grpc commands
+const envCosmosGRPC = "COSMOS_GRPC_ADDR"
+
+// cosmosGRPCOptions are shared by the cosmos grpc subcommands
+type cosmosGRPCOptions struct {
+	target      string
+	plaintext   bool
+	retries     int
+	customToken string
+}
+
+// register adds the gRPC endpoint flags to cmd and its subcommands
+func (g *cosmosGRPCOptions) register(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&g.target, "grpc", "", "gRPC endpoint as host:port (default $"+envCosmosGRPC+" or "+DefaultCosmosGRPC+")")
+	flags.BoolVar(&g.plaintext, "plaintext", false, "connect to the gRPC endpoint without TLS")
+	flags.IntVar(&g.retries, "retries", 2, "times to retry a gRPC call the node rejects as unavailable or overloaded")
+	flags.StringVar(&g.customToken, "customtoken-package", DefaultCustomTokenPackage, "protobuf package of the customtoken module")
+}
+
+// client connects to the gRPC endpoint, instrumented like the Ethereum client
+func (g *cosmosGRPCOptions) client(opts *globalOptions) (*CosmosQueryClient, error) {
+	target := g.target
+	if target == "" {
+		target = os.Getenv(envCosmosGRPC)
+	}
+	if target == "" {
+		target = DefaultCosmosGRPC
+	}
+	client, err := DialCosmosGRPC(target, g.plaintext, g.retries, opts.logger)
+	if err != nil {
+		return nil, err
+	}
+	client.SetCustomTokenPackage(g.customToken)
+	return client, nil
+}
+
+// newCosmosGRPCCommand builds the cosmos grpc subcommand for module queries
+func newCosmosGRPCCommand(opts *globalOptions) *cobra.Command {
+	gopts := &cosmosGRPCOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "grpc",
+		Short: "Query Cosmos SDK modules over gRPC",
+		Long: `Query the bank, auth, staking, and customtoken modules over the gRPC endpoint
+that Cosmos SDK nodes serve, usually on port 9090. The endpoint comes from
+--grpc, the ` + envCosmosGRPC + ` environment variable, or defaults to ` + DefaultCosmosGRPC + `.
+Calls are logged, traced, and reported to StatsD and the latency histograms
+under their full method name, such as cosmos.bank.v1beta1.Query/Balance, so
+they can be compared with Ethereum calls to the same chain or another.
+
+The customtoken module is chain-specific: its Token request is assumed to
+carry the denom as field 1, and its responses are printed field by field since
+the tester has no schema for them.`,
+	}
+	gopts.register(cmd)
+
+	cmd.AddCommand(
+		newCosmosBalanceCommand(opts, gopts),
+		newCosmosAccountCommand(opts, gopts),
+		newCosmosValidatorsCommand(opts, gopts),
+		newCosmosPoolCommand(opts, gopts),
+		newCosmosTokenCommand(opts, gopts),
+		newCosmosTokensCommand(opts, gopts),
+		newCosmosGRPCBenchCommand(opts, gopts),
+	)
+	return cmd
+}
+
+// newCosmosBalanceCommand shows one balance or all balances of an account
+func newCosmosBalanceCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "balance ADDRESS [DENOM]",
+		Short: "Show an account's balance of DENOM, or all its balances",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			var coins []Coin
+			if len(args) == 2 {
+				coin, err := client.Balance(ctx, args[0], args[1])
+				if err != nil {
+					return err
+				}
+				coins = []Coin{coin}
+			} else if coins, err = client.AllBalances(ctx, args[0]); err != nil {
+				return err
+			}
+
+			records := make([]Record, len(coins))
+			for i, coin := range coins {
+				records[i] = Record{{"denom", coin.Denom}, {"amount", coin.Amount}}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+}
+
+// newCosmosAccountCommand shows an account's number and sequence
+func newCosmosAccountCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "account ADDRESS",
+		Short: "Show an account's type, number, and sequence",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			account, err := client.Account(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, Record{
+				{"address", account.Address},
+				{"type", account.TypeURL},
+				{"account_number", account.AccountNumber},
+				{"sequence", account.Sequence},
+			})
+		},
+	}
+}
+
+// newCosmosValidatorsCommand lists the staking validators
+func newCosmosValidatorsCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	var status string
+
+	cmd := &cobra.Command{
+		Use:   "validators",
+		Short: "List the staking validators",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if status != "" {
+				status = "BOND_STATUS_" + strings.ToUpper(strings.TrimPrefix(status, "BOND_STATUS_"))
+			}
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			validators, err := client.Validators(ctx, status)
+			if err != nil {
+				return err
+			}
+			records := make([]Record, len(validators))
+			for i, v := range validators {
+				records[i] = Record{
+					{"operator", v.OperatorAddress},
+					{"moniker", v.Moniker},
+					{"status", strings.TrimPrefix(v.Status, "BOND_STATUS_")},
+					{"jailed", v.Jailed},
+					{"tokens", v.Tokens},
+				}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "only validators in this state: bonded, unbonding, or unbonded")
+	return cmd
+}
+
+// newCosmosPoolCommand shows the staking pool totals
+func newCosmosPoolCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pool",
+		Short: "Show the bonded and not-bonded token totals",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			bonded, notBonded, err := client.StakingPool(ctx)
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, Record{{"bonded", bonded}, {"not_bonded", notBonded}})
+		},
+	}
+}
+
+// newCosmosTokenCommand shows one token of the customtoken module
+func newCosmosTokenCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "token DENOM",
+		Short: "Show a customtoken module token",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			token, err := client.CustomToken(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, Record{{"denom", args[0]}, {"token", token}})
+		},
+	}
+}
+
+// newCosmosTokensCommand lists the customtoken module's tokens
+func newCosmosTokensCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tokens",
+		Short: "List the customtoken module's tokens",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			tokens, err := client.CustomTokens(ctx)
+			if err != nil {
+				return err
+			}
+			records := make([]Record, len(tokens))
+			for i, token := range tokens {
+				records[i] = Record{{"token", token}}
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+}
+
+// cosmosGRPCQuery returns the query a bench repeats, by name and arguments
+func cosmosGRPCQuery(client *CosmosQueryClient, name string, args []string) (func(context.Context) error, error) {
+	need := func(n int, usage string) error {
+		if len(args) != n {
+			return fmt.Errorf("%s takes %s", name, usage)
+		}
+		return nil
+	}
+	var err error
+	var query func(context.Context) error
+	switch name {
+	case "balance":
+		if err = need(2, "ADDRESS DENOM"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.Balance(ctx, args[0], args[1]); return err }
+		}
+	case "balances":
+		if err = need(1, "ADDRESS"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.AllBalances(ctx, args[0]); return err }
+		}
+	case "account":
+		if err = need(1, "ADDRESS"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.Account(ctx, args[0]); return err }
+		}
+	case "validators":
+		if err = need(0, "no arguments"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.Validators(ctx, ""); return err }
+		}
+	case "pool":
+		if err = need(0, "no arguments"); err == nil {
+			query = func(ctx context.Context) error { _, _, err := client.StakingPool(ctx); return err }
+		}
+	case "token":
+		if err = need(1, "DENOM"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.CustomToken(ctx, args[0]); return err }
+		}
+	case "tokens":
+		if err = need(0, "no arguments"); err == nil {
+			query = func(ctx context.Context) error { _, err := client.CustomTokens(ctx); return err }
+		}
+	default:
+		err = fmt.Errorf("unknown query %q (want balance, balances, account, validators, pool, token, or tokens)", name)
+	}
+	return query, err
+}
+
+// newCosmosGRPCBenchCommand benchmarks one module query
+func newCosmosGRPCBenchCommand(opts *globalOptions, gopts *cosmosGRPCOptions) *cobra.Command {
+	var requests, concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "bench QUERY [ARGS...]",
+		Short: "Benchmark a module query's latency and throughput",
+		Long: `Fire a fixed number of one gRPC query at a given concurrency and report
+throughput and latency percentiles, as bench does for Ethereum methods. QUERY
+is balance ADDRESS DENOM, balances ADDRESS, account ADDRESS, validators, pool,
+token DENOM, or tokens:
+
+  megaeth-rpc-tester cosmos grpc bench balance cosmos1... uatom -n 500 -C 20 --plaintext
+
+Retried calls count once, with the latency of all their attempts.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if requests <= 0 {
+				return fmt.Errorf("--requests must be positive")
+			}
+			client, err := gopts.client(opts)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			query, err := cosmosGRPCQuery(client, args[0], args[1:])
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			source := "cosmos grpc bench " + args[0]
+			opts.events.Publish(Event{Kind: EventRunStarted, Source: source, Endpoint: client.Target()})
+			result := client.Bench(ctx, args[0], query, requests, concurrency, nil)
+			opts.events.Publish(Event{
+				Kind:     EventRunFinished,
+				Source:   source,
+				Endpoint: client.Target(),
+				Message:  fmt.Sprintf("%d requests, %d errors", result.Requests, result.Errors),
+			})
+			opts.recordHealth(client.Target(), HealthSample{
+				Source:    "cosmos grpc bench",
+				Reachable: result.Errors < result.Requests,
+				Requests:  result.Requests,
+				Errors:    result.Errors,
+				P95Ms:     durationMillis(result.P95),
+			})
+			if result.Errors == result.Requests {
+				return fmt.Errorf("all %d requests failed: %s", result.Requests, result.FirstError)
+			}
+			return opts.write(cmd, nil, benchRecord(result, concurrency))
+		},
+	}
+
+	cmd.Flags().IntVarP(&requests, "requests", "n", 100, "number of requests to send")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "C", 10, "number of concurrent workers")
+
+	return cmd
+}
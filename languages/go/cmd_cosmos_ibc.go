@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// openIBCChain connects to a Cosmos chain configured under cosmos_chains
+func openIBCChain(ctx context.Context, opts *globalOptions, name string, retries int) (*IBCChain, error) {
+	chain, ok := opts.cosmosChains[name]
+	if !ok {
+		names := make([]string, 0, len(opts.cosmosChains))
+		for n := range opts.cosmosChains {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown cosmos chain %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+	comet, err := NewCometClient(chain.RPCURL, opts.headers)
+	if err != nil {
+		return nil, err
+	}
+	comet.SetLogger(opts.logger)
+	comet.SetSlowLog(opts.slowThreshold, opts.slowSample)
+	queries, err := DialCosmosGRPC(chain.GRPCAddr, chain.Plaintext, retries, opts.logger)
+	if err != nil {
+		return nil, err
+	}
+	ibc, err := NewIBCChain(ctx, name, comet, queries)
+	if err != nil {
+		queries.Close()
+		return nil, err
+	}
+	return ibc, nil
+}
+
+// newCosmosIBCCommand builds the cosmos ibc subcommand, which checks the
+// channels and relaying between two chains
+func newCosmosIBCCommand(opts *globalOptions) *cobra.Command {
+	var check IBCCheckOptions
+	var retries int
+
+	cmd := &cobra.Command{
+		Use:   "ibc CHAIN_A CHAIN_B",
+		Short: "Check IBC channels and relaying between two chains",
+		Long: `Check every IBC channel between two Cosmos chains, in both directions: its
+state on each end, the packets committed on the sender, how many the receiver
+has not received, how many it has not acknowledged, and how many
+acknowledgements were not relayed back. The chains are named in the config
+file:
+
+  cosmos_chains:
+    hub:
+      rpc_url: https://rpc.cosmos.network
+      grpc_addr: grpc.cosmos.network:443
+    osmosis:
+      rpc_url: https://rpc.osmosis.zone
+      grpc_addr: grpc.osmosis.zone:443
+
+A channel is stuck when packets are in flight on a channel that is not open on
+both ends, or when the oldest unreceived packet or unrelayed acknowledgement
+was sent more than --stuck-after ago. Relay latency is the time from the send
+block to the receive block of the last --latency-samples relayed packets.
+Packet ages and latencies need transaction indexing on both nodes.
+
+  megaeth-rpc-tester cosmos ibc hub osmosis --port transfer --timeout 5m
+
+Stuck channels raise alerts and make the command exit with status 4.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == args[1] {
+				return fmt.Errorf("name two different chains")
+			}
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			a, err := openIBCChain(ctx, opts, args[0], retries)
+			if err != nil {
+				return err
+			}
+			defer a.Queries.Close()
+			b, err := openIBCChain(ctx, opts, args[1], retries)
+			if err != nil {
+				return err
+			}
+			defer b.Queries.Close()
+
+			reports, err := CheckIBC(ctx, a, b, check, opts.logger)
+			if err != nil {
+				return err
+			}
+			if len(reports) == 0 {
+				opts.logger.Warn("no channels between the chains", "a", a.ChainID, "b", b.ChainID)
+			}
+
+			var stuck []string
+			records := make([]Record, len(reports))
+			for i, r := range reports {
+				records[i] = Record{
+					{"source", r.Source},
+					{"destination", r.Destination},
+					{"port", r.Port},
+					{"channel", r.Channel},
+					{"counterparty_channel", r.CounterpartyChannel},
+					{"state", r.State},
+					{"counterparty_state", r.CounterpartyState},
+					{"pending", r.Pending},
+					{"unreceived", r.Unreceived},
+					{"awaiting_ack", r.AwaitingAck},
+					{"unrelayed_acks", r.UnrelayedAcks},
+					{"oldest_sequence", r.OldestSequence},
+					{"oldest_age_s", r.OldestAge.Round(time.Second).Seconds()},
+					{"relay_latency_ms", durationMillis(r.RelayLatency)},
+					{"max_relay_latency_ms", durationMillis(r.MaxRelayLatency)},
+					{"latency_samples", r.LatencySamples},
+					{"stuck", r.Stuck},
+				}
+				if r.Stuck == "" {
+					continue
+				}
+				name := fmt.Sprintf("%s %s/%s", r.Source, r.Port, r.Channel)
+				stuck = append(stuck, name+": "+r.Stuck)
+				alert := &Alert{
+					Source:    "cosmos ibc",
+					Endpoint:  name,
+					Metric:    "ibc_stuck",
+					Value:     r.OldestAge.Round(time.Second).String(),
+					Threshold: check.StuckAfter.String(),
+					Message:   fmt.Sprintf("%s to %s stuck: %s", name, r.Destination, r.Stuck),
+				}
+				opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "cosmos ibc", Endpoint: name, Alert: alert, Message: alert.Message})
+			}
+			if err := opts.write(cmd, nil, records...); err != nil {
+				return err
+			}
+			if len(stuck) > 0 {
+				return withExitCode(ExitCheckFailure, errors.New(strings.Join(stuck, "; ")))
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&check.Port, "port", "", "only channels bound to this port, such as transfer")
+	flags.StringVar(&check.Channel, "channel", "", "only this channel of CHAIN_A, and its counterparty")
+	flags.DurationVar(&check.StuckAfter, "stuck-after", 15*time.Minute, "call a channel stuck when a packet or acknowledgement has waited this long (0 disables)")
+	flags.IntVar(&check.LatencySamples, "latency-samples", 5, "recently relayed packets to time per channel (0 disables)")
+	flags.IntVar(&retries, "retries", 2, "times to retry a gRPC call the node rejects as unavailable or overloaded")
+
+	return cmd
+}
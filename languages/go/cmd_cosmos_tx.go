@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// cosmosTxOptions are shared by the cosmos tx subcommands
+type cosmosTxOptions struct {
+	grpc          cosmosGRPCOptions
+	mode          string
+	gas           string
+	gasAdjustment float64
+	fees          string
+	gasPrices     string
+	memo          string
+	prefix        string
+	chainID       string
+	repeat        int
+}
+
+// newCosmosTxCommand builds the cosmos tx subcommand for signed transactions
+func newCosmosTxCommand(opts *globalOptions, copts *cosmosOptions) *cobra.Command {
+	topts := &cosmosTxOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "tx",
+		Short: "Sign and broadcast Cosmos SDK transactions",
+		Long: `Sign transactions with --key (or the ` + envPrivateKey + ` environment variable) in
+SIGN_MODE_DIRECT and broadcast them through the CometBFT RPC. The account
+number and sequence come from the gRPC endpoint, and the chain ID from the
+node unless --chain-id is set.
+
+--mode sync returns once the transaction passed CheckTx, async as soon as the
+node has it, and block once it is in a block. --gas auto simulates the
+transaction first and pads the estimate by --gas-adjustment.
+
+--repeat sends that many transactions back to back with consecutive sequence
+numbers and reports latency percentiles, a write-path load test for the
+chain. --timeout then bounds each transaction, and the run stops early when
+interrupted.
+
+  megaeth-rpc-tester cosmos tx send cosmos1... 1000uatom --gas-prices 0.025uatom --repeat 200`,
+	}
+	topts.grpc.register(cmd)
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&topts.mode, "mode", BroadcastSync, "broadcast mode: sync, async, or block")
+	flags.StringVar(&topts.gas, "gas", "200000", "gas limit, or auto to simulate")
+	flags.Float64Var(&topts.gasAdjustment, "gas-adjustment", 1.3, "multiplier applied to the simulated gas with --gas auto")
+	flags.StringVar(&topts.fees, "fees", "", "fee coins, such as 5000uatom")
+	flags.StringVar(&topts.gasPrices, "gas-prices", "", "price per unit of gas, such as 0.025uatom (instead of --fees)")
+	flags.StringVar(&topts.memo, "memo", "", "transaction memo")
+	flags.StringVar(&topts.prefix, "prefix", "cosmos", "bech32 account address prefix of the chain")
+	flags.StringVar(&topts.chainID, "chain-id", "", "chain ID to sign for (default the node's network)")
+	flags.IntVar(&topts.repeat, "repeat", 1, "send this many transactions back to back and report latencies")
+
+	cmd.AddCommand(
+		newCosmosSendCommand(opts, copts, topts),
+		newCosmosCreateTokenCommand(opts, copts, topts),
+		newCosmosMintCommand(opts, copts, topts),
+	)
+	return cmd
+}
+
+// newCosmosSendCommand transfers coins with the bank module
+func newCosmosSendCommand(opts *globalOptions, copts *cosmosOptions, topts *cosmosTxOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "send TO AMOUNT",
+		Short: "Send coins, such as 1000uatom, to an address",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := ParseCoins(args[1])
+			if err != nil {
+				return err
+			}
+			return topts.run(cmd, opts, copts, "send", func(sender string) CosmosMsg {
+				return MsgSend(sender, args[0], amount)
+			})
+		},
+	}
+}
+
+// newCosmosCreateTokenCommand sends the customtoken module's MsgCreateToken
+func newCosmosCreateTokenCommand(opts *globalOptions, copts *cosmosOptions, topts *cosmosTxOptions) *cobra.Command {
+	var maxSupply string
+
+	cmd := &cobra.Command{
+		Use:   "create-token DENOM",
+		Short: "Create a customtoken module token",
+		Long: `Send the customtoken module's MsgCreateToken. The message is assumed to carry
+the creator as field 1, the denom as field 2, and the maximum supply as field
+3; select the module's protobuf package with --customtoken-package.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := parseOptionalWei("--max-supply", maxSupply); err != nil {
+				return err
+			}
+			return topts.run(cmd, opts, copts, "create-token", func(sender string) CosmosMsg {
+				return MsgCreateToken(topts.grpc.customToken, sender, args[0], maxSupply)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&maxSupply, "max-supply", "", "maximum supply of the token (default unlimited)")
+	return cmd
+}
+
+// newCosmosMintCommand sends the customtoken module's MsgMintToken
+func newCosmosMintCommand(opts *globalOptions, copts *cosmosOptions, topts *cosmosTxOptions) *cobra.Command {
+	var recipient string
+
+	cmd := &cobra.Command{
+		Use:   "mint DENOM AMOUNT",
+		Short: "Mint a customtoken module token",
+		Long: `Send the customtoken module's MsgMintToken. The message is assumed to carry
+the creator as field 1, the denom as field 2, the amount as field 3, and the
+recipient as field 4; select the module's protobuf package with
+--customtoken-package.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, ok := new(big.Int).SetString(args[1], 10); !ok || v.Sign() <= 0 {
+				return fmt.Errorf("invalid amount %q", args[1])
+			}
+			return topts.run(cmd, opts, copts, "mint", func(sender string) CosmosMsg {
+				return MsgMintToken(topts.grpc.customToken, sender, args[0], args[1], recipient)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&recipient, "recipient", "", "address that receives the minted tokens (default the sender)")
+	return cmd
+}
+
+// run signs and broadcasts the message build returns for the sender, once or
+// --repeat times
+func (t *cosmosTxOptions) run(cmd *cobra.Command, opts *globalOptions, copts *cosmosOptions, name string, build func(sender string) CosmosMsg) error {
+	if _, ok := cometBroadcastMethods[t.mode]; !ok {
+		return fmt.Errorf("unknown --mode %q (want sync, async, or block)", t.mode)
+	}
+	if t.fees != "" && t.gasPrices != "" {
+		return fmt.Errorf("--fees and --gas-prices are mutually exclusive")
+	}
+	if t.repeat <= 0 {
+		return fmt.Errorf("--repeat must be positive")
+	}
+	if opts.privateKey == "" {
+		return fmt.Errorf("cosmos transactions need --key")
+	}
+	key, err := NewCosmosKey(opts.privateKey, t.prefix)
+	if err != nil {
+		return err
+	}
+	fees, err := ParseCoins(t.fees)
+	if err != nil {
+		return err
+	}
+
+	comet, err := copts.client(opts)
+	if err != nil {
+		return err
+	}
+	queries, err := t.grpc.client(opts)
+	if err != nil {
+		return err
+	}
+	defer queries.Close()
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	chainID := t.chainID
+	if chainID == "" {
+		status, err := comet.Status(ctx)
+		if err != nil {
+			return err
+		}
+		chainID = status.NodeInfo.Network
+	}
+	sender := key.Address()
+	account, err := queries.Account(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s (is it funded?): %w", sender, err)
+	}
+
+	tx := CosmosTx{Msgs: []CosmosMsg{build(sender)}, Memo: t.memo, Fees: fees}
+	if t.gas == "auto" {
+		simulated, err := key.SignTx(tx, chainID, account.AccountNumber, account.Sequence)
+		if err != nil {
+			return err
+		}
+		used, err := queries.Simulate(ctx, simulated)
+		if err != nil {
+			return err
+		}
+		tx.GasLimit = uint64(math.Ceil(float64(used) * t.gasAdjustment))
+		opts.logger.Info("simulated gas", "used", used, "limit", tx.GasLimit)
+	} else if tx.GasLimit, err = strconv.ParseUint(t.gas, 10, 64); err != nil {
+		return fmt.Errorf("invalid --gas %q (want a limit or auto)", t.gas)
+	}
+	if t.gasPrices != "" {
+		fee, err := FeeForGas(tx.GasLimit, t.gasPrices)
+		if err != nil {
+			return err
+		}
+		tx.Fees = []Coin{fee}
+	}
+
+	sequence := account.Sequence
+	send := func(ctx context.Context) (*CometBroadcast, error) {
+		txBytes, err := key.SignTx(tx, chainID, account.AccountNumber, sequence)
+		if err != nil {
+			return nil, err
+		}
+		result, err := comet.BroadcastTx(ctx, txBytes, t.mode)
+		if err != nil {
+			return nil, err
+		}
+		// A transaction CheckTx rejected leaves the sequence unused. One that
+		// made it into a block used it even if it then failed, and async
+		// results cannot tell, so assume it was accepted.
+		if result.Code == 0 || result.Height > 0 || t.mode == BroadcastAsync {
+			sequence++
+		}
+		return result, nil
+	}
+
+	source := "cosmos tx " + name
+	if t.repeat == 1 {
+		used := sequence
+		result, err := send(ctx)
+		if err != nil {
+			return err
+		}
+		if t.mode == BroadcastBlock && result.Code == 0 {
+			opts.events.Publish(Event{
+				Kind:     EventTxConfirmed,
+				Source:   source,
+				Endpoint: comet.DisplayURL(),
+				TxHash:   result.Hash,
+				Message:  fmt.Sprintf("success in block %d", result.Height),
+			})
+		}
+		rec := Record{
+			{"hash", result.Hash},
+			{"sender", sender},
+			{"sequence", used},
+			{"code", result.Code},
+		}
+		if t.mode == BroadcastBlock {
+			rec = append(rec, Field{"height", result.Height}, Field{"gas_wanted", result.GasWanted}, Field{"gas_used", result.GasUsed})
+		}
+		if err := opts.write(cmd, nil, rec); err != nil {
+			return err
+		}
+		return cosmosTxError(result)
+	}
+
+	// The run lasts as long as the transactions take, so --timeout bounds
+	// each one rather than the whole run
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// One account signs every transaction, so they go out in order
+	opts.events.Publish(Event{Kind: EventRunStarted, Source: source, Endpoint: comet.DisplayURL()})
+	sample := sampleCalls(runCtx, opts.logger, comet.DisplayURL(), source, t.repeat, 1, nil, func(ctx context.Context) error {
+		if opts.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+			defer cancel()
+		}
+		result, err := send(ctx)
+		if err != nil {
+			return err
+		}
+		return cosmosTxError(result)
+	})
+	result := summarizeBench(source, sample)
+	opts.events.Publish(Event{
+		Kind:     EventRunFinished,
+		Source:   source,
+		Endpoint: comet.DisplayURL(),
+		Message:  fmt.Sprintf("%d transactions, %d errors", result.Requests, result.Errors),
+	})
+	if result.Requests == 0 {
+		return fmt.Errorf("stopped before any transaction was sent")
+	}
+	if result.Errors == result.Requests {
+		return fmt.Errorf("all %d transactions failed: %s", result.Requests, result.FirstError)
+	}
+	return opts.write(cmd, nil, benchRecord(result, 1))
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+)
+
+// Input kinds accepted by decode --as
+const (
+	decodeAsAuto     = "auto"
+	decodeAsHash     = "hash"
+	decodeAsRaw      = "raw"
+	decodeAsCalldata = "calldata"
+	decodeAsLog      = "log"
+)
+
+// newDecodeCommand builds the decode subcommand
+func newDecodeCommand(opts *globalOptions) *cobra.Command {
+	var abiPath, kind string
+	var lookup bool
+
+	cmd := &cobra.Command{
+		Use:   "decode INPUT",
+		Short: "Decode a transaction, raw transaction, calldata, or log entry",
+		Long: `Decode INPUT into a field-by-field breakdown. INPUT is one of:
+
+  a transaction hash    fetched from the endpoint with its receipt logs
+  a raw transaction     RLP or typed-envelope hex as sent to eth_sendRawTransaction
+  calldata              hex starting with a 4-byte selector
+  a log entry           JSON object with address, topics, and data
+
+The kind is detected automatically unless --as is given. Calls and events are
+identified with --abi, then a built-in set of common ABIs, then, with --lookup,
+the 4byte.directory signature database:
+
+  megaeth-rpc-tester decode 0xa9059cbb000000000000000000000000...
+  megaeth-rpc-tester decode --lookup 0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input := strings.TrimSpace(args[0])
+			if kind == decodeAsAuto {
+				kind = detectDecodeInput(input)
+			}
+
+			var contract *abi.ABI
+			if abiPath != "" {
+				loaded, err := LoadABI(abiPath)
+				if err != nil {
+					return err
+				}
+				contract = loaded
+			}
+			decoder := NewDecoder(contract, lookup)
+
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			var records []Record
+			var err error
+			switch kind {
+			case decodeAsHash:
+				records, err = decodeTxHash(ctx, opts, decoder, input)
+			case decodeAsRaw:
+				records, err = decodeRawTx(ctx, decoder, input)
+			case decodeAsCalldata:
+				records, err = decodeCalldataInput(ctx, decoder, input)
+			case decodeAsLog:
+				records, err = decodeLogInput(ctx, decoder, input)
+			default:
+				return fmt.Errorf("unknown --as %q (want %s)", kind, strings.Join([]string{decodeAsAuto, decodeAsHash, decodeAsRaw, decodeAsCalldata, decodeAsLog}, ", "))
+			}
+			if err != nil {
+				return err
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&abiPath, "abi", "", "contract ABI or build artifact to decode with")
+	flags.StringVar(&kind, "as", decodeAsAuto, "input kind: auto, hash, raw, calldata, or log")
+	flags.BoolVar(&lookup, "lookup", false, "resolve unknown selectors and topics through 4byte.directory")
+
+	return cmd
+}
+
+// detectDecodeInput guesses the kind of a decode input. Hex that parses as a
+// transaction envelope is taken to be one; any other hex is calldata.
+func detectDecodeInput(input string) string {
+	if strings.HasPrefix(input, "{") {
+		return decodeAsLog
+	}
+	if len(input) == 66 && strings.HasPrefix(input, "0x") {
+		return decodeAsHash
+	}
+	if data, err := hexutil.Decode(input); err == nil {
+		var tx types.Transaction
+		if tx.UnmarshalBinary(data) == nil {
+			return decodeAsRaw
+		}
+	}
+	return decodeAsCalldata
+}
+
+// decodeRecord is one row of a decode breakdown
+func decodeRecord(section, field, typ string, value interface{}) Record {
+	return Record{{"section", section}, {"field", field}, {"type", typ}, {"value", value}}
+}
+
+// decodeTxHash fetches a transaction and its receipt and decodes both
+func decodeTxHash(ctx context.Context, opts *globalOptions, decoder *Decoder, input string) ([]Record, error) {
+	client, err := opts.client()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	hash := common.HexToHash(input)
+	tx, _, err := client.client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	records, err := describeDecodedTx(ctx, decoder, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := client.client.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		// Still pending, so there are no logs yet
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+	records = append(records,
+		decodeRecord("receipt", "status", "uint64", receipt.Status),
+		decodeRecord("receipt", "block_number", "uint256", receipt.BlockNumber.String()),
+		decodeRecord("receipt", "gas_used", "uint64", receipt.GasUsed),
+	)
+	for i, log := range receipt.Logs {
+		logRecords, err := describeDecodedLog(ctx, decoder, fmt.Sprintf("log[%d]", i), *log)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, logRecords...)
+	}
+	return records, nil
+}
+
+// decodeRawTx decodes a signed transaction envelope
+func decodeRawTx(ctx context.Context, decoder *Decoder, input string) ([]Record, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction hex: %w", err)
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("failed to decode raw transaction: %w", err)
+	}
+	return describeDecodedTx(ctx, decoder, &tx)
+}
+
+// describeDecodedTx lists a transaction's fields followed by its decoded calldata
+func describeDecodedTx(ctx context.Context, decoder *Decoder, tx *types.Transaction) ([]Record, error) {
+	from := "(unknown)"
+	if sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		from = sender.Hex()
+	}
+
+	var records []Record
+	for _, f := range DescribeTransaction(tx, tx.ChainId(), common.Address{}) {
+		value := f.Value
+		switch f.Name {
+		case "from":
+			value = from
+		case "calldata":
+			// Replaced by the full breakdown below
+			continue
+		}
+		records = append(records, decodeRecord("transaction", f.Name, "", value))
+	}
+
+	if len(tx.Data()) < 4 {
+		return records, nil
+	}
+	callRecords, err := describeDecodedCall(ctx, decoder, tx.Data())
+	if err != nil {
+		return nil, err
+	}
+	return append(records, callRecords...), nil
+}
+
+// decodeCalldataInput decodes bare calldata
+func decodeCalldataInput(ctx context.Context, decoder *Decoder, input string) ([]Record, error) {
+	data, err := hexutil.Decode(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calldata hex: %w", err)
+	}
+	return describeDecodedCall(ctx, decoder, data)
+}
+
+// describeDecodedCall lists the method and arguments of calldata
+func describeDecodedCall(ctx context.Context, decoder *Decoder, data []byte) ([]Record, error) {
+	call, err := decoder.DecodeCall(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{
+		decodeRecord("call", "selector", "bytes4", call.Selector),
+		decodeRecord("call", "method", "", call.Signature),
+	}
+	for _, arg := range call.Args {
+		records = append(records, decodeRecord("call", arg.Name, arg.Type, arg.Value))
+	}
+	return records, nil
+}
+
+// decodeLogInput decodes a log entry given as JSON, as returned by eth_getLogs
+func decodeLogInput(ctx context.Context, decoder *Decoder, input string) ([]Record, error) {
+	var entry struct {
+		Address common.Address `json:"address"`
+		Topics  []common.Hash  `json:"topics"`
+		Data    hexutil.Bytes  `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(input), &entry); err != nil {
+		return nil, fmt.Errorf("invalid log entry: %w", err)
+	}
+	return describeDecodedLog(ctx, decoder, "log", types.Log{Address: entry.Address, Topics: entry.Topics, Data: entry.Data})
+}
+
+// describeDecodedLog lists the emitter, event, and arguments of a log
+func describeDecodedLog(ctx context.Context, decoder *Decoder, section string, log types.Log) ([]Record, error) {
+	decoded, err := decoder.DecodeLog(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{
+		decodeRecord(section, "address", "address", decoded.Address.Hex()),
+		decodeRecord(section, "event", "", decoded.Signature),
+	}
+	for _, arg := range decoded.Args {
+		records = append(records, decodeRecord(section, arg.Name, arg.Type, arg.Value))
+	}
+	return records, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newFinalityCommand builds the finality subcommand, which watches the safe
+// and finalized tags for lag, stalls, and reverted finality
+func newFinalityCommand(opts *globalOptions) *cobra.Command {
+	var duration, interval, stallAfter time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "finality",
+		Short: "Track finalization lag, stalls, and reverted finalized blocks",
+		Long: `Poll the latest, safe, and finalized block tags every --interval for --duration
+and report how far finality lags behind the head, in blocks and seconds. A
+stall is finality not advancing for longer than --stall-after; a violation is
+a block once reported as finalized whose hash later changed.
+
+  megaeth-rpc-tester finality --network sepolia --duration 30m --stall-after 15m
+
+Stalls and violations raise alerts and make the command exit with status 4.
+Interrupting the run reports what was observed so far.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if duration <= 0 || interval <= 0 || stallAfter <= 0 {
+				return fmt.Errorf("--duration, --interval, and --stall-after must be positive")
+			}
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			// The run lasts --duration, so the global timeout does not apply
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			endpoint := client.DisplayURL()
+			opts.logger.Info("tracking finality", "endpoint", endpoint, "duration", duration, "interval", interval)
+			report, err := client.TrackFinality(ctx, duration, interval, stallAfter)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+
+			for _, stall := range report.Stalls {
+				alert := &Alert{
+					Source:    "finality",
+					Endpoint:  endpoint,
+					Metric:    "finalization stall",
+					Value:     stall.Round(time.Second).String(),
+					Threshold: stallAfter.String(),
+					Message:   fmt.Sprintf("finalized block did not advance for %s", stall.Round(time.Second)),
+				}
+				opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "finality", Endpoint: endpoint, Alert: alert, Message: alert.Message})
+			}
+			for _, v := range report.Violations {
+				alert := &Alert{
+					Source:    "finality",
+					Endpoint:  endpoint,
+					Metric:    "finalized block reverted",
+					Value:     v.NewHash.Hex(),
+					Threshold: v.OldHash.Hex(),
+					Message:   fmt.Sprintf("finalized block %d changed from %s to %s", v.Block, v.OldHash.Hex(), v.NewHash.Hex()),
+				}
+				opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "finality", Endpoint: endpoint, Alert: alert, Message: alert.Message})
+			}
+
+			rec := Record{
+				{"endpoint", endpoint},
+				{"samples", len(report.Samples)},
+				{"max_lag_blocks", report.MaxLag},
+				{"stalls", len(report.Stalls)},
+				{"violations", len(report.Violations)},
+			}
+			if n := len(report.Samples); n > 0 {
+				last := report.Samples[n-1]
+				rec = append(rec,
+					Field{"head", last.Head},
+					Field{"safe", last.Safe},
+					Field{"finalized", last.Finalized},
+					Field{"lag_blocks", last.LagBlocks},
+					Field{"lag_s", last.LagSeconds},
+				)
+			}
+			if err := opts.write(cmd, nil, rec); err != nil {
+				return err
+			}
+			if !report.Passed() {
+				return withExitCode(ExitCheckFailure, fmt.Errorf("%d finality stalls and %d reverted finalized blocks", len(report.Stalls), len(report.Violations)))
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.DurationVar(&duration, "duration", 10*time.Minute, "how long to observe finality")
+	flags.DurationVar(&interval, "interval", 12*time.Second, "time between polls of the block tags")
+	flags.DurationVar(&stallAfter, "stall-after", 5*time.Minute, "call finality stalled when the finalized block has not advanced for this long")
+
+	return cmd
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// hdOptions select a range of HD accounts from a mnemonic, the accounts of
+// an encrypted key vault, or accounts derived from a run seed
+type hdOptions struct {
+	mnemonic   string
+	passphrase string
+	path       string
+	start      int
+	count      int
+	seed       string
+	// worker offsets start by worker*count so workers take disjoint ranges
+	worker int
+
+	vault        string
+	passwordFile string
+	cmd          *cobra.Command
+}
+
+// register adds the HD account flags to cmd
+func (h *hdOptions) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&h.mnemonic, "mnemonic", "", "BIP-39 mnemonic (prefer the "+envMnemonic+" environment variable)")
+	flags.StringVar(&h.passphrase, "passphrase", "", "optional BIP-39 passphrase")
+	flags.StringVar(&h.path, "path", DefaultHDBasePath, "base derivation path; the account index is appended")
+	flags.IntVar(&h.start, "start", 0, "first account index")
+	flags.IntVarP(&h.count, "count", "n", 5, "number of accounts (default every account with --vault)")
+	flags.StringVar(&h.vault, "vault", "", "use the accounts of this encrypted key vault instead of a mnemonic")
+	flags.StringVar(&h.passwordFile, "password-file", "", "file holding the vault password (default "+envKeystorePassword+" or a prompt)")
+	flags.StringVar(&h.seed, "seed", "", "derive accounts from this run seed instead of a mnemonic (prefer the "+envRunSeed+" environment variable)")
+	flags.IntVar(&h.worker, "worker", 0, "worker index; selects the worker's own block of --count accounts after --start")
+	h.cmd = cmd
+}
+
+// derive returns the selected accounts
+func (h *hdOptions) derive() ([]HDAccount, error) {
+	if h.worker < 0 {
+		return nil, fmt.Errorf("invalid --worker %d", h.worker)
+	}
+	if h.vault != "" {
+		return h.unlockVault()
+	}
+	if h.seed != "" && h.mnemonic != "" {
+		return nil, fmt.Errorf("--seed and --mnemonic are mutually exclusive")
+	}
+	if h.seed == "" && h.mnemonic == "" {
+		// Neither source wins silently when both are in the environment
+		h.seed, h.mnemonic = os.Getenv(envRunSeed), os.Getenv(envMnemonic)
+		if h.seed != "" && h.mnemonic != "" {
+			return nil, fmt.Errorf("both %s and %s are set; unset one, or pick with --seed or --mnemonic", envRunSeed, envMnemonic)
+		}
+	}
+	if h.seed != "" {
+		RegisterSecret(h.seed)
+		return DeriveSeedAccounts(h.seed, h.start+h.worker*h.count, h.count)
+	}
+	if h.mnemonic == "" {
+		return nil, fmt.Errorf("a mnemonic is required; pass --mnemonic or set %s", envMnemonic)
+	}
+	RegisterSecret(h.mnemonic)
+	return DeriveHDAccounts(h.mnemonic, h.passphrase, h.path, h.start+h.worker*h.count, h.count)
+}
+
+// unlockVault unlocks the vault once and returns the accounts selected by
+// --start and, when given, --count
+func (h *hdOptions) unlockVault() ([]HDAccount, error) {
+	if h.mnemonic != "" || h.seed != "" {
+		return nil, fmt.Errorf("--vault is mutually exclusive with --mnemonic and --seed")
+	}
+	vault, err := LoadVault(h.vault)
+	if err != nil {
+		return nil, err
+	}
+	password, err := readPassword(h.passwordFile, false)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := vault.Accounts(password)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := h.start, len(accounts)
+	if h.cmd.Flags().Changed("count") {
+		start += h.worker * h.count
+		end = start + h.count
+	} else if h.worker != 0 {
+		return nil, fmt.Errorf("--worker needs --count with --vault")
+	}
+	if start < 0 || end > len(accounts) || start > end {
+		return nil, fmt.Errorf("vault holds %d accounts; cannot select %d from index %d", len(accounts), end-start, start)
+	}
+	return accounts[start:end], nil
+}
+
+// transferOptions control how a funding or sweep plan is submitted
+type transferOptions struct {
+	yes         bool
+	batchSize   int
+	wait        bool
+	waitTimeout time.Duration
+}
+
+// register adds the submission flags to cmd
+func (t *transferOptions) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVarP(&t.yes, "yes", "y", false, "send without asking for confirmation")
+	flags.IntVar(&t.batchSize, "batch-size", 20, "transactions per JSON-RPC batch")
+	flags.BoolVar(&t.wait, "wait", true, "wait for every transfer to be included")
+	flags.DurationVar(&t.waitTimeout, "wait-timeout", 5*time.Minute, "how long to wait for inclusion")
+}
+
+// newFundCommand builds the fund subcommand
+func newFundCommand(opts *globalOptions) *cobra.Command {
+	hd := &hdOptions{}
+	topts := &transferOptions{}
+	var amountArg, keepArg string
+
+	cmd := &cobra.Command{
+		Use:   "fund",
+		Short: "Distribute the balance of --key across HD-derived test accounts",
+		Long: `Send ETH from the --key account to --count accounts derived from a mnemonic.
+By default the balance, less --keep and gas, is split evenly; --amount sends a
+fixed amount to each. Transfers use consecutive nonces and are submitted in
+JSON-RPC batches. sweep returns the funds. --vault takes the accounts from an
+encrypted key vault (see account vault) instead of a mnemonic, and --seed
+derives them from a run seed (see account pool).
+
+  MNEMONIC="..." megaeth-rpc-tester fund --key $PRIVATE_KEY --count 10 --amount 1000000000000000 --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			amount, err := parseOptionalWei("--amount", amountArg)
+			if err != nil {
+				return err
+			}
+			keep, err := parseOptionalWei("--keep", keepArg)
+			if err != nil {
+				return err
+			}
+			if !opts.hasSigner() {
+				return fmt.Errorf("a signer is required; pass --key, --ledger, --node-account, or --transit-key")
+			}
+			accounts, err := hd.derive()
+			if err != nil {
+				return err
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			plan, err := client.PlanFunding(ctx, accounts, amount, keep)
+			if err != nil {
+				return err
+			}
+			return submitPlan(cmd, opts, topts, client, plan)
+		},
+	}
+
+	hd.register(cmd)
+	topts.register(cmd)
+	cmd.Flags().StringVar(&amountArg, "amount", "", "wei to send to each account (default an even split)")
+	cmd.Flags().StringVar(&keepArg, "keep", "0", "wei to leave in the --key account when splitting")
+
+	return cmd
+}
+
+// newSweepCommand builds the sweep subcommand
+func newSweepCommand(opts *globalOptions) *cobra.Command {
+	hd := &hdOptions{}
+	topts := &transferOptions{}
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "sweep",
+		Short: "Return the balances of HD-derived test accounts",
+		Long: `Send the whole balance, less gas, of each of --count accounts derived from a
+mnemonic to --to, or to the signing account when --to is not given.
+Accounts whose balance does not cover gas are skipped.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var dest common.Address
+			switch {
+			case to != "":
+				if !common.IsHexAddress(to) {
+					return fmt.Errorf("invalid --to address %q", to)
+				}
+				dest = common.HexToAddress(to)
+			case opts.hasSigner():
+				addr, err := opts.signerAddress()
+				if err != nil {
+					return err
+				}
+				dest = addr
+			default:
+				return fmt.Errorf("a destination is required; pass --to or a signer")
+			}
+			accounts, err := hd.derive()
+			if err != nil {
+				return err
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			plan, err := client.PlanSweep(ctx, accounts, dest)
+			if err != nil {
+				return err
+			}
+			if len(plan) == 0 {
+				opts.logger.Info("nothing to sweep: no account holds more than the gas for a transfer")
+				return nil
+			}
+			return submitPlan(cmd, opts, topts, client, plan)
+		},
+	}
+
+	hd.register(cmd)
+	topts.register(cmd)
+	cmd.Flags().StringVar(&to, "to", "", "address to sweep to (default the --key account)")
+
+	return cmd
+}
+
+// submitPlan previews a plan, simulating each transfer on a dry run, and
+// unless it is a dry run or the user declines, signs and submits it and
+// optionally waits for every transfer
+func submitPlan(cmd *cobra.Command, opts *globalOptions, topts *transferOptions, client *RPCClient, plan []PlannedTransfer) error {
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	total := new(big.Int)
+	failing := 0
+	records := make([]Record, len(plan))
+	for i, t := range plan {
+		total.Add(total, t.Tx.Value())
+		records[i] = Record{
+			{"path", t.Path},
+			{"from", t.From.Hex()},
+			{"to", t.To.Hex()},
+			{"nonce", t.Tx.Nonce()},
+			{"value_wei", t.Tx.Value().String()},
+			{"max_gas_cost_wei", transferCost(t.Tx).String()},
+		}
+		if opts.dryRun {
+			sim := client.SimulateTransaction(ctx, t.Tx, t.From)
+			if sim.Err != nil {
+				failing++
+			}
+			records[i] = append(records[i], sim.Record()...)
+		}
+	}
+	if err := opts.write(cmd, nil, records...); err != nil {
+		return err
+	}
+	opts.logger.Info("planned transfers", "transfers", len(plan), "total_wei", total.String())
+
+	if opts.dryRun {
+		opts.logger.Info("dry run: transfers not sent")
+		if failing > 0 {
+			return fmt.Errorf("%d of %d transfers would fail", failing, len(plan))
+		}
+		return nil
+	}
+	if !topts.yes && !confirm(cmd, "Send these transfers?") {
+		return fmt.Errorf("aborted")
+	}
+
+	if err := SignPlan(ctx, plan); err != nil {
+		return err
+	}
+	if err := client.BroadcastBatch(ctx, plan, topts.batchSize); err != nil {
+		return err
+	}
+
+	var submitted []PlannedTransfer
+	for _, t := range plan {
+		if t.Err != nil {
+			opts.logger.Warn("transfer failed", "from", t.From.Hex(), "to", t.To.Hex(), "error", t.Err)
+			continue
+		}
+		opts.logger.Info("transfer submitted", "to", t.To.Hex(), "tx_hash", t.Tx.Hash().Hex())
+		submitted = append(submitted, t)
+	}
+	opts.logger.Info("transfers submitted", "submitted", len(submitted), "planned", len(plan))
+
+	if topts.wait {
+		// Inclusion can take far longer than the per-command RPC timeout
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), topts.waitTimeout)
+		defer waitCancel()
+		for _, t := range submitted {
+			receipt, err := client.WaitForReceipt(waitCtx, t.Tx.Hash(), time.Second, func(ReceiptStatus) {})
+			if err != nil {
+				return err
+			}
+			opts.events.Publish(Event{
+				Kind:     EventTxConfirmed,
+				Source:   cmd.Name(),
+				Endpoint: client.DisplayURL(),
+				TxHash:   receipt.TxHash.Hex(),
+				Message:  fmt.Sprintf("transfer to %s in block %d", t.To.Hex(), receipt.BlockNumber.Uint64()),
+			})
+			if receipt.Status == 0 {
+				return fmt.Errorf("transfer %s reverted", t.Tx.Hash().Hex())
+			}
+		}
+		opts.logger.Info("transfers included", "transfers", len(submitted))
+	}
+
+	if len(submitted) != len(plan) {
+		return fmt.Errorf("%d of %d transfers failed", len(plan)-len(submitted), len(plan))
+	}
+	return nil
+}
+
+// parseOptionalWei parses a decimal wei amount, returning nil for ""
+func parseOptionalWei(flag, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %q", flag, s)
+	}
+	return v, nil
+}
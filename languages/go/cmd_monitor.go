@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// monitorOptions are shared by the monitor subcommands
+type monitorOptions struct {
+	historyPath string
+	slo         float64
+}
+
+// history returns the path of the probe history file
+func (m *monitorOptions) history() (string, error) {
+	if m.historyPath != "" {
+		return m.historyPath, nil
+	}
+	return defaultUptimePath()
+}
+
+// newMonitorCommand builds the monitor subcommand and its report
+func newMonitorCommand(opts *globalOptions) *cobra.Command {
+	mopts := &monitorOptions{}
+	var interval, probeTimeout time.Duration
+	var scenarioPath, listen string
+	var maxBurnRate float64
+
+	run := &cobra.Command{
+		Use:   "run [ENDPOINT...]",
+		Short: "Probe endpoints on an interval and track their uptime",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 || probeTimeout <= 0 {
+				return fmt.Errorf("--interval and --probe-timeout must be positive")
+			}
+			if mopts.slo <= 0 || mopts.slo >= 100 {
+				return fmt.Errorf("--slo must be between 0 and 100")
+			}
+			path, err := mopts.history()
+			if err != nil {
+				return err
+			}
+
+			var canaries []DiffRequest
+			if scenarioPath != "" {
+				if canaries, err = LoadScenario(scenarioPath); err != nil {
+					return err
+				}
+			}
+
+			targets := args
+			if len(targets) == 0 {
+				targets = []string{""}
+			}
+			clients := make([]*RPCClient, 0, len(targets))
+			defer func() {
+				for _, c := range clients {
+					c.Close()
+				}
+			}()
+			for _, target := range targets {
+				client, err := opts.clientFor(target)
+				if err != nil {
+					return fmt.Errorf("failed to connect to %s: %w", target, err)
+				}
+				clients = append(clients, client)
+			}
+
+			monitor := NewUptimeMonitor(clients, canaries, interval, probeTimeout, mopts.slo/100)
+			earlier, err := LoadProbes(path, startOfMonth(time.Now()))
+			if err != nil {
+				return err
+			}
+			monitor.Seed(earlier)
+
+			probeLog, err := OpenProbeLog(path)
+			if err != nil {
+				return err
+			}
+			defer probeLog.Close()
+
+			// monitor runs until interrupted, so the global timeout does not apply
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			var mu sync.Mutex
+			stream := NewRecordStream(cmd.OutOrStdout(), opts.output)
+			down := make(map[string]bool)
+			monitor.OnProbe(func(p Probe) {
+				if err := probeLog.Append(p); err != nil {
+					opts.logger.Warn("failed to record probe", "error", err)
+				}
+				burn := monitor.BurnRate(p.Endpoint, p.At)
+
+				mu.Lock()
+				wasDown := down[p.Endpoint]
+				down[p.Endpoint] = !p.Up
+				status := "up"
+				if !p.Up {
+					status = "down"
+				}
+				stream.Write(Record{
+					{"at", p.At.Format(time.RFC3339)},
+					{"endpoint", p.Endpoint},
+					{"status", status},
+					{"latency_ms", p.LatencyMs},
+					{"burn_rate_1h", burn},
+					{"error", p.Error},
+				})
+				mu.Unlock()
+
+				availability := &Alert{Source: "monitor", Endpoint: p.Endpoint, Metric: "availability", Value: "down", Threshold: "up", At: p.At}
+				switch {
+				case !p.Up && !wasDown:
+					opts.logger.Warn("endpoint down", "endpoint", p.Endpoint, "error", p.Error)
+					availability.Message = "endpoint down: " + p.Error
+					opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "monitor", Endpoint: p.Endpoint, Alert: availability, Message: availability.Message, At: p.At})
+				case p.Up && wasDown:
+					opts.logger.Info("endpoint recovered", "endpoint", p.Endpoint)
+					opts.events.Publish(Event{Kind: EventThresholdCleared, Source: "monitor", Endpoint: p.Endpoint, Alert: availability, At: p.At})
+				}
+				if maxBurnRate > 0 && burn > maxBurnRate {
+					alert := &Alert{
+						Source: "monitor", Endpoint: p.Endpoint, Metric: "error budget burn rate",
+						Value:     strconv.FormatFloat(burn, 'f', -1, 64),
+						Threshold: strconv.FormatFloat(maxBurnRate, 'f', -1, 64),
+						Message:   fmt.Sprintf("error budget burning at %gx over the last hour (limit %gx)", burn, maxBurnRate),
+						At:        p.At,
+					}
+					opts.events.Publish(Event{Kind: EventThresholdBreached, Source: "monitor", Endpoint: p.Endpoint, Alert: alert, Message: alert.Message, At: p.At})
+				}
+			})
+
+			if listen != "" {
+				go func() {
+					if err := ServeUntilDone(ctx, listen, monitor); err != nil {
+						opts.logger.Error("metrics server stopped", "error", err)
+					}
+				}()
+				opts.logger.Info("serving uptime metrics", "listen", listen)
+			}
+			opts.logger.Info("monitoring", "endpoints", len(clients), "interval", interval, "history", path)
+
+			if err := monitor.Run(ctx); err != nil && ctx.Err() == nil {
+				return err
+			}
+			return nil
+		},
+	}
+	run.Flags().DurationVar(&interval, "interval", 30*time.Second, "time between probes")
+	run.Flags().DurationVar(&probeTimeout, "probe-timeout", 10*time.Second, "fail a probe that takes longer than this")
+	run.Flags().StringVar(&scenarioPath, "scenario", "", "YAML or JSON file listing the canary requests (default eth_chainId, eth_blockNumber, and the latest block)")
+	run.Flags().StringVar(&listen, "listen", "", "serve Prometheus SLA metrics on this address")
+	run.Flags().Float64Var(&maxBurnRate, "max-burn-rate", 14.4, "alert when the one-hour error budget burn rate exceeds this (0 disables)")
+
+	var month string
+	report := &cobra.Command{
+		Use:   "report [ENDPOINT...]",
+		Short: "Report monthly uptime and error budget use from the probe history",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if mopts.slo <= 0 || mopts.slo >= 100 {
+				return fmt.Errorf("--slo must be between 0 and 100")
+			}
+			now := time.Now()
+			start := startOfMonth(now)
+			if month != "" {
+				parsed, err := time.Parse(slaMonthLayout, month)
+				if err != nil {
+					return fmt.Errorf("invalid --month %q (want YYYY-MM)", month)
+				}
+				start = parsed
+			}
+			path, err := mopts.history()
+			if err != nil {
+				return err
+			}
+			probes, err := LoadProbes(path, start)
+			if err != nil {
+				return err
+			}
+
+			wanted := opts.endpointFilter(args)
+			var records []Record
+			for _, r := range ComputeSLA(probes, start, mopts.slo/100, now) {
+				if len(wanted) > 0 && !wanted[r.Endpoint] {
+					continue
+				}
+				records = append(records, Record{
+					{"endpoint", r.Endpoint},
+					{"month", r.Month},
+					{"probes", r.Probes},
+					{"failures", r.Failures},
+					{"uptime_pct", r.UptimePct},
+					{"target_pct", r.TargetPct},
+					{"mean_latency_ms", r.MeanLatencyMs},
+					{"p95_latency_ms", r.P95LatencyMs},
+					{"downtime", r.Downtime},
+					{"error_budget", r.ErrorBudget},
+					{"budget_used_pct", r.BudgetUsedPct},
+					{"burn_rate_1h", r.BurnRate},
+					{"last_error", r.LastError},
+				})
+			}
+			if len(records) == 0 {
+				return fmt.Errorf("no probes recorded for %s in %s", start.Format(slaMonthLayout), path)
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+	report.Flags().StringVar(&month, "month", "", "calendar month to report, as YYYY-MM in UTC (default the current month)")
+
+	cmd := &cobra.Command{
+		Use:   "monitor",
+		Short: "Track endpoint uptime and SLA error budgets",
+		Long: `Probe endpoints with a lightweight canary request set on an interval and
+measure them against an uptime target. monitor run probes until interrupted,
+printing each probe, appending it to the history file, and alerting the
+--alert sinks when an endpoint goes down or burns its error budget too fast.
+monitor report summarizes a calendar month from the history: uptime, latency,
+estimated downtime, and how much of the month's error budget is spent.
+
+  megaeth-rpc-tester monitor run megaeth-testnet https://rpc.example.org --listen :9109
+  megaeth-rpc-tester monitor report --month 2026-09 -o csv > sla.csv`,
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&mopts.historyPath, "history", "", "probe history file (default ~/"+uptimeFile+")")
+	flags.Float64Var(&mopts.slo, "slo", 99.9, "uptime target in percent")
+
+	cmd.AddCommand(run, report)
+	return cmd
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newNetworksCommand builds the networks subcommand listing known profiles
+func newNetworksCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "networks",
+		Short: "List the network profiles selectable with --network",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCHAIN ID\tRPC\tGAS")
+			for _, name := range networkNames(opts.networks) {
+				profile := opts.networks[name]
+				marker := ""
+				if name == opts.network {
+					marker = " *"
+				}
+				gas := profile.GasStrategy
+				if gas == "" {
+					gas = GasStrategyLegacy
+				}
+				fmt.Fprintf(w, "%s%s\t%d\t%s\t%s\n", name, marker, profile.ChainID, profile.RPCURL, gas)
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newProxyCommand builds the proxy subcommand and its record and replay modes
+func newProxyCommand(opts *globalOptions) *cobra.Command {
+	var listen, sessionPath string
+
+	record := &cobra.Command{
+		Use:   "record",
+		Short: "Forward traffic to the endpoint and record it to a session file",
+		Long: `Listen for JSON-RPC requests, forward them to the endpoint, and record every
+request/response pair with its timing. The session file is checkpointed every
+few seconds and written in full when the proxy is interrupted or terminated:
+
+  megaeth-rpc-tester proxy record --network megaeth-testnet --session session.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			proxy := NewRecordingProxy(client, exchangeLogger(cmd, opts))
+			opts.logger.Info("recording", "endpoint", client.DisplayURL(), "listen", listen)
+
+			// Checkpoint the session while recording so a crash loses at most
+			// the last interval
+			done := make(chan struct{})
+			checkpointed := make(chan struct{})
+			go func() {
+				defer close(checkpointed)
+				ticker := time.NewTicker(checkpointInterval)
+				defer ticker.Stop()
+				saved := 0
+				for {
+					select {
+					case <-done:
+						return
+					case <-ticker.C:
+						if n := proxy.Recorded(); n > saved {
+							if err := proxy.Session().Save(sessionPath); err != nil {
+								opts.logger.Warn("failed to checkpoint session", "error", err)
+								continue
+							}
+							saved = n
+						}
+					}
+				}
+			}()
+			serveErr := serveUntilInterrupted(listen, proxy)
+			close(done)
+			<-checkpointed
+
+			// A proxy that never served, such as one whose port was taken,
+			// must not replace an earlier session with an empty one
+			session := proxy.Session()
+			if serveErr != nil && len(session.Exchanges) == 0 {
+				return serveErr
+			}
+			if err := session.Save(sessionPath); err != nil {
+				return err
+			}
+			opts.logger.Info("session saved", "exchanges", len(session.Exchanges), "path", sessionPath)
+			return serveErr
+		},
+	}
+
+	replay := &cobra.Command{
+		Use:   "replay",
+		Short: "Serve recorded responses from a session file",
+		Long: `Listen for JSON-RPC requests and answer them from a recorded session without
+contacting any endpoint. Calls are matched by method and params; calls that
+were recorded several times are answered in recording order.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			session, err := LoadProxySession(sessionPath)
+			if err != nil {
+				return err
+			}
+
+			proxy := NewReplayProxy(session, exchangeLogger(cmd, opts))
+			opts.logger.Info("replaying", "exchanges", len(session.Exchanges), "path", sessionPath, "listen", listen)
+			return serveUntilInterrupted(listen, proxy)
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Run a local JSON-RPC proxy that records or replays traffic",
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&listen, "listen", "127.0.0.1:8545", "address to listen on")
+	flags.StringVar(&sessionPath, "session", "session.json", "session file to record to or replay from")
+
+	cmd.AddCommand(record, replay)
+	return cmd
+}
+
+// checkpointInterval is how often proxy record saves the session so far
+const checkpointInterval = 5 * time.Second
+
+// serveUntilInterrupted serves handler until interrupted or terminated
+func serveUntilInterrupted(listen string, handler http.Handler) error {
+	// Servers run until interrupted, so the global timeout does not apply
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return ServeUntilDone(ctx, listen, handler)
+}
+
+// exchangeLogger streams one record per proxied call; handlers run
+// concurrently, so writes are serialized
+func exchangeLogger(cmd *cobra.Command, opts *globalOptions) ExchangeHook {
+	var mu sync.Mutex
+	stream := NewRecordStream(cmd.OutOrStdout(), opts.output)
+
+	return func(e ProxyExchange) {
+		status := "ok"
+		if e.Error != nil {
+			status = fmt.Sprintf("error %d", e.Error.Code)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		stream.Write(Record{
+			{"method", e.Method},
+			{"status", status},
+			{"duration_ms", e.DurationMs},
+		})
+	}
+}
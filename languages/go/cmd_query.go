@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// newQueryCommand builds the query subcommand and its read-only queries
+func newQueryCommand(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query chain data from the endpoint",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "block-number",
+			Short: "Print the latest block number",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				client, err := opts.client()
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				ctx, cancel := opts.context()
+				defer cancel()
+
+				n, err := client.GetBlockNumber(ctx)
+				if err != nil {
+					return err
+				}
+				return opts.write(cmd, nil, Record{{"block_number", n.Uint64()}})
+			},
+		},
+		&cobra.Command{
+			Use:   "chain-id",
+			Short: "Print the chain ID",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				client, err := opts.client()
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				ctx, cancel := opts.context()
+				defer cancel()
+
+				id, err := client.GetChainID(ctx)
+				if err != nil {
+					return err
+				}
+				return opts.write(cmd, nil, Record{{"chain_id", id.Uint64()}})
+			},
+		},
+		&cobra.Command{
+			Use:   "gas-price",
+			Short: "Print the suggested gas price in wei",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				client, err := opts.client()
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				ctx, cancel := opts.context()
+				defer cancel()
+
+				price, err := client.GetGasPrice(ctx)
+				if err != nil {
+					return err
+				}
+				return opts.write(cmd, nil, Record{{"gas_price_wei", price.String()}})
+			},
+		},
+		&cobra.Command{
+			Use:   "balance ADDRESS",
+			Short: "Print the balance of an address in wei",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if !common.IsHexAddress(args[0]) {
+					return fmt.Errorf("invalid address %q", args[0])
+				}
+				client, err := opts.client()
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				ctx, cancel := opts.context()
+				defer cancel()
+
+				address := common.HexToAddress(args[0])
+				balance, err := client.GetBalance(ctx, address)
+				if err != nil {
+					return err
+				}
+				return opts.write(cmd, nil, Record{
+					{"address", address.Hex()},
+					{"balance_wei", balance.String()},
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "block [NUMBER|TAG]",
+			Short: "Print a block header summary (default latest)",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				ref := "latest"
+				if len(args) == 1 {
+					ref = args[0]
+					if n, err := strconv.ParseUint(ref, 10, 64); err == nil {
+						ref = hexutil.EncodeUint64(n)
+					}
+				}
+				client, err := opts.client()
+				if err != nil {
+					return err
+				}
+				defer client.Close()
+				ctx, cancel := opts.context()
+				defer cancel()
+
+				raw, err := client.CallRaw(ctx, "eth_getBlockByNumber", ref, false)
+				if err != nil {
+					return err
+				}
+				var block queriedBlock
+				if err := json.Unmarshal(raw, &block); err != nil {
+					return fmt.Errorf("failed to decode block: %w", err)
+				}
+				if block.Hash == (common.Hash{}) {
+					return fmt.Errorf("block %s not found", ref)
+				}
+				return opts.write(cmd, raw, block.record())
+			},
+		},
+	)
+
+	return cmd
+}
+
+// queriedBlock is the subset of a block printed by query block
+type queriedBlock struct {
+	Number        hexutil.Uint64 `json:"number"`
+	Hash          common.Hash    `json:"hash"`
+	ParentHash    common.Hash    `json:"parentHash"`
+	Timestamp     hexutil.Uint64 `json:"timestamp"`
+	Miner         common.Address `json:"miner"`
+	GasUsed       hexutil.Uint64 `json:"gasUsed"`
+	GasLimit      hexutil.Uint64 `json:"gasLimit"`
+	BaseFeePerGas *hexutil.Big   `json:"baseFeePerGas"`
+	Transactions  []common.Hash  `json:"transactions"`
+}
+
+// record converts the block into output fields
+func (b queriedBlock) record() Record {
+	baseFee := ""
+	if b.BaseFeePerGas != nil {
+		baseFee = b.BaseFeePerGas.ToInt().String()
+	}
+	return Record{
+		{"number", uint64(b.Number)},
+		{"hash", b.Hash.Hex()},
+		{"parent_hash", b.ParentHash.Hex()},
+		{"timestamp", uint64(b.Timestamp)},
+		{"miner", b.Miner.Hex()},
+		{"gas_used", uint64(b.GasUsed)},
+		{"gas_limit", uint64(b.GasLimit)},
+		{"base_fee_per_gas_wei", baseFee},
+		{"transaction_count", len(b.Transactions)},
+	}
+}
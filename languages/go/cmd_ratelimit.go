@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newRateLimitCommand builds the ratelimit subcommand, which profiles how the
+// endpoint enforces its rate limit
+func newRateLimitCommand(opts *globalOptions) *cobra.Command {
+	var maxRPS int
+	var maxWindow time.Duration
+	var savePath string
+
+	cmd := &cobra.Command{
+		Use:   "ratelimit",
+		Short: "Find and characterize the endpoint's rate limit",
+		Long: `Deliberately exceed the endpoint's rate limit, doubling the request rate every
+second up to --max-rps, then report how the limit is signalled (HTTP 429 or a
+JSON-RPC error code), the Retry-After it sends, how long it takes to recover,
+and whether batch entries count individually. An endpoint still limiting after
+--max-window is reported with a window of at least that long. Only run it
+against endpoints you are allowed to load.
+
+--save writes the profile as JSON. Pass it back with --rate-limit-profile and
+calls the endpoint rate limits are retried after the profiled wait:
+
+  megaeth-rpc-tester ratelimit --save limits.json
+  megaeth-rpc-tester --rate-limit-profile limits.json bench -n 10000 -C 100`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if maxRPS <= 0 {
+				return fmt.Errorf("--max-rps must be positive")
+			}
+			if maxWindow <= 0 {
+				return fmt.Errorf("--max-window must be positive")
+			}
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			// Retrying would hide the very limit being measured
+			client.SetRateLimit(nil)
+
+			// Waiting out the limit can take longer than --timeout
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			opts.logger.Info("probing rate limit", "endpoint", client.DisplayURL(), "max_rps", maxRPS)
+			profile, err := client.ProbeRateLimit(ctx, maxRPS, maxWindow)
+			if err != nil {
+				return err
+			}
+			if savePath != "" {
+				if err := profile.Save(savePath); err != nil {
+					return err
+				}
+			}
+			window := profile.Window.Round(time.Millisecond).String()
+			if profile.WindowAtLeast {
+				window = "≥ " + window
+			}
+			return opts.write(cmd, nil, Record{
+				{"endpoint", profile.Endpoint},
+				{"limited", profile.Limited},
+				{"signal", profile.Signal},
+				{"http_status", profile.HTTPStatus},
+				{"error_code", profile.ErrorCode},
+				{"error_message", Redact(profile.ErrorMessage)},
+				{"limit_rps", profile.LimitRPS},
+				{"retry_after", profile.RetryAfter.Round(time.Millisecond).String()},
+				{"window", window},
+				{"batch_counts_entries", profile.BatchCountsEntry},
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&maxRPS, "max-rps", 256, "highest request rate to try before concluding the endpoint is not limited")
+	cmd.Flags().DurationVar(&maxWindow, "max-window", 2*time.Minute, "longest to wait for the limit to lift before reporting the window as at least this long")
+	cmd.Flags().StringVar(&savePath, "save", "", "write the profile as JSON to this path")
+
+	return cmd
+}
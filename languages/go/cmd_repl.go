@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+)
+
+// replHistoryFile is stored in the user's home directory
+const replHistoryFile = ".megaeth-rpc-tester_history"
+
+// replExtraMethods complete alongside the schema-backed methods
+var replExtraMethods = []string{
+	"debug_traceTransaction",
+	"debug_traceCall",
+	"eth_sendRawTransaction",
+	"net_version",
+	"net_peerCount",
+	"txpool_status",
+	"web3_clientVersion",
+}
+
+// replCommands are the REPL's own commands
+var replCommands = map[string]string{
+	".help":     "show this help",
+	".last":     "pretty-print the last response",
+	".time":     "toggle printing the latency of each call",
+	".endpoint": "show the endpoint in use",
+	".exit":     "leave the REPL",
+}
+
+// repl holds the state of an interactive session
+type repl struct {
+	opts     *globalOptions
+	client   *RPCClient
+	out      io.Writer
+	last     json.RawMessage
+	showTime bool
+}
+
+// newReplCommand builds the repl subcommand
+func newReplCommand(opts *globalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "repl",
+		Short: "Start an interactive prompt for issuing RPC calls",
+		Long: `Start an interactive prompt against the configured endpoint.
+
+Enter a JSON-RPC method followed by its parameters, for example
+  eth_getBalance 0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045 latest
+Parameters that parse as JSON are sent as-is; anything else is sent as a string.
+Query subcommands such as "block latest" or "balance ADDRESS" work too.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			s := &repl{opts: opts, client: client, out: cmd.OutOrStdout(), showTime: true}
+			return s.run()
+		},
+	}
+}
+
+// run reads and evaluates lines until EOF or .exit
+func (s *repl) run() error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(s.completer())
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, replHistoryFile)
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+	defer func() {
+		if historyPath == "" {
+			return
+		}
+		if f, err := os.Create(historyPath); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	fmt.Fprintf(s.out, "Connected to %s. Type .help for commands.\n", s.client.DisplayURL())
+	for {
+		input, err := line.Prompt("rpc> ")
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if err != nil {
+			// EOF (Ctrl-D) ends the session
+			fmt.Fprintln(s.out)
+			return nil
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		line.AppendHistory(input)
+
+		if input == ".exit" || input == ".quit" {
+			return nil
+		}
+		if err := s.eval(input); err != nil {
+			fmt.Fprintln(s.out, "Error:", Redact(err.Error()))
+		}
+	}
+}
+
+// eval runs one line of input
+func (s *repl) eval(input string) error {
+	fields := strings.Fields(input)
+
+	switch fields[0] {
+	case ".help":
+		names := make([]string, 0, len(replCommands))
+		for name := range replCommands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(s.out, "  %-10s %s\n", name, replCommands[name])
+		}
+		fmt.Fprintln(s.out, "  <method> [params...]  call a JSON-RPC method")
+		fmt.Fprintln(s.out, "  <query> [args...]     run a query subcommand, e.g. block latest")
+		return nil
+	case ".last":
+		if s.last == nil {
+			return fmt.Errorf("no response yet")
+		}
+		return s.print(s.last)
+	case ".time":
+		s.showTime = !s.showTime
+		if s.showTime {
+			fmt.Fprintln(s.out, "timing on")
+		} else {
+			fmt.Fprintln(s.out, "timing off")
+		}
+		return nil
+	case ".endpoint":
+		fmt.Fprintln(s.out, s.client.DisplayURL())
+		return nil
+	}
+
+	if strings.HasPrefix(fields[0], ".") {
+		return fmt.Errorf("unknown command %s", fields[0])
+	}
+
+	start := time.Now()
+	var err error
+	if s.isQuery(fields[0]) {
+		err = s.runQuery(fields)
+	} else {
+		err = s.call(fields[0], fields[1:])
+	}
+	if s.showTime {
+		fmt.Fprintf(s.out, "(%s)\n", time.Since(start).Round(time.Microsecond))
+	}
+	return err
+}
+
+// call issues a raw JSON-RPC request
+func (s *repl) call(method string, args []string) error {
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		params[i] = parseParam(arg)
+	}
+
+	ctx, cancel := s.opts.context()
+	defer cancel()
+
+	raw, err := s.client.CallRaw(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+	s.last = raw
+	return s.print(raw)
+}
+
+// isQuery reports whether name is a query subcommand
+func (s *repl) isQuery(name string) bool {
+	for _, sub := range newQueryCommand(s.opts).Commands() {
+		if sub.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runQuery runs a query subcommand with the session's options
+func (s *repl) runQuery(args []string) error {
+	query := newQueryCommand(s.opts)
+	query.SetArgs(args)
+	query.SetOut(s.out)
+	query.SilenceUsage = true
+	query.SilenceErrors = true
+	return query.Execute()
+}
+
+// print pretty-prints a raw response
+func (s *repl) print(raw json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, string(out))
+	return nil
+}
+
+// completer completes method names, query subcommands, and REPL commands
+func (s *repl) completer() liner.Completer {
+	candidates := append([]string{}, replExtraMethods...)
+	if validator, err := NewSchemaValidator(); err == nil {
+		candidates = append(candidates, validator.Methods()...)
+	}
+	for _, sub := range newQueryCommand(s.opts).Commands() {
+		candidates = append(candidates, sub.Name())
+	}
+	for name := range replCommands {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	return func(line string) []string {
+		if strings.Contains(line, " ") {
+			return nil
+		}
+		var matches []string
+		for _, c := range candidates {
+			if strings.HasPrefix(c, line) {
+				matches = append(matches, c)
+			}
+		}
+		return matches
+	}
+}
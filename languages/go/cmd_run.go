@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newRunCommand builds the run subcommand for scripted call sequences
+func newRunCommand(opts *globalOptions) *cobra.Command {
+	var varArgs []string
+
+	cmd := &cobra.Command{
+		Use:   "run FILE",
+		Short: "Run a scripted sequence of JSON-RPC calls",
+		Long: `Run the steps of a YAML or JSON script in order and report each one. A step
+can capture values from its result into variables, refer to variables in its
+params as ${name}, check result values with expect, and wait for a non-null
+result. Steps after a failure are skipped unless continue_on_failure is set:
+
+  vars:
+    raw: "0x02f8..."
+  steps:
+    - name: send
+      method: eth_sendRawTransaction
+      params: ["${raw}"]
+      capture: {hash: "$"}
+    - name: receipt
+      method: eth_getTransactionReceipt
+      params: ["${hash}"]
+      wait: 30s
+      expect: {"$.status": "0x1"}
+
+--var name=value sets or overrides a variable.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			script, err := LoadScript(args[0])
+			if err != nil {
+				return err
+			}
+			vars := make(map[string]interface{}, len(varArgs))
+			for _, arg := range varArgs {
+				name, value, ok := strings.Cut(arg, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("--var %q is not in name=value form", arg)
+				}
+				vars[name] = parseParam(value)
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			// Steps may wait far longer than --timeout, so the run lasts until
+			// it finishes or is interrupted
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			results := client.RunScript(ctx, script, vars, nil)
+
+			records := make([]Record, len(results))
+			passed := 0
+			for i, r := range results {
+				if r.Status == StatusPass {
+					passed++
+				}
+				records[i] = Record{
+					{"step", r.Name},
+					{"method", r.Method},
+					{"status", r.Status},
+					{"duration_ms", r.DurationMs},
+					{"captured", formatCaptured(r.Captured)},
+					{"detail", r.Detail},
+				}
+			}
+			if err := opts.write(cmd, nil, records...); err != nil {
+				return err
+			}
+			if passed != len(results) {
+				return withExitCode(ExitCheckFailure, fmt.Errorf("%d of %d steps did not pass", len(results)-passed, len(results)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&varArgs, "var", nil, "script variable as name=value (repeatable)")
+
+	return cmd
+}
+
+// formatCaptured renders captured variables as sorted name=value pairs
+func formatCaptured(captured map[string]interface{}) string {
+	names := make([]string, 0, len(captured))
+	for name := range captured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%v", name, captured[name])
+	}
+	return strings.Join(pairs, " ")
+}
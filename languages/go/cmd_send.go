@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// newSendCommand builds the send subcommand, which previews a transaction,
+// asks for confirmation, submits it, and follows it to inclusion
+func newSendCommand(opts *globalOptions) *cobra.Command {
+	var wait, yes, nodeSend bool
+	var data string
+	var gasLimit uint64
+	var waitTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "send TO AMOUNT_WEI",
+		Short: "Preview, confirm, and send a signed transaction",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !common.IsHexAddress(args[0]) {
+				return fmt.Errorf("invalid address %q", args[0])
+			}
+			to := common.HexToAddress(args[0])
+			value, ok := new(big.Int).SetString(args[1], 10)
+			if !ok || value.Sign() < 0 {
+				return fmt.Errorf("invalid amount %q", args[1])
+			}
+			var calldata []byte
+			if data != "" {
+				var err error
+				if calldata, err = hexutil.Decode(data); err != nil {
+					return fmt.Errorf("invalid --data: %w", err)
+				}
+			}
+			if !opts.hasSigner() {
+				return fmt.Errorf("a signer is required; pass --key, --ledger, --node-account, or --transit-key")
+			}
+			if nodeSend && opts.nodeAccount == "" {
+				return fmt.Errorf("--node-send requires --node-account")
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			ctx, cancel := opts.context()
+			defer cancel()
+
+			// The preview and a dry run use the unsigned transaction, so the
+			// signer is only asked once the user confirms
+			tx, chainID, err := client.PrepareTransaction(ctx, TxRequest{To: &to, Value: value, Data: calldata, Gas: gasLimit})
+			if err != nil {
+				return err
+			}
+			if opts.profile != nil && opts.profile.ChainID != 0 && chainID.Uint64() != opts.profile.ChainID {
+				return fmt.Errorf("endpoint reports chain %s but network %s is chain %d", chainID, opts.network, opts.profile.ChainID)
+			}
+			if err := opts.write(cmd, nil, DescribeTransaction(tx, chainID, client.GetAddress())); err != nil {
+				return err
+			}
+
+			if opts.dryRun {
+				sim := client.SimulateTransaction(ctx, tx, client.GetAddress())
+				if err := opts.write(cmd, nil, sim.Record()); err != nil {
+					return err
+				}
+				opts.logger.Info("dry run: transaction not sent")
+				if sim.Err != nil {
+					return fmt.Errorf("transaction would fail: %s", Redact(sim.Err.Error()))
+				}
+				return nil
+			}
+
+			if !yes && !confirm(cmd, "Send this transaction?") {
+				return fmt.Errorf("aborted")
+			}
+
+			// With --node-send the node signs as it sends
+			var hash common.Hash
+			if nodeSend {
+				signer, _ := client.signer.(*NodeSigner)
+				if hash, err = signer.SendTransaction(ctx, tx, chainID); err != nil {
+					return err
+				}
+			} else {
+				if tx, err = client.signer.SignTx(ctx, tx, chainID); err != nil {
+					return err
+				}
+				if err := client.BroadcastTransaction(ctx, tx); err != nil {
+					return err
+				}
+				hash = tx.Hash()
+			}
+			if opts.profile != nil {
+				if link := opts.profile.TxURL(hash.Hex()); link != "" {
+					opts.logger.Info("view on explorer", "url", link)
+				}
+			}
+			if !wait {
+				return nil
+			}
+
+			// Inclusion can take far longer than the per-command RPC timeout
+			waitCtx, waitCancel := context.WithTimeout(context.Background(), waitTimeout)
+			defer waitCancel()
+
+			lastState := ""
+			receipt, err := client.WaitForReceipt(waitCtx, hash, time.Second, func(s ReceiptStatus) {
+				if s.State != lastState {
+					opts.logger.Info("waiting for receipt", "tx_hash", hash.Hex(), "state", s.State, "elapsed", s.Elapsed.Round(time.Second))
+					lastState = s.State
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			status := "success"
+			if receipt.Status == 0 {
+				status = "reverted"
+			}
+			opts.events.Publish(Event{
+				Kind:     EventTxConfirmed,
+				Source:   "send",
+				Endpoint: client.DisplayURL(),
+				TxHash:   receipt.TxHash.Hex(),
+				Message:  fmt.Sprintf("%s in block %d", status, receipt.BlockNumber.Uint64()),
+			})
+			if err := opts.write(cmd, nil, Record{
+				{"hash", receipt.TxHash.Hex()},
+				{"status", status},
+				{"block", receipt.BlockNumber.Uint64()},
+				{"gas_used", receipt.GasUsed},
+				{"effective_gas_price_wei", receipt.EffectiveGasPrice.String()},
+			}); err != nil {
+				return err
+			}
+			if receipt.Status == 0 {
+				return fmt.Errorf("transaction %s reverted", receipt.TxHash.Hex())
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&wait, "wait", true, "wait for the transaction to be included")
+	flags.BoolVarP(&yes, "yes", "y", false, "send without asking for confirmation")
+	flags.StringVar(&data, "data", "", "hex calldata to include")
+	flags.BoolVar(&nodeSend, "node-send", false, "have the node sign and submit with eth_sendTransaction alone, for nodes without eth_signTransaction (requires --node-account)")
+	flags.Uint64Var(&gasLimit, "gas-limit", 0, "gas limit (default estimated)")
+	flags.DurationVar(&waitTimeout, "wait-timeout", 5*time.Minute, "how long to wait for inclusion")
+
+	return cmd
+}
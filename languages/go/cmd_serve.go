@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// envServeToken holds the bearer token for the serve API
+const envServeToken = "SERVE_TOKEN"
+
+// newServeCommand builds the serve subcommand
+func newServeCommand(opts *globalOptions) *cobra.Command {
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a REST API for starting and following test runs",
+		Long: `Serve a REST API that starts conformance and script runs in the background,
+reports their live progress, stops them, and returns their JSON reports:
+
+  GET  /runs              list runs
+  POST /runs              start a run
+  GET  /runs/{id}         live status
+  POST /runs/{id}/stop    cancel a run
+  GET  /runs/{id}/report  the finished report
+
+A run is started with a body such as {"kind": "conformance", "checks": ["schema"]}
+or {"kind": "script", "script_file": "smoke.yaml", "endpoint": "megaeth-testnet"}.
+When ` + envServeToken + ` is set, requests must send it as a bearer token.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token := os.Getenv(envServeToken)
+			RegisterSecret(token)
+
+			server := NewRunServer(opts.clientFor, token)
+			opts.logger.Info("serving the run API", "listen", listen)
+			return serveUntilInterrupted(listen, server)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8080", "address to listen on")
+
+	return cmd
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newSoakCommand builds the soak subcommand, a long-running transaction load
+// whose accounts can be rotated without stopping it
+func newSoakCommand(opts *globalOptions) *cobra.Command {
+	hd := &hdOptions{}
+	var interval, duration, rotateEvery, waitTimeout time.Duration
+	var rotationVault string
+	var batchSize int
+
+	cmd := &cobra.Command{
+		Use:   "soak",
+		Short: "Send a steady stream of transactions from an account pool for a long run",
+		Long: `Keep every account of a pool sending a zero-value transfer to itself, one in
+flight per account, every --interval until --duration passes or the run is
+interrupted. The pool is selected like fund's: from a mnemonic, --vault, or
+--seed. Fund it first.
+
+With --rotation-vault the accounts can be rotated mid-run, on a schedule with
+--rotate-every or at once on SIGHUP. Rotation stops sending, drains the
+in-flight transfers, generates a fresh set into the rotation vault, sweeps
+each old account's balance to its replacement, and carries on with the fresh
+set. Use it to retire keys that may have been exposed during a week-long soak.
+An account whose sweep does not land is kept, so funds are never stranded.
+
+A transfer still in flight after --wait-timeout is replaced with higher fees
+at the same nonce, or written off as dropped if the account's nonce has moved
+past it. A rotation waits until no transfers are stuck.
+
+  MNEMONIC="..." megaeth-rpc-tester soak --count 20 --rotation-vault rotated.vault --rotate-every 24h
+  kill -HUP <pid>`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 || waitTimeout <= 0 {
+				return fmt.Errorf("--interval and --wait-timeout must be positive")
+			}
+			if rotateEvery > 0 && rotationVault == "" {
+				return fmt.Errorf("--rotate-every needs --rotation-vault to keep the fresh keys")
+			}
+			accounts, err := hd.derive()
+			if err != nil {
+				return err
+			}
+
+			// Read the password up front: nobody is at the prompt mid-run
+			var password string
+			if rotationVault != "" {
+				_, err := os.Stat(rotationVault)
+				if password, err = readPassword(hd.passwordFile, errors.Is(err, os.ErrNotExist)); err != nil {
+					return err
+				}
+			}
+
+			client, err := opts.client()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			rotate := make(chan os.Signal, 1)
+			if rotationVault != "" {
+				signal.Notify(rotate, syscall.SIGHUP)
+				defer signal.Stop(rotate)
+			}
+
+			opts.logger.Info("soak started", "accounts", len(accounts), "interval", interval, "pid", os.Getpid())
+			result := client.Soak(ctx, accounts, SoakOptions{
+				Interval:    interval,
+				Duration:    duration,
+				RotateEvery: rotateEvery,
+				Rotate:      rotate,
+				WaitTimeout: waitTimeout,
+				BatchSize:   batchSize,
+				FreshAccounts: func(n int) ([]HDAccount, error) {
+					return GrowVault(rotationVault, password, n, false)
+				},
+				Source: "soak",
+				Events: opts.events,
+			})
+
+			first, last := "", ""
+			if len(result.Accounts) > 0 {
+				first, last = result.Accounts[0].Path, result.Accounts[len(result.Accounts)-1].Path
+			}
+			return opts.write(cmd, nil, Record{
+				{"sent", result.Sent},
+				{"confirmed", result.Confirmed},
+				{"reverted", result.Reverted},
+				{"failed", result.Failed},
+				{"replaced", result.Replaced},
+				{"dropped", result.Dropped},
+				{"rotations", result.Rotations},
+				{"first_account", first},
+				{"last_account", last},
+			})
+		},
+	}
+
+	hd.register(cmd)
+	flags := cmd.Flags()
+	flags.DurationVar(&interval, "interval", 5*time.Second, "time between rounds of transfers")
+	flags.DurationVar(&duration, "duration", 0, "how long to run (default until interrupted)")
+	flags.DurationVar(&rotateEvery, "rotate-every", 0, "rotate the accounts on this schedule (requires --rotation-vault)")
+	flags.StringVar(&rotationVault, "rotation-vault", "", "encrypted vault that receives the fresh keys of each rotation; enables rotation on SIGHUP")
+	flags.DurationVar(&waitTimeout, "wait-timeout", 5*time.Minute, "how long to wait for in-flight transfers when rotating or stopping, and before replacing a stuck one")
+	flags.IntVar(&batchSize, "batch-size", 20, "transactions per JSON-RPC batch")
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// newStatusCommand builds the status subcommand reporting endpoint health scores
+func newStatusCommand(opts *globalOptions) *cobra.Command {
+	var window time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "status [ENDPOINT...]",
+		Short: "Show rolling health scores for the endpoints tested on this machine",
+		Long: `Summarize the health history recorded by bench, compare, and conformance
+runs. Each endpoint is scored from 0 to 1 as availability × (1 − error rate)
+× conformance pass rate over --window, best first; mean p95 latency is shown
+beside the score. ENDPOINT is a URL or a network profile name and limits the
+view to those endpoints:
+
+  megaeth-rpc-tester status --window 720h megaeth-testnet`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if window <= 0 {
+				return fmt.Errorf("--window must be positive")
+			}
+			path, err := opts.scoreboard()
+			if err != nil {
+				return err
+			}
+			board, err := LoadScoreboard(path)
+			if err != nil {
+				return err
+			}
+
+			wanted := opts.endpointFilter(args)
+			var records []Record
+			for _, s := range board.Scores(time.Now().Add(-window)) {
+				if len(wanted) > 0 && !wanted[s.Endpoint] {
+					continue
+				}
+				records = append(records, Record{
+					{"endpoint", s.Endpoint},
+					{"score", s.Score},
+					{"availability", s.Availability},
+					{"error_rate", s.ErrorRate},
+					{"mean_p95_ms", s.MeanP95Ms},
+					{"conformance_pass_rate", s.ConformancePass},
+					{"samples", s.Samples},
+					{"last_seen", s.LastSeen.Format(time.RFC3339)},
+				})
+			}
+			if len(records) == 0 {
+				return fmt.Errorf("no health samples in the last %s (record some with bench, compare, or conformance)", window)
+			}
+			return opts.write(cmd, nil, records...)
+		},
+	}
+
+	cmd.Flags().DurationVar(&window, "window", 7*24*time.Hour, "only score samples newer than this")
+	return cmd
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// watchOptions are shared by the watch subcommands
+type watchOptions struct {
+	interval   time.Duration
+	wsURL      string
+	poll       bool
+	stallAfter time.Duration
+
+	finalityStallAfter time.Duration
+}
+
+// subscriptionURL returns the WebSocket URL to subscribe on, or "" to poll
+func (w *watchOptions) subscriptionURL(opts *globalOptions) string {
+	if w.poll {
+		return ""
+	}
+	if w.wsURL != "" {
+		return w.wsURL
+	}
+	if opts.profile != nil {
+		return opts.profile.WSURL
+	}
+	return ""
+}
+
+// newWatchCommand builds the watch subcommand and its event streams
+func newWatchCommand(opts *globalOptions) *cobra.Command {
+	wopts := &watchOptions{}
+
+	heads := &cobra.Command{
+		Use:   "heads",
+		Short: "Stream new blocks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd, opts, wopts, func(ctx context.Context, client *RPCClient, emit WatchFunc) error {
+				if wopts.stallAfter > 0 {
+					emit = watchForStall(ctx, opts, client.DisplayURL(), wopts.stallAfter, emit)
+				}
+				if wopts.finalityStallAfter > 0 {
+					go watchFinalityStall(ctx, opts, client, wopts.finalityStallAfter)
+				}
+				return client.WatchHeads(ctx, wopts.subscriptionURL(opts), wopts.interval, emit)
+			}, func(e WatchEvent) Record {
+				return Record{
+					{"block", e.BlockNumber},
+					{"hash", e.BlockHash.Hex()},
+					{"txs", e.TxCount},
+					{"latency_ms", latencyMillis(e.Latency)},
+					{"source", e.Source},
+				}
+			})
+		},
+	}
+
+	var addresses, topics []string
+	logs := &cobra.Command{
+		Use:   "logs",
+		Short: "Stream logs matching --address and --topic",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := ethereum.FilterQuery{}
+			for _, a := range addresses {
+				if !common.IsHexAddress(a) {
+					return fmt.Errorf("invalid address %q", a)
+				}
+				query.Addresses = append(query.Addresses, common.HexToAddress(a))
+			}
+			if len(topics) > 0 {
+				var topic0 []common.Hash
+				for _, t := range topics {
+					topic0 = append(topic0, common.HexToHash(t))
+				}
+				query.Topics = [][]common.Hash{topic0}
+			}
+
+			return runWatch(cmd, opts, wopts, func(ctx context.Context, client *RPCClient, emit WatchFunc) error {
+				return client.WatchLogs(ctx, wopts.subscriptionURL(opts), wopts.interval, query, emit)
+			}, func(e WatchEvent) Record {
+				return Record{
+					{"block", e.BlockNumber},
+					{"tx", e.TxHash.Hex()},
+					{"log_index", e.LogIndex},
+					{"address", e.Address.Hex()},
+					{"topic0", e.Topic0.Hex()},
+					{"latency_ms", latencyMillis(e.Latency)},
+					{"source", e.Source},
+				}
+			})
+		},
+	}
+	logs.Flags().StringArrayVar(&addresses, "address", nil, "only logs emitted by this contract (repeatable)")
+	logs.Flags().StringArrayVar(&topics, "topic", nil, "only logs whose first topic matches (repeatable)")
+
+	pending := &cobra.Command{
+		Use:   "pending",
+		Short: "Stream pending transaction hashes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd, opts, wopts, func(ctx context.Context, client *RPCClient, emit WatchFunc) error {
+				return client.WatchPending(ctx, wopts.subscriptionURL(opts), wopts.interval, emit)
+			}, func(e WatchEvent) Record {
+				return Record{
+					{"tx", e.TxHash.Hex()},
+					{"received", e.Received.UTC().Format(time.RFC3339Nano)},
+					{"source", e.Source},
+				}
+			})
+		},
+	}
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Tail live chain activity (heads by default)",
+		Long: `Tail live chain activity. Events arrive over a WebSocket subscription when the
+endpoint, --ws, or the network profile provides one, and by polling otherwise.
+Latency is measured from the block timestamp to when the event was received.
+With --stall-after, heads warns and alerts the --alert sinks when no new
+block arrives in time, repeating every --alert-cooldown until blocks resume.
+--finality-stall-after does the same for the finalized block tag.`,
+		Args: cobra.NoArgs,
+		RunE: heads.RunE,
+	}
+	flags := cmd.PersistentFlags()
+	flags.DurationVar(&wopts.interval, "interval", time.Second, "polling interval")
+	flags.StringVar(&wopts.wsURL, "ws", "", "WebSocket URL for subscriptions (default from the network profile)")
+	flags.BoolVar(&wopts.poll, "poll", false, "always poll instead of subscribing")
+	flags.DurationVar(&wopts.stallAfter, "stall-after", 0, "alert when heads sees no new block for this long (0 disables)")
+	flags.DurationVar(&wopts.finalityStallAfter, "finality-stall-after", 0, "alert when heads sees the finalized block not advance for this long (0 disables)")
+
+	cmd.AddCommand(heads, logs, pending)
+	return cmd
+}
+
+// runWatch runs a watcher until interrupted, rendering each event as a record
+func runWatch(cmd *cobra.Command, opts *globalOptions, wopts *watchOptions,
+	watch func(context.Context, *RPCClient, WatchFunc) error, toRecord func(WatchEvent) Record) error {
+	if wopts.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// watch runs until interrupted, so the global timeout does not apply
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stream := NewRecordStream(cmd.OutOrStdout(), opts.output)
+	return watch(ctx, client, func(e WatchEvent) error {
+		return stream.Write(toRecord(e))
+	})
+}
+
+// watchForStall raises a block-stall alert whenever no block has arrived for
+// stallAfter and resolves it when blocks resume. It returns emit wrapped to
+// note each block.
+func watchForStall(ctx context.Context, opts *globalOptions, endpoint string, stallAfter time.Duration, emit WatchFunc) WatchFunc {
+	const source, metric = "watch heads", "block stall"
+	var mu sync.Mutex
+	last := time.Now()
+	var lastBlock uint64
+	stalled := false
+
+	go func() {
+		check := stallAfter / 4
+		if check <= 0 {
+			check = stallAfter
+		}
+		ticker := time.NewTicker(check)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			mu.Lock()
+			since, block, wasStalled := time.Since(last), lastBlock, stalled
+			if since >= stallAfter {
+				stalled = true
+			}
+			mu.Unlock()
+			if since < stallAfter {
+				continue
+			}
+
+			if !wasStalled {
+				opts.logger.Warn("no new block", "endpoint", endpoint, "last_block", block, "for", since.Round(time.Second))
+			}
+			alert := &Alert{
+				Source:    source,
+				Endpoint:  endpoint,
+				Metric:    metric,
+				Value:     since.Round(time.Second).String(),
+				Threshold: stallAfter.String(),
+				Message:   fmt.Sprintf("no new block for %s after block %d", since.Round(time.Second), block),
+			}
+			opts.events.Publish(Event{Kind: EventThresholdBreached, Source: source, Endpoint: endpoint, Alert: alert, Message: alert.Message})
+		}
+	}()
+
+	return func(e WatchEvent) error {
+		mu.Lock()
+		last, lastBlock = time.Now(), e.BlockNumber
+		wasStalled := stalled
+		stalled = false
+		mu.Unlock()
+
+		if wasStalled {
+			opts.logger.Info("blocks resumed", "endpoint", endpoint, "block", e.BlockNumber)
+			opts.events.Publish(Event{
+				Kind:     EventThresholdCleared,
+				Source:   source,
+				Endpoint: endpoint,
+				Alert:    &Alert{Source: source, Endpoint: endpoint, Metric: metric},
+			})
+		}
+		return emit(e)
+	}
+}
+
+// watchFinalityStall polls the finalized tag until ctx is done, raising a
+// finalization-stall alert whenever it has not advanced for stallAfter and
+// resolving it when it moves again
+func watchFinalityStall(ctx context.Context, opts *globalOptions, client *RPCClient, stallAfter time.Duration) {
+	const source, metric = "watch heads", "finalization stall"
+	endpoint := client.DisplayURL()
+	var finalized uint64
+	last := time.Now()
+	stalled := false
+
+	check := stallAfter / 4
+	if check <= 0 {
+		check = stallAfter
+	}
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+	for {
+		block, err := client.getBlockSummary(ctx, "eth_getBlockByNumber", "finalized")
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			opts.logger.Warn("failed to fetch the finalized block", "endpoint", endpoint, "error", err)
+		case block == nil:
+			opts.logger.Warn("endpoint does not resolve the finalized tag", "endpoint", endpoint)
+			return
+		case uint64(block.Number) > finalized:
+			finalized, last = uint64(block.Number), time.Now()
+			if stalled {
+				stalled = false
+				opts.logger.Info("finalization resumed", "endpoint", endpoint, "finalized", finalized)
+				opts.events.Publish(Event{
+					Kind:     EventThresholdCleared,
+					Source:   source,
+					Endpoint: endpoint,
+					Alert:    &Alert{Source: source, Endpoint: endpoint, Metric: metric},
+				})
+			}
+		}
+
+		if since := time.Since(last); since >= stallAfter {
+			if !stalled {
+				opts.logger.Warn("finalized block not advancing", "endpoint", endpoint, "finalized", finalized, "for", since.Round(time.Second))
+			}
+			stalled = true
+			alert := &Alert{
+				Source:    source,
+				Endpoint:  endpoint,
+				Metric:    metric,
+				Value:     since.Round(time.Second).String(),
+				Threshold: stallAfter.String(),
+				Message:   fmt.Sprintf("finalized block stuck at %d for %s", finalized, since.Round(time.Second)),
+			}
+			opts.events.Publish(Event{Kind: EventThresholdBreached, Source: source, Endpoint: endpoint, Alert: alert, Message: alert.Message})
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// latencyMillis rounds a latency to whole milliseconds
+func latencyMillis(d time.Duration) int64 {
+	return int64(math.Round(float64(d) / float64(time.Millisecond)))
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultCometEndpoint is where a local Cosmos SDK node serves CometBFT RPC
+const DefaultCometEndpoint = "http://localhost:26657"
+
+// CometClient calls the CometBFT RPC of a Cosmos chain node, JSON-RPC 2.0
+// with named params. Calls go through the same logging, tracing, and metrics
+// transports as Ethereum calls, so the harness measures both alike.
+type CometClient struct {
+	// base holds the URL, headers, logger, and instrumented HTTP client; it
+	// is never dialed as an Ethereum client
+	base   *RPCClient
+	nextID atomic.Int64
+}
+
+// NewCometClient creates a client for the CometBFT RPC at rpcURL
+func NewCometClient(rpcURL string, headers http.Header) (*CometClient, error) {
+	u, err := url.Parse(rpcURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid CometBFT endpoint %q (want an http or https URL)", RedactURL(rpcURL))
+	}
+	base := &RPCClient{rpcURL: strings.TrimRight(rpcURL, "/"), headers: headers, logger: slog.Default()}
+	base.http = base.newHTTPClient()
+	return &CometClient{base: base}, nil
+}
+
+// SetLogger replaces the logger used for the client's calls
+func (c *CometClient) SetLogger(logger *slog.Logger) {
+	c.base.SetLogger(logger)
+}
+
+// SetSlowLog logs calls that take at least threshold, like RPCClient.SetSlowLog
+func (c *CometClient) SetSlowLog(threshold time.Duration, sample float64) {
+	c.base.SetSlowLog(threshold, sample)
+}
+
+// DisplayURL returns the endpoint URL with credentials redacted
+func (c *CometClient) DisplayURL() string {
+	return c.base.DisplayURL()
+}
+
+// Call performs a CometBFT RPC call and returns the undecoded result
+func (c *CometClient) Call(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range c.base.headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+
+	resp, err := c.base.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to call %s: HTTP %s", method, resp.Status)
+		}
+		return nil, fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if envelope.Error != nil {
+		// CometBFT puts the useful part of the error in data
+		var detail string
+		if json.Unmarshal(envelope.Error.Data, &detail) == nil && detail != "" {
+			return nil, fmt.Errorf("failed to call %s: %s: %s", method, envelope.Error.Message, detail)
+		}
+		return nil, fmt.Errorf("failed to call %s: %s (code %d)", method, envelope.Error.Message, envelope.Error.Code)
+	}
+	return envelope.Result, nil
+}
+
+// call performs a CometBFT RPC call and decodes its result into out
+func (c *CometClient) call(ctx context.Context, method string, params map[string]interface{}, out interface{}) error {
+	raw, err := c.Call(ctx, method, params)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// heightParams selects a block by height, the latest when height is zero
+func heightParams(height int64) map[string]interface{} {
+	if height <= 0 {
+		return nil
+	}
+	// CometBFT takes 64-bit integers as strings
+	return map[string]interface{}{"height": strconv.FormatInt(height, 10)}
+}
+
+// CometStatus is the subset of /status the tester reports
+type CometStatus struct {
+	NodeInfo struct {
+		ID      string `json:"id"`
+		Network string `json:"network"`
+		Version string `json:"version"`
+		Moniker string `json:"moniker"`
+	} `json:"node_info"`
+	SyncInfo struct {
+		LatestBlockHash     string    `json:"latest_block_hash"`
+		LatestBlockHeight   int64     `json:"latest_block_height,string"`
+		LatestBlockTime     time.Time `json:"latest_block_time"`
+		EarliestBlockHeight int64     `json:"earliest_block_height,string"`
+		CatchingUp          bool      `json:"catching_up"`
+	} `json:"sync_info"`
+}
+
+// Status returns the node's identity and sync state
+func (c *CometClient) Status(ctx context.Context) (*CometStatus, error) {
+	var status CometStatus
+	if err := c.call(ctx, "status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// CometBlock is the subset of /block the tester reports
+type CometBlock struct {
+	BlockID struct {
+		Hash string `json:"hash"`
+	} `json:"block_id"`
+	Block struct {
+		Header struct {
+			ChainID         string    `json:"chain_id"`
+			Height          int64     `json:"height,string"`
+			Time            time.Time `json:"time"`
+			ProposerAddress string    `json:"proposer_address"`
+		} `json:"header"`
+		Data struct {
+			// Txs are base64-encoded transaction bytes
+			Txs []string `json:"txs"`
+		} `json:"data"`
+	} `json:"block"`
+}
+
+// Block returns the block at height, the latest when height is zero
+func (c *CometClient) Block(ctx context.Context, height int64) (*CometBlock, error) {
+	var block CometBlock
+	if err := c.call(ctx, "block", heightParams(height), &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// CometTxResult is the outcome of executing one transaction
+type CometTxResult struct {
+	Code      uint32 `json:"code"`
+	Codespace string `json:"codespace"`
+	Log       string `json:"log"`
+	GasWanted int64  `json:"gas_wanted,string"`
+	GasUsed   int64  `json:"gas_used,string"`
+	// Events are only filled in by the calls that return them, such as
+	// tx_search
+	Events []CometABCIEvent `json:"events"`
+}
+
+// CometABCIEvent is one event emitted while executing a transaction
+type CometABCIEvent struct {
+	Type       string `json:"type"`
+	Attributes []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"attributes"`
+}
+
+// Attr returns the value of the attribute key, or "". CometBFT before 0.37
+// base64-encodes keys and values, so those are matched too.
+func (e CometABCIEvent) Attr(key string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(key))
+	for _, a := range e.Attributes {
+		switch a.Key {
+		case key:
+			return a.Value
+		case encoded:
+			value, _ := base64.StdEncoding.DecodeString(a.Value)
+			return string(value)
+		}
+	}
+	return ""
+}
+
+// CometBlockResults is the subset of /block_results the tester reports
+type CometBlockResults struct {
+	Height     int64           `json:"height,string"`
+	TxsResults []CometTxResult `json:"txs_results"`
+}
+
+// BlockResults returns the execution results of the block at height, the
+// latest when height is zero
+func (c *CometClient) BlockResults(ctx context.Context, height int64) (*CometBlockResults, error) {
+	var results CometBlockResults
+	if err := c.call(ctx, "block_results", heightParams(height), &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
+
+// CometTx is one transaction found by tx_search
+type CometTx struct {
+	Hash     string        `json:"hash"`
+	Height   int64         `json:"height,string"`
+	Index    uint32        `json:"index"`
+	TxResult CometTxResult `json:"tx_result"`
+}
+
+// CometTxSearch is one page of tx_search results
+type CometTxSearch struct {
+	Txs        []CometTx `json:"txs"`
+	TotalCount int       `json:"total_count,string"`
+}
+
+// TxSearch returns a page of the transactions matching query, an event
+// query such as "transfer.recipient='cosmos1...'". page counts from 1.
+func (c *CometClient) TxSearch(ctx context.Context, query string, page, perPage int, ascending bool) (*CometTxSearch, error) {
+	order := "desc"
+	if ascending {
+		order = "asc"
+	}
+	params := map[string]interface{}{
+		"query":    query,
+		"prove":    false,
+		"page":     strconv.Itoa(page),
+		"per_page": strconv.Itoa(perPage),
+		"order_by": order,
+	}
+	var result CometTxSearch
+	if err := c.call(ctx, "tx_search", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CometEvent is one event delivered to a subscription
+type CometEvent struct {
+	Query string `json:"query"`
+	Data  struct {
+		// Type is e.g. tendermint/event/NewBlock or tendermint/event/Tx
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"data"`
+	// Events are the ABCI event attributes, keyed by type.attribute
+	Events map[string][]string `json:"events"`
+}
+
+// Subscribe streams the events matching query, such as "tm.event='NewBlock'",
+// over the node's /websocket endpoint to handle until ctx is done or the
+// connection fails
+func (c *CometClient) Subscribe(ctx context.Context, query string, handle func(CometEvent)) error {
+	wsURL := strings.Replace(c.base.rpcURL, "http", "ws", 1) + "/websocket"
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, c.base.headers)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock the read loop when the caller is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	id := c.nextID.Add(1)
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  "subscribe",
+		"params":  map[string]interface{}{"query": query},
+	}
+	if err := conn.WriteJSON(request); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	c.base.logger.Debug("subscribed", "endpoint", c.DisplayURL(), "query", query)
+
+	for {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *rpcError       `json:"error"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("subscription to %q ended: %w", query, err)
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("subscription to %q failed: %s (code %d)", query, msg.Error.Message, msg.Error.Code)
+		}
+
+		// The subscribe reply and events share the request id; the reply's
+		// result is an empty object
+		var event CometEvent
+		if err := json.Unmarshal(msg.Result, &event); err != nil || event.Query == "" {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		handle(event)
+	}
+}
+
+// Bench issues requests calls of method with the given concurrency and
+// measures the latency distribution of the successful ones
+func (c *CometClient) Bench(ctx context.Context, method string, params map[string]interface{}, requests, concurrency int, stats *LiveStats) BenchResult {
+	sample := sampleCalls(ctx, c.base.logger, c.DisplayURL(), method, requests, concurrency, stats, func(ctx context.Context) error {
+		_, err := c.Call(ctx, method, params)
+		return err
+	})
+	return summarizeBench(method, sample)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadScenario reads a request stream from a YAML or JSON file holding a
+// list of {method, params} entries
+func LoadScenario(path string) ([]DiffRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	var requests []DiffRequest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &requests)
+	default:
+		err = yaml.Unmarshal(data, &requests)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("scenario %s has no requests", path)
+	}
+	for i, req := range requests {
+		if req.Method == "" {
+			return nil, fmt.Errorf("scenario entry %d has no method", i)
+		}
+	}
+
+	return requests, nil
+}
+
+// EndpointScore is one endpoint's aggregate performance over a scenario
+type EndpointScore struct {
+	Rank          int     `json:"rank"`
+	Endpoint      string  `json:"endpoint"`
+	Requests      int     `json:"requests"`
+	Errors        int     `json:"errors"`
+	ErrorRate     float64 `json:"error_rate"`
+	FirstError    string  `json:"first_error,omitempty"`
+	ThroughputRPS float64 `json:"throughput_rps"`
+	MeanMs        float64 `json:"mean_ms"`
+	P50Ms         float64 `json:"p50_ms"`
+	P95Ms         float64 `json:"p95_ms"`
+	P99Ms         float64 `json:"p99_ms"`
+	// Differences counts responses that differ from the reference endpoint
+	Differences int `json:"differences"`
+}
+
+// CompareReport is the outcome of an A/B run across endpoints
+type CompareReport struct {
+	Reference string          `json:"reference"`
+	StartedAt time.Time       `json:"started_at"`
+	Scores    []EndpointScore `json:"scores"`
+	// Differences maps each non-reference endpoint to its response
+	// differences from the reference
+	Differences map[string][]string `json:"differences"`
+}
+
+// CompareEndpoints benchmarks every endpoint on the same scenario, ranks them
+// by error rate and then p95 latency, and diffs each endpoint's responses
+// against the first, which serves as the reference
+func CompareEndpoints(ctx context.Context, clients []*RPCClient, requests []DiffRequest, perRequest, concurrency int) (*CompareReport, error) {
+	if len(clients) < 2 {
+		return nil, fmt.Errorf("at least two endpoints are required")
+	}
+
+	ctx, span := startSpan(ctx, "compare", attribute.Int("compare.endpoints", len(clients)))
+	defer span.End()
+
+	report := &CompareReport{
+		Reference:   clients[0].DisplayURL(),
+		StartedAt:   time.Now(),
+		Differences: make(map[string][]string),
+	}
+
+	for _, client := range clients[1:] {
+		diffs, err := NewDiffer(client, clients[0]).Run(ctx, requests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", client.DisplayURL(), err)
+		}
+		lines := make([]string, len(diffs))
+		for i, d := range diffs {
+			lines[i] = d.String()
+		}
+		report.Differences[client.DisplayURL()] = lines
+	}
+
+	for _, client := range clients {
+		// Pool every request's latencies so percentiles span the whole scenario
+		var pooled benchSample
+		for _, req := range requests {
+			sample := client.benchSample(ctx, req.Method, req.Params, perRequest, concurrency, nil)
+			pooled.calls += sample.calls
+			pooled.latencies = append(pooled.latencies, sample.latencies...)
+			pooled.duration += sample.duration
+			if pooled.errors == 0 && sample.errors > 0 {
+				pooled.firstError = fmt.Sprintf("%s: %s", req.Method, sample.firstError)
+			}
+			pooled.errors += sample.errors
+		}
+
+		result := summarizeBench("scenario", pooled)
+		report.Scores = append(report.Scores, EndpointScore{
+			Endpoint:      client.DisplayURL(),
+			Requests:      result.Requests,
+			Errors:        result.Errors,
+			ErrorRate:     errorRate(result),
+			FirstError:    Redact(result.FirstError),
+			ThroughputRPS: math.Round(result.Throughput*10) / 10,
+			MeanMs:        durationMillis(result.Mean),
+			P50Ms:         durationMillis(result.P50),
+			P95Ms:         durationMillis(result.P95),
+			P99Ms:         durationMillis(result.P99),
+			Differences:   len(report.Differences[client.DisplayURL()]),
+		})
+	}
+
+	sort.SliceStable(report.Scores, func(i, j int) bool {
+		a, b := report.Scores[i], report.Scores[j]
+		if a.ErrorRate != b.ErrorRate {
+			return a.ErrorRate < b.ErrorRate
+		}
+		return a.P95Ms < b.P95Ms
+	})
+	for i := range report.Scores {
+		report.Scores[i].Rank = i + 1
+	}
+
+	return report, nil
+}
+
+// DiffTraces diffs the call trace of each transaction on every endpoint
+// against the reference's and adds the divergences to the report
+func (r *CompareReport) DiffTraces(ctx context.Context, clients []*RPCClient, txHashes []common.Hash) error {
+	for _, client := range clients[1:] {
+		divergences, err := NewDiffer(client, clients[0]).DiffTraces(ctx, txHashes)
+		if err != nil {
+			return fmt.Errorf("failed to diff traces of %s: %w", client.DisplayURL(), err)
+		}
+		lines := make([]string, len(divergences))
+		for i, d := range divergences {
+			lines[i] = "trace " + d.String()
+		}
+		r.addDifferences(client.DisplayURL(), lines)
+	}
+	return nil
+}
+
+// DiffLogs runs the eth_getLogs boundary cases against every endpoint and adds
+// each endpoint whose answer differs from the reference's to the report. The
+// range ends lag blocks behind the reference head so that every endpoint has it.
+func (r *CompareReport) DiffLogs(ctx context.Context, clients []*RPCClient, lag uint64) error {
+	from, to, err := recentRange(ctx, clients[0], 10+lag)
+	if err != nil {
+		return err
+	}
+	if to >= from+lag {
+		to -= lag
+	}
+	cases, err := logsCasesAt(ctx, clients[0], from, to)
+	if err != nil {
+		return err
+	}
+	results, err := RunLogsBoundarySuite(ctx, clients, cases)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Consistent {
+			continue
+		}
+		reference := result.Outcomes[0]
+		for _, outcome := range result.Outcomes[1:] {
+			if (outcome.Err == "") == (reference.Err == "") && outcome.Digest == reference.Digest {
+				continue
+			}
+			r.addDifferences(outcome.Endpoint, []string{fmt.Sprintf("eth_getLogs %q: target=%s reference=%s", result.Case, logsOutcomeString(outcome), logsOutcomeString(reference))})
+		}
+	}
+	return nil
+}
+
+// logsOutcomeString formats a logs case outcome for a difference line
+func logsOutcomeString(o LogsOutcome) string {
+	if o.Err != "" {
+		return "error " + Redact(o.Err)
+	}
+	return fmt.Sprintf("%d logs", o.Count)
+}
+
+// addDifferences records more differences of a non-reference endpoint
+func (r *CompareReport) addDifferences(endpoint string, lines []string) {
+	r.Differences[endpoint] = append(r.Differences[endpoint], lines...)
+	for i := range r.Scores {
+		if r.Scores[i].Endpoint == endpoint {
+			r.Scores[i].Differences += len(lines)
+		}
+	}
+}
+
+// Save writes the report as indented JSON
+func (r *CompareReport) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// durationMillis converts a duration to fractional milliseconds for reports
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
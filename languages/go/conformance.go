@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CheckFunc runs one conformance check against a client and reports whether it passed
+type CheckFunc func(ctx context.Context, client *RPCClient) (passed bool, detail string, err error)
+
+// ConformanceCheck is a named check that can be scheduled by the daemon or selected by group
+type ConformanceCheck struct {
+	Name  string
+	Group string
+	Run   CheckFunc
+}
+
+// checkSkipped is returned by a check that does not apply to the endpoint,
+// such as one that needs configuration the endpoint lacks
+type checkSkipped struct {
+	reason string
+}
+
+func (e checkSkipped) Error() string {
+	return "skipped: " + e.reason
+}
+
+// skipCheck reports that a check was not run, and why
+func skipCheck(reason string) error {
+	return checkSkipped{reason: reason}
+}
+
+// latestBlock returns the current head number
+func latestBlock(ctx context.Context, client *RPCClient) (uint64, error) {
+	n, err := client.GetBlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64(), nil
+}
+
+// recentRange returns the last span blocks ending at head
+func recentRange(ctx context.Context, client *RPCClient, span uint64) (uint64, uint64, error) {
+	head, err := latestBlock(ctx, client)
+	if err != nil {
+		return 0, 0, err
+	}
+	if head < span {
+		return 0, head, nil
+	}
+	return head - span + 1, head, nil
+}
+
+// summarize turns a list of problem strings into a check verdict
+func summarize(problems []string) (bool, string) {
+	if len(problems) == 0 {
+		return true, ""
+	}
+	if len(problems) > 3 {
+		return false, fmt.Sprintf("%s (and %d more)", strings.Join(problems[:3], "; "), len(problems)-3)
+	}
+	return false, strings.Join(problems, "; ")
+}
+
+// DefaultConformanceChecks returns every built-in check with parameters derived
+// from the endpoint's current head
+func DefaultConformanceChecks() []ConformanceCheck {
+	return []ConformanceCheck{
+		{Name: "fuzz", Group: "errors", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			results, err := c.Fuzz(ctx, nil, 10*time.Second)
+			if err != nil {
+				return false, "", err
+			}
+			var problems []string
+			for _, r := range results {
+				if !r.Passed {
+					problems = append(problems, fmt.Sprintf("%s: %s", r.Case, r.Reason))
+				}
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "batch", Group: "errors", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			check, err := c.CheckBatchOrdering(ctx, 50, 7)
+			if err != nil {
+				return false, "", err
+			}
+			problems := check.Problems
+			if len(check.PoisonedIDs) > 0 {
+				problems = append(problems, fmt.Sprintf("%d valid entries poisoned by sibling errors", len(check.PoisonedIDs)))
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "http", Group: "errors", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			checks, err := c.CheckHTTPConformance(ctx, "https://example.org", 5<<20)
+			if err != nil {
+				return false, "", err
+			}
+			var problems []string
+			for _, h := range checks {
+				if !h.Passed {
+					problems = append(problems, fmt.Sprintf("%s: %s", h.Name, h.Detail))
+				}
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "logs", Group: "logs", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 10)
+			if err != nil {
+				return false, "", err
+			}
+			cases, err := logsCasesAt(ctx, c, from, to)
+			if err != nil {
+				return false, "", err
+			}
+			results, err := RunLogsBoundarySuite(ctx, []*RPCClient{c}, cases)
+			if err != nil {
+				return false, "", err
+			}
+			var problems []string
+			for _, r := range results {
+				mustFail, pinned := LogsCaseExpectations[r.Case]
+				if !pinned {
+					continue
+				}
+				failed := r.Outcomes[0].Err != ""
+				switch {
+				case mustFail && !failed:
+					problems = append(problems, fmt.Sprintf("%s: accepted, want an error", r.Case))
+				case !mustFail && failed:
+					problems = append(problems, fmt.Sprintf("%s: %s", r.Case, r.Outcomes[0].Err))
+				}
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "schema", Group: "schema", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			validator, err := NewSchemaValidator()
+			if err != nil {
+				return false, "", err
+			}
+			head, err := latestBlock(ctx, c)
+			if err != nil {
+				return false, "", err
+			}
+			violations, err := c.ValidateResponses(ctx, validator, DefaultDiffRequests(head))
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(violations))
+			for i, v := range violations {
+				problems[i] = v.String()
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "tags", Group: "semantics", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			check, err := c.CheckBlockTags(ctx)
+			if err != nil {
+				return false, "", err
+			}
+			passed, detail := summarize(check.Problems)
+			return passed, detail, nil
+		}},
+		{Name: "chain-id", Group: "semantics", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 50)
+			if err != nil {
+				return false, "", err
+			}
+			check, err := c.CheckChainID(ctx, SampleBlockNumbers(from, to, 3))
+			if err != nil {
+				return false, "", err
+			}
+			passed, detail := summarize(check.Problems)
+			if passed && check.SkippedTxs > 0 {
+				detail = fmt.Sprintf("%d transactions of unknown types without a chainId skipped", check.SkippedTxs)
+			}
+			return passed, detail, nil
+		}},
+		{Name: "chain-continuity", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 20)
+			if err != nil {
+				return false, "", err
+			}
+			issues, err := c.VerifyChainContinuity(ctx, from, to)
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(issues))
+			for i, issue := range issues {
+				problems[i] = issue.String()
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "receipts", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 100)
+			if err != nil {
+				return false, "", err
+			}
+			checks, err := c.VerifyReceipts(ctx, SampleBlockNumbers(from, to, 3))
+			if err != nil {
+				return false, "", err
+			}
+			var problems []string
+			for _, rc := range checks {
+				if !rc.Passed() {
+					problems = append(problems, fmt.Sprintf("block %d: %s", rc.Block, rc.Detail))
+				}
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "proofs", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			head, err := latestBlock(ctx, c)
+			if err != nil {
+				return false, "", err
+			}
+			header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(head))
+			if err != nil {
+				return false, "", fmt.Errorf("failed to get header %d: %w", head, err)
+			}
+			check, err := c.VerifyProof(ctx, header.Coinbase, []common.Hash{{}}, head)
+			if err != nil {
+				return false, "", err
+			}
+			return check.Passed(), check.Detail, nil
+		}},
+		{Name: "basefee", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 20)
+			if err != nil {
+				return false, "", err
+			}
+			params := DefaultBaseFeeParams()
+			if c.profile != nil {
+				params = c.profile.BaseFee.Params()
+			}
+			deviations, err := c.VerifyBaseFees(ctx, from, to, params)
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(deviations))
+			for i, d := range deviations {
+				problems[i] = d.String()
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "tx-encoding", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 50)
+			if err != nil {
+				return false, "", err
+			}
+			check, err := c.CheckTxEncoding(ctx, SampleBlockNumbers(from, to, 3))
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(check.Mismatches))
+			for i, m := range check.Mismatches {
+				problems[i] = fmt.Sprintf("block %d tx %s: %s", m.Block, m.Hash.Hex(), m.Detail)
+			}
+			passed, detail := summarize(problems)
+			if passed && len(check.Unchecked) > 0 {
+				unchecked := make([]string, 0, len(check.Unchecked))
+				for name, n := range check.Unchecked {
+					unchecked = append(unchecked, fmt.Sprintf("%d %s", n, name))
+				}
+				sort.Strings(unchecked)
+				detail = "unchecked unknown types: " + strings.Join(unchecked, ", ")
+			}
+			return passed, detail, nil
+		}},
+		{Name: "timestamps", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			from, to, err := recentRange(ctx, c, 50)
+			if err != nil {
+				return false, "", err
+			}
+			check, err := c.CheckTimestamps(ctx, from, to, 15*time.Second, true)
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(check.Issues))
+			for i, issue := range check.Issues {
+				problems[i] = fmt.Sprintf("block %d: %s", issue.Block, issue.Detail)
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "resubscribe", Group: "semantics", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			wsURL := c.wsURL()
+			if wsURL == "" {
+				return false, "", skipCheck("no WebSocket URL (set ws_url in the network profile)")
+			}
+			check, err := c.CheckResubscription(ctx, wsURL, 3, 30*time.Second)
+			if err != nil {
+				return false, "", err
+			}
+			problems := check.Problems
+			if check.OldSubStillValid {
+				problems = append(problems, "dropped subscription still valid after reconnecting")
+			}
+			if len(check.DuplicateHeads) > 0 {
+				problems = append(problems, fmt.Sprintf("%d duplicate heads after resubscribing", len(check.DuplicateHeads)))
+			}
+			if len(check.MissingHeads) > 0 {
+				problems = append(problems, fmt.Sprintf("%d heads missing after resubscribing", len(check.MissingHeads)))
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "block-shape", Group: "semantics", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			if c.profile == nil || c.profile.Forks.Merge == nil {
+				return false, "", skipCheck("no fork heights (set forks in the network profile)")
+			}
+			from, to, err := recentRange(ctx, c, 50)
+			if err != nil {
+				return false, "", err
+			}
+			blocks := append(c.profile.Forks.boundaries(to), SampleBlockNumbers(from, to, 3)...)
+			issues, err := c.CheckBlockShape(ctx, blocks, c.profile.Forks)
+			if err != nil {
+				return false, "", err
+			}
+			problems := make([]string, len(issues))
+			for i, issue := range issues {
+				problems[i] = issue.String()
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "resubmit", Group: "transactions", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			if c.signer == nil {
+				return false, "", skipCheck("no signer (pass --key, --ledger, --node-account, or --transit-key)")
+			}
+			check, err := c.CheckIdempotentResubmission(ctx, 3, 5)
+			if err != nil {
+				return false, "", err
+			}
+			problems := check.Problems
+			if !check.Included && len(problems) == 0 {
+				problems = append(problems, fmt.Sprintf("%s was not included", check.TxHash.Hex()))
+			}
+			passed, detail := summarize(problems)
+			return passed, detail, nil
+		}},
+		{Name: "history", Group: "semantics", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+			check, err := c.CheckHistoricalState(ctx, common.Address{}, []uint64{1}, nil)
+			if err != nil {
+				return false, "", err
+			}
+			passed, detail := summarize(check.Problems)
+			return passed, detail, nil
+		}},
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Conformance check and script step statuses
+const (
+	StatusPass    = "pass"
+	StatusFail    = "fail"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// ConformanceResult is the outcome of one check in a report
+type ConformanceResult struct {
+	Name       string  `json:"name"`
+	Group      string  `json:"group"`
+	Status     string  `json:"status"`
+	Detail     string  `json:"detail,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// ConformanceReport is the artifact written by the conformance command
+type ConformanceReport struct {
+	Endpoint   string    `json:"endpoint"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Errored    int       `json:"errored"`
+	Skipped    int       `json:"skipped"`
+	// Unreachable counts the errored checks that could not reach the endpoint
+	Unreachable int                 `json:"unreachable"`
+	Results     []ConformanceResult `json:"results"`
+}
+
+// OK reports whether every check passed
+func (r ConformanceReport) OK() bool {
+	return r.Failed == 0 && r.Errored == 0
+}
+
+// Save writes the report as indented JSON
+func (r ConformanceReport) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// SelectChecks keeps the checks whose name or group is in filter; an empty
+// filter keeps everything
+func SelectChecks(checks []ConformanceCheck, filter []string) ([]ConformanceCheck, error) {
+	if len(filter) == 0 {
+		return checks, nil
+	}
+
+	known := make(map[string]bool)
+	for _, c := range checks {
+		known[c.Name] = true
+		known[c.Group] = true
+	}
+	wanted := make(map[string]bool, len(filter))
+	for _, f := range filter {
+		if !known[f] {
+			names := make([]string, 0, len(known))
+			for name := range known {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown check or group %q (available: %s)", f, strings.Join(names, ", "))
+		}
+		wanted[f] = true
+	}
+
+	var selected []ConformanceCheck
+	for _, c := range checks {
+		if wanted[c.Name] || wanted[c.Group] {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// RunConformance runs checks in order against client, giving each its own timeout
+func RunConformance(ctx context.Context, client *RPCClient, checks []ConformanceCheck, timeout time.Duration) ConformanceReport {
+	return RunConformanceWithProgress(ctx, client, checks, timeout, nil)
+}
+
+// RunConformanceWithProgress is RunConformance that calls onResult, when not
+// nil, as each check finishes
+func RunConformanceWithProgress(ctx context.Context, client *RPCClient, checks []ConformanceCheck, timeout time.Duration, onResult func(ConformanceResult)) ConformanceReport {
+	report := ConformanceReport{Endpoint: client.DisplayURL(), StartedAt: time.Now()}
+
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		checkCtx, span := startSpan(checkCtx, "conformance "+check.Name, attribute.String("conformance.group", check.Group))
+		start := time.Now()
+		passed, detail, err := check.Run(checkCtx, client)
+		cancel()
+
+		result := ConformanceResult{
+			Name:       check.Name,
+			Group:      check.Group,
+			Detail:     detail,
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		var skipped checkSkipped
+		switch {
+		case errors.As(err, &skipped):
+			result.Status = StatusSkipped
+			result.Detail = skipped.reason
+			report.Skipped++
+			err = nil
+		case err != nil:
+			result.Status = StatusError
+			result.Detail = Redact(err.Error())
+			report.Errored++
+			if isUnreachable(err) {
+				report.Unreachable++
+			}
+		case !passed:
+			result.Status = StatusFail
+			report.Failed++
+		default:
+			result.Status = StatusPass
+			report.Passed++
+		}
+		span.SetAttributes(attribute.String("conformance.status", result.Status))
+		endSpan(span, err)
+
+		report.Results = append(report.Results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+
+	report.DurationMs = float64(time.Since(report.StartedAt).Microseconds()) / 1000
+	return report
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DefaultCosmosGRPC is where a local Cosmos SDK node serves gRPC queries
+const DefaultCosmosGRPC = "localhost:9090"
+
+// DefaultCustomTokenPackage is the protobuf package assumed for the
+// customtoken module's Query service
+const DefaultCustomTokenPackage = "customtoken.v1"
+
+// grpcRetryBackoff is the wait before the first retry; it doubles per retry
+const grpcRetryBackoff = 200 * time.Millisecond
+
+// Coin is an amount of one denomination
+type Coin struct {
+	Denom  string
+	Amount string
+}
+
+// CosmosAccount is the base account behind any auth account type
+type CosmosAccount struct {
+	TypeURL       string
+	Address       string
+	AccountNumber uint64
+	Sequence      uint64
+}
+
+// CosmosValidator is the subset of a staking validator the tester reports
+type CosmosValidator struct {
+	OperatorAddress string
+	Moniker         string
+	Jailed          bool
+	Status          string
+	Tokens          string
+}
+
+// validatorStatuses names the staking BondStatus enum values
+var validatorStatuses = []string{"BOND_STATUS_UNSPECIFIED", "BOND_STATUS_UNBONDED", "BOND_STATUS_UNBONDING", "BOND_STATUS_BONDED"}
+
+// CosmosQueryClient queries Cosmos SDK modules over gRPC. Every call is
+// logged, traced, and reported to StatsD and the latency histograms like an
+// Ethereum JSON-RPC call, and retried when the node is unavailable or
+// throttling.
+type CosmosQueryClient struct {
+	conn   *grpc.ClientConn
+	target string
+	logger *slog.Logger
+	// customToken is the protobuf package of the customtoken Query service
+	customToken string
+}
+
+// DialCosmosGRPC connects to a node's gRPC endpoint. With plaintext unset
+// the connection uses TLS. retries is how many times a call that fails with
+// Unavailable or ResourceExhausted is repeated.
+func DialCosmosGRPC(target string, plaintext bool, retries int, logger *slog.Logger) (*CosmosQueryClient, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if plaintext {
+		creds = insecure.NewCredentials()
+	}
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		host = target
+	}
+	conn, err := grpc.Dial(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+		// Retries wrap the instrumentation so every attempt is measured
+		grpc.WithChainUnaryInterceptor(retryInterceptor(retries, logger), instrumentInterceptor(host, logger)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gRPC endpoint: %w", err)
+	}
+	return &CosmosQueryClient{conn: conn, target: target, logger: logger, customToken: DefaultCustomTokenPackage}, nil
+}
+
+// SetCustomTokenPackage changes the protobuf package of the customtoken
+// Query service, for chains that register it under another name
+func (c *CosmosQueryClient) SetCustomTokenPackage(pkg string) {
+	c.customToken = pkg
+}
+
+// Target returns the gRPC endpoint
+func (c *CosmosQueryClient) Target() string {
+	return c.target
+}
+
+// Close closes the connection
+func (c *CosmosQueryClient) Close() {
+	c.conn.Close()
+}
+
+// grpcMethodName shortens /cosmos.bank.v1beta1.Query/Balance to
+// cosmos.bank.v1beta1.Query/Balance for logs and metrics
+func grpcMethodName(fullMethod string) string {
+	return strings.TrimPrefix(fullMethod, "/")
+}
+
+// instrumentInterceptor logs, traces, and records metrics for every call
+func instrumentInterceptor(host string, logger *slog.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := grpcMethodName(method)
+		ctx, span := startSpan(ctx, name,
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", name),
+			attribute.String("server.address", host),
+		)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		elapsed := time.Since(start)
+
+		errorCode := ""
+		if err != nil {
+			errorCode = status.Code(err).String()
+		}
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+		endSpan(span, err)
+		if metricsSink != nil {
+			metricsSink.RecordCall(name, host, elapsed, errorCode)
+		}
+		if latencyHistograms != nil {
+			latencyHistograms.Observe(name, host, elapsed, trace.SpanContextFromContext(ctx))
+		}
+		if err != nil {
+			logger.Debug("grpc call failed", "method", name, "host", host, "duration", elapsed, "code", errorCode, "error", err)
+		} else {
+			logger.Debug("grpc call", "method", name, "host", host, "duration", elapsed)
+		}
+		return err
+	}
+}
+
+// retryInterceptor repeats calls the node refused for being unavailable or
+// overloaded, with exponential backoff
+func retryInterceptor(retries int, logger *slog.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		backoff := grpcRetryBackoff
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			code := status.Code(err)
+			if err == nil || attempt >= retries || (code != grpccodes.Unavailable && code != grpccodes.ResourceExhausted) {
+				return err
+			}
+			logger.Debug("retrying grpc call", "method", grpcMethodName(method), "attempt", attempt+1, "code", code.String(), "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// query invokes a unary method and decodes the response fields
+func (c *CosmosQueryClient) query(ctx context.Context, method string, request []byte) (pbFields, error) {
+	req := pbMessage(request)
+	var reply pbMessage
+	if err := c.conn.Invoke(ctx, method, &req, &reply); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", grpcMethodName(method), err)
+	}
+	fields, err := decodePB(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", grpcMethodName(method), err)
+	}
+	return fields, nil
+}
+
+// queryPages collects the repeated field itemField of every page of a
+// paginated query. build encodes the request around a PageRequest.
+func (c *CosmosQueryClient) queryPages(ctx context.Context, method string, build func(page []byte) []byte, itemField, pageField protowire.Number) ([]pbFields, error) {
+	var items []pbFields
+	var key []byte
+	for {
+		// PageRequest: key = 1, limit = 3
+		page := pbUint(nil, 3, 200)
+		if len(key) > 0 {
+			page = pbBytes(page, 1, key)
+		}
+		fields, err := c.query(ctx, method, build(page))
+		if err != nil {
+			return nil, err
+		}
+		batch, err := fields.msgs(itemField)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, batch...)
+
+		// PageResponse: next_key = 1
+		next, err := fields.msg(pageField)
+		if err != nil {
+			return nil, err
+		}
+		if key = []byte(next.str(1)); len(key) == 0 {
+			return items, nil
+		}
+	}
+}
+
+// coinFrom decodes a Coin: denom = 1, amount = 2
+func coinFrom(f pbFields) Coin {
+	return Coin{Denom: f.str(1), Amount: f.str(2)}
+}
+
+// Balance returns the balance of one denomination
+func (c *CosmosQueryClient) Balance(ctx context.Context, address, denom string) (Coin, error) {
+	// QueryBalanceRequest: address = 1, denom = 2
+	req := pbString(pbString(nil, 1, address), 2, denom)
+	fields, err := c.query(ctx, "/cosmos.bank.v1beta1.Query/Balance", req)
+	if err != nil {
+		return Coin{}, err
+	}
+	balance, err := fields.msg(1)
+	if err != nil {
+		return Coin{}, err
+	}
+	coin := coinFrom(balance)
+	if coin.Denom == "" {
+		coin = Coin{Denom: denom, Amount: "0"}
+	}
+	return coin, nil
+}
+
+// AllBalances returns every balance of an account
+func (c *CosmosQueryClient) AllBalances(ctx context.Context, address string) ([]Coin, error) {
+	// QueryAllBalancesRequest: address = 1, pagination = 2; the response
+	// has balances = 1, pagination = 2
+	items, err := c.queryPages(ctx, "/cosmos.bank.v1beta1.Query/AllBalances", func(page []byte) []byte {
+		return pbBytes(pbString(nil, 1, address), 2, page)
+	}, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	coins := make([]Coin, len(items))
+	for i, item := range items {
+		coins[i] = coinFrom(item)
+	}
+	return coins, nil
+}
+
+// Account returns the base account of address, unwrapping module and
+// vesting account types
+func (c *CosmosQueryClient) Account(ctx context.Context, address string) (*CosmosAccount, error) {
+	// QueryAccountRequest: address = 1; the response has account = 1, an Any
+	fields, err := c.query(ctx, "/cosmos.auth.v1beta1.Query/Account", pbString(nil, 1, address))
+	if err != nil {
+		return nil, err
+	}
+	packed, err := fields.msg(1)
+	if err != nil {
+		return nil, err
+	}
+	// Any: type_url = 1, value = 2
+	account := &CosmosAccount{TypeURL: packed.str(1)}
+	base, err := packed.msg(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Module and vesting accounts embed the BaseAccount as field 1, one or
+	// two levels down; BaseAccount's own field 1 is the address string
+	for depth := 0; depth < 3; depth++ {
+		values := base[1]
+		if len(values) == 0 || isText(values[0].bytes) {
+			break
+		}
+		if base, err = base.msg(1); err != nil {
+			return nil, err
+		}
+	}
+	// BaseAccount: address = 1, pub_key = 2, account_number = 3, sequence = 4
+	account.Address = base.str(1)
+	account.AccountNumber = base.varint(3)
+	account.Sequence = base.varint(4)
+	return account, nil
+}
+
+// Validators returns the validators with the given status, such as
+// BOND_STATUS_BONDED, or all validators when status is empty
+func (c *CosmosQueryClient) Validators(ctx context.Context, statusFilter string) ([]CosmosValidator, error) {
+	// QueryValidatorsRequest: status = 1, pagination = 2; the response has
+	// validators = 1, pagination = 2
+	items, err := c.queryPages(ctx, "/cosmos.staking.v1beta1.Query/Validators", func(page []byte) []byte {
+		return pbBytes(pbString(nil, 1, statusFilter), 2, page)
+	}, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make([]CosmosValidator, len(items))
+	for i, item := range items {
+		// Validator: operator_address = 1, jailed = 3, status = 4,
+		// tokens = 5, description = 7 (moniker = 1)
+		description, err := item.msg(7)
+		if err != nil {
+			return nil, err
+		}
+		state := fmt.Sprint(item.varint(4))
+		if s := item.varint(4); s < uint64(len(validatorStatuses)) {
+			state = validatorStatuses[s]
+		}
+		validators[i] = CosmosValidator{
+			OperatorAddress: item.str(1),
+			Moniker:         description.str(1),
+			Jailed:          item.varint(3) != 0,
+			Status:          state,
+			Tokens:          item.str(5),
+		}
+	}
+	return validators, nil
+}
+
+// StakingPool returns the bonded and not-bonded token totals
+func (c *CosmosQueryClient) StakingPool(ctx context.Context) (bonded, notBonded string, err error) {
+	fields, err := c.query(ctx, "/cosmos.staking.v1beta1.Query/Pool", nil)
+	if err != nil {
+		return "", "", err
+	}
+	// QueryPoolResponse: pool = 1; Pool: not_bonded_tokens = 1, bonded_tokens = 2
+	pool, err := fields.msg(1)
+	if err != nil {
+		return "", "", err
+	}
+	return pool.str(2), pool.str(1), nil
+}
+
+// CustomToken queries the customtoken module for one token. The module's
+// protobuf schema is not part of the tester, so the request follows the
+// Cosmos convention of denom = 1 and the response is rendered field by field.
+func (c *CosmosQueryClient) CustomToken(ctx context.Context, denom string) (string, error) {
+	fields, err := c.query(ctx, "/"+c.customToken+".Query/Token", pbString(nil, 1, denom))
+	if err != nil {
+		return "", err
+	}
+	return describePB(fields), nil
+}
+
+// CustomTokens lists the customtoken module's tokens, one rendered message
+// per token, from the first repeated field of the response
+func (c *CosmosQueryClient) CustomTokens(ctx context.Context) ([]string, error) {
+	fields, err := c.query(ctx, "/"+c.customToken+".Query/Tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+	items, err := fields.msgs(1)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, len(items))
+	for i, item := range items {
+		tokens[i] = describePB(item)
+	}
+	return tokens, nil
+}
+
+// Bench issues requests calls of query with the given concurrency and
+// measures the latency distribution of the successful ones
+func (c *CosmosQueryClient) Bench(ctx context.Context, name string, query func(context.Context) error, requests, concurrency int, stats *LiveStats) BenchResult {
+	sample := sampleCalls(ctx, c.logger, c.target, name, requests, concurrency, stats, query)
+	return summarizeBench(name, sample)
+}
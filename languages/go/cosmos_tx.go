@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Cosmos SDK transactions are built here as protobuf by hand, like the
+// queries in cosmos_grpc.go. Field numbers follow cosmos/tx/v1beta1/tx.proto.
+
+// signModeDirect is SIGN_MODE_DIRECT: the signature covers the SignDoc bytes
+const signModeDirect = 1
+
+// Cosmos broadcast modes: async returns once the node has the transaction,
+// sync once it passed CheckTx, and block once it is in a block
+const (
+	BroadcastAsync = "async"
+	BroadcastSync  = "sync"
+	BroadcastBlock = "block"
+)
+
+// cometBroadcastMethods maps broadcast modes to CometBFT RPC methods
+var cometBroadcastMethods = map[string]string{
+	BroadcastAsync: "broadcast_tx_async",
+	BroadcastSync:  "broadcast_tx_sync",
+	BroadcastBlock: "broadcast_tx_commit",
+}
+
+// CosmosKey signs Cosmos SDK transactions with a secp256k1 key, the same
+// key type as an Ethereum account
+type CosmosKey struct {
+	key *ecdsa.PrivateKey
+	// prefix is the chain's bech32 account prefix, such as cosmos
+	prefix string
+}
+
+// NewCosmosKey loads a hex private key for a chain whose account addresses
+// start with prefix
+func NewCosmosKey(privateKeyHex, prefix string) (*CosmosKey, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return &CosmosKey{key: key, prefix: prefix}, nil
+}
+
+// PubKey returns the compressed public key
+func (k *CosmosKey) PubKey() []byte {
+	return crypto.CompressPubkey(&k.key.PublicKey)
+}
+
+// Address returns the bech32 account address,
+// ripemd160(sha256(compressed public key))
+func (k *CosmosKey) Address() string {
+	digest := sha256.Sum256(k.PubKey())
+	h := ripemd160.New()
+	h.Write(digest[:])
+	return bech32Encode(k.prefix, h.Sum(nil))
+}
+
+// sign returns the 64-byte r||s signature of sha256(msg) with a low s, as
+// the Cosmos SDK verifies it
+func (k *CosmosKey) sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	// crypto.Sign always produces a low s; the recovery byte is dropped
+	sig, err := crypto.Sign(digest[:], k.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return sig[:64], nil
+}
+
+// bech32Charset is the BIP-173 alphabet
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Encode encodes data under the human-readable part hrp
+func bech32Encode(hrp string, data []byte) string {
+	// Regroup the 8-bit bytes as 5-bit values, zero-padding the last one
+	var values []byte
+	acc, bits := 0, 0
+	for _, b := range data {
+		acc = acc<<8 | int(b)
+		for bits += 8; bits >= 5; bits -= 5 {
+			values = append(values, byte(acc>>(bits-5)&31))
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits)&31))
+	}
+
+	polymod := func(values []byte) uint32 {
+		generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+		chk := uint32(1)
+		for _, v := range values {
+			top := chk >> 25
+			chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+			for i := 0; i < 5; i++ {
+				if top>>i&1 == 1 {
+					chk ^= generator[i]
+				}
+			}
+		}
+		return chk
+	}
+	var expanded []byte
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c>>5))
+	}
+	expanded = append(expanded, 0)
+	for _, c := range hrp {
+		expanded = append(expanded, byte(c&31))
+	}
+	mod := polymod(append(append(expanded, values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[mod>>(5*(5-i))&31])
+	}
+	return sb.String()
+}
+
+// coinPattern matches one coin, an integer amount followed by a denom
+var coinPattern = regexp.MustCompile(`^([0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]{1,127})$`)
+
+// ParseCoins parses a comma-separated coin list such as 1000uatom,5stake
+func ParseCoins(s string) ([]Coin, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var coins []Coin
+	for _, part := range strings.Split(s, ",") {
+		m := coinPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, fmt.Errorf("invalid coin %q (want an amount and denom, such as 1000uatom)", part)
+		}
+		coins = append(coins, Coin{Denom: m[2], Amount: m[1]})
+	}
+	return coins, nil
+}
+
+// FeeForGas prices gas at a decimal gas price such as 0.025uatom, rounding up
+func FeeForGas(gas uint64, gasPrice string) (Coin, error) {
+	i := strings.IndexFunc(gasPrice, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return Coin{}, fmt.Errorf("invalid gas price %q (want an amount and denom, such as 0.025uatom)", gasPrice)
+	}
+	price, ok := new(big.Rat).SetString(gasPrice[:i])
+	if !ok {
+		return Coin{}, fmt.Errorf("invalid gas price %q", gasPrice)
+	}
+	fee := price.Mul(price, new(big.Rat).SetInt64(int64(gas)))
+	amount := new(big.Int).Quo(fee.Num(), fee.Denom())
+	if new(big.Rat).SetInt(amount).Cmp(fee) < 0 {
+		amount.Add(amount, big.NewInt(1))
+	}
+	return Coin{Denom: gasPrice[i:], Amount: amount.String()}, nil
+}
+
+// CosmosMsg is a transaction message, packed as an Any
+type CosmosMsg struct {
+	TypeURL string
+	Value   []byte
+}
+
+// pbCoin encodes a Coin: denom = 1, amount = 2
+func pbCoin(c Coin) []byte {
+	return pbString(pbString(nil, 1, c.Denom), 2, c.Amount)
+}
+
+// MsgSend transfers coins with the bank module
+func MsgSend(from, to string, amount []Coin) CosmosMsg {
+	// MsgSend: from_address = 1, to_address = 2, amount = 3
+	value := pbString(pbString(nil, 1, from), 2, to)
+	for _, c := range amount {
+		value = pbBytes(value, 3, pbCoin(c))
+	}
+	return CosmosMsg{TypeURL: "/cosmos.bank.v1beta1.MsgSend", Value: value}
+}
+
+// MsgCreateToken creates a token with the customtoken module under the
+// protobuf package pkg. The layout is assumed, as for the module's queries:
+// creator = 1, denom = 2, max_supply = 3, an integer string; an empty
+// max_supply is left out.
+func MsgCreateToken(pkg, creator, denom, maxSupply string) CosmosMsg {
+	value := pbString(pbString(pbString(nil, 1, creator), 2, denom), 3, maxSupply)
+	return CosmosMsg{TypeURL: "/" + pkg + ".MsgCreateToken", Value: value}
+}
+
+// MsgMintToken mints amount of a customtoken module token, assuming the
+// layout creator = 1, denom = 2, amount = 3, an integer string, and
+// recipient = 4, which the module may default to the creator when empty
+func MsgMintToken(pkg, creator, denom, amount, recipient string) CosmosMsg {
+	value := pbString(pbString(pbString(pbString(nil, 1, creator), 2, denom), 3, amount), 4, recipient)
+	return CosmosMsg{TypeURL: "/" + pkg + ".MsgMintToken", Value: value}
+}
+
+// CosmosTx is a transaction before signing
+type CosmosTx struct {
+	Msgs     []CosmosMsg
+	Memo     string
+	GasLimit uint64
+	Fees     []Coin
+}
+
+// SignTx signs tx with SIGN_MODE_DIRECT and returns the encoded TxRaw,
+// ready to broadcast
+func (k *CosmosKey) SignTx(tx CosmosTx, chainID string, accountNumber, sequence uint64) ([]byte, error) {
+	// TxBody: messages = 1, memo = 2
+	var body []byte
+	for _, m := range tx.Msgs {
+		// Any: type_url = 1, value = 2
+		body = pbBytes(body, 1, pbBytes(pbString(nil, 1, m.TypeURL), 2, m.Value))
+	}
+	body = pbString(body, 2, tx.Memo)
+
+	// SignerInfo: public_key = 1, mode_info = 2, sequence = 3; the key is a
+	// secp256k1 PubKey (key = 1) in an Any, and ModeInfo is single = 1 with
+	// mode = 1
+	pubKey := pbBytes(pbString(nil, 1, "/cosmos.crypto.secp256k1.PubKey"), 2, pbBytes(nil, 1, k.PubKey()))
+	modeInfo := pbBytes(nil, 1, pbUint(nil, 1, signModeDirect))
+	signerInfo := pbUint(pbBytes(pbBytes(nil, 1, pubKey), 2, modeInfo), 3, sequence)
+
+	// Fee: amount = 1, gas_limit = 2; AuthInfo: signer_infos = 1, fee = 2
+	var fee []byte
+	for _, c := range tx.Fees {
+		fee = pbBytes(fee, 1, pbCoin(c))
+	}
+	fee = pbUint(fee, 2, tx.GasLimit)
+	authInfo := pbBytes(pbBytes(nil, 1, signerInfo), 2, fee)
+
+	// SignDoc: body_bytes = 1, auth_info_bytes = 2, chain_id = 3,
+	// account_number = 4
+	signDoc := pbUint(pbString(pbBytes(pbBytes(nil, 1, body), 2, authInfo), 3, chainID), 4, accountNumber)
+	sig, err := k.sign(signDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	// TxRaw: body_bytes = 1, auth_info_bytes = 2, signatures = 3
+	return pbBytes(pbBytes(pbBytes(nil, 1, body), 2, authInfo), 3, sig), nil
+}
+
+// CosmosTxHash returns the hash CometBFT indexes a transaction under
+func CosmosTxHash(txBytes []byte) string {
+	digest := sha256.Sum256(txBytes)
+	return strings.ToUpper(fmt.Sprintf("%x", digest))
+}
+
+// Simulate executes a signed transaction without committing it and returns
+// the gas it used
+func (c *CosmosQueryClient) Simulate(ctx context.Context, txBytes []byte) (uint64, error) {
+	// SimulateRequest: tx_bytes = 2; SimulateResponse: gas_info = 1
+	// (gas_wanted = 1, gas_used = 2)
+	fields, err := c.query(ctx, "/cosmos.tx.v1beta1.Service/Simulate", pbBytes(nil, 2, txBytes))
+	if err != nil {
+		return 0, err
+	}
+	gasInfo, err := fields.msg(1)
+	if err != nil {
+		return 0, err
+	}
+	return gasInfo.varint(2), nil
+}
+
+// CometBroadcast is the node's answer to a broadcast. Async broadcasts only
+// carry the hash; Height and the gas figures are set in block mode.
+type CometBroadcast struct {
+	Hash      string
+	Code      uint32
+	Codespace string
+	Log       string
+	Height    int64
+	GasWanted int64
+	GasUsed   int64
+}
+
+// BroadcastTx submits a signed transaction in the given mode. A transaction
+// the node rejected is not an error: its Code is nonzero.
+func (c *CometClient) BroadcastTx(ctx context.Context, txBytes []byte, mode string) (*CometBroadcast, error) {
+	method, ok := cometBroadcastMethods[mode]
+	if !ok {
+		return nil, fmt.Errorf("unknown broadcast mode %q (want sync, async, or block)", mode)
+	}
+	params := map[string]interface{}{"tx": base64.StdEncoding.EncodeToString(txBytes)}
+
+	if mode != BroadcastBlock {
+		var result struct {
+			Code      uint32 `json:"code"`
+			Codespace string `json:"codespace"`
+			Log       string `json:"log"`
+			Hash      string `json:"hash"`
+		}
+		if err := c.call(ctx, method, params, &result); err != nil {
+			return nil, err
+		}
+		return &CometBroadcast{Hash: result.Hash, Code: result.Code, Codespace: result.Codespace, Log: result.Log}, nil
+	}
+
+	var result struct {
+		CheckTx CometTxResult `json:"check_tx"`
+		// CometBFT 0.38 renamed deliver_tx to tx_result
+		TxResult  *CometTxResult `json:"tx_result"`
+		DeliverTx *CometTxResult `json:"deliver_tx"`
+		Hash      string         `json:"hash"`
+		Height    int64          `json:"height,string"`
+	}
+	if err := c.call(ctx, method, params, &result); err != nil {
+		return nil, err
+	}
+	executed := result.CheckTx
+	if result.CheckTx.Code == 0 {
+		if result.TxResult != nil {
+			executed = *result.TxResult
+		} else if result.DeliverTx != nil {
+			executed = *result.DeliverTx
+		}
+	}
+	return &CometBroadcast{
+		Hash:      result.Hash,
+		Code:      executed.Code,
+		Codespace: executed.Codespace,
+		Log:       executed.Log,
+		Height:    result.Height,
+		GasWanted: executed.GasWanted,
+		GasUsed:   executed.GasUsed,
+	}, nil
+}
+
+// cosmosTxError describes a rejected transaction, or returns nil
+func cosmosTxError(b *CometBroadcast) error {
+	if b.Code == 0 {
+		return nil
+	}
+	return fmt.Errorf("transaction %s failed with %s code %d: %s", b.Hash, b.Codespace, b.Code, b.Log)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBech32Encode(t *testing.T) {
+	// BIP-173 valid checksums whose data is a whole number of bytes
+	tests := []struct {
+		hrp  string
+		data string
+		want string
+	}{
+		{"a", "", "a12uel5l"},
+		{"abcdef", "00443214c74254b635cf84653a56d7c675be77df", "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw"},
+	}
+	for _, tt := range tests {
+		if got := bech32Encode(tt.hrp, common.FromHex(tt.data)); got != tt.want {
+			t.Errorf("bech32Encode(%q, %s) = %s, want %s", tt.hrp, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestParseCoins(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Coin
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "1000uatom", want: []Coin{{Denom: "uatom", Amount: "1000"}}},
+		{in: "1000uatom, 5stake", want: []Coin{{Denom: "uatom", Amount: "1000"}, {Denom: "stake", Amount: "5"}}},
+		{in: "7ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", want: []Coin{{Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Amount: "7"}}},
+		{in: "1000", wantErr: true},
+		{in: "uatom", wantErr: true},
+		{in: "1.5uatom", wantErr: true},
+		{in: "-5uatom", wantErr: true},
+		{in: "1000uatom,", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCoins(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseCoins(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseCoins(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCoins(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFeeForGas(t *testing.T) {
+	tests := []struct {
+		gas      uint64
+		gasPrice string
+		want     Coin
+		wantErr  bool
+	}{
+		{gas: 200000, gasPrice: "0.025uatom", want: Coin{Denom: "uatom", Amount: "5000"}},
+		// 2500.025 rounds up
+		{gas: 100001, gasPrice: "0.025uatom", want: Coin{Denom: "uatom", Amount: "2501"}},
+		{gas: 21, gasPrice: "2stake", want: Coin{Denom: "stake", Amount: "42"}},
+		{gas: 0, gasPrice: "0.025uatom", want: Coin{Denom: "uatom", Amount: "0"}},
+		{gas: 1000, gasPrice: "uatom", wantErr: true},
+		{gas: 1000, gasPrice: "0.025", wantErr: true},
+		{gas: 1000, gasPrice: "0.0.1uatom", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := FeeForGas(tt.gas, tt.gasPrice)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("FeeForGas(%d, %q) = %v, want an error", tt.gas, tt.gasPrice, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FeeForGas(%d, %q): %v", tt.gas, tt.gasPrice, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FeeForGas(%d, %q) = %v, want %v", tt.gas, tt.gasPrice, got, tt.want)
+		}
+	}
+}
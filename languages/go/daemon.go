@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CheckOutcome is the result of one scheduled check run against one endpoint
+type CheckOutcome struct {
+	Endpoint string
+	Check    string
+	Passed   bool
+	Skipped  bool
+	Detail   string
+	At       time.Time
+	Duration time.Duration
+}
+
+// EndpointHealth is the rolling health of one endpoint
+type EndpointHealth struct {
+	Endpoint string
+	Score    float64
+	Runs     int
+	Failures int
+	Last     map[string]CheckOutcome
+}
+
+// AlertHook is called for every failed check with the endpoint's updated health score
+type AlertHook func(outcome CheckOutcome, score float64)
+
+// ConformanceDaemon runs a rotating subset of conformance checks against
+// configured endpoints on a schedule and keeps a rolling health score per endpoint
+type ConformanceDaemon struct {
+	clients  []*RPCClient
+	checks   []ConformanceCheck
+	interval time.Duration
+	subset   int
+	window   int
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	next    int
+	history map[string][]bool
+	health  map[string]*EndpointHealth
+	hooks   []AlertHook
+}
+
+// NewConformanceDaemon creates a daemon that runs subset checks per endpoint every
+// interval, scoring each endpoint over its last window outcomes
+func NewConformanceDaemon(clients []*RPCClient, checks []ConformanceCheck, interval time.Duration, subset, window int) *ConformanceDaemon {
+	if len(checks) == 0 {
+		checks = DefaultConformanceChecks()
+	}
+	if subset <= 0 || subset > len(checks) {
+		subset = len(checks)
+	}
+	if window <= 0 {
+		window = 100
+	}
+
+	health := make(map[string]*EndpointHealth, len(clients))
+	for _, c := range clients {
+		health[c.DisplayURL()] = &EndpointHealth{Endpoint: c.DisplayURL(), Score: 1, Last: make(map[string]CheckOutcome)}
+	}
+
+	return &ConformanceDaemon{
+		clients:  clients,
+		checks:   checks,
+		interval: interval,
+		subset:   subset,
+		window:   window,
+		timeout:  2 * time.Minute,
+		history:  make(map[string][]bool),
+		health:   health,
+	}
+}
+
+// SetCheckTimeout bounds each check run, two minutes by default
+func (d *ConformanceDaemon) SetCheckTimeout(timeout time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.timeout = timeout
+}
+
+// OnAlert registers a hook called whenever a check fails
+func (d *ConformanceDaemon) OnAlert(hook AlertHook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks = append(d.hooks, hook)
+}
+
+// Run executes a round immediately and then every interval until ctx is cancelled
+func (d *ConformanceDaemon) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs the next rotating subset of checks against every endpoint
+func (d *ConformanceDaemon) RunOnce(ctx context.Context) {
+	d.mu.Lock()
+	selected := make([]ConformanceCheck, 0, d.subset)
+	for i := 0; i < d.subset; i++ {
+		selected = append(selected, d.checks[(d.next+i)%len(d.checks)])
+	}
+	d.next = (d.next + d.subset) % len(d.checks)
+	d.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range d.clients {
+		wg.Add(1)
+		go func(client *RPCClient) {
+			defer wg.Done()
+			for _, check := range selected {
+				d.record(d.runCheck(ctx, client, check))
+			}
+		}(client)
+	}
+	wg.Wait()
+}
+
+// runCheck runs a single check with the daemon's timeout
+func (d *ConformanceDaemon) runCheck(ctx context.Context, client *RPCClient, check ConformanceCheck) CheckOutcome {
+	checkCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	start := time.Now()
+	passed, detail, err := check.Run(checkCtx, client)
+	var skipped checkSkipped
+	if errors.As(err, &skipped) {
+		return CheckOutcome{Endpoint: client.DisplayURL(), Check: check.Name, Skipped: true, Detail: skipped.reason, At: start}
+	}
+	if err != nil {
+		passed = false
+		detail = fmt.Sprintf("error: %v", err)
+	}
+
+	return CheckOutcome{
+		Endpoint: client.DisplayURL(),
+		Check:    check.Name,
+		Passed:   passed,
+		Detail:   detail,
+		At:       start,
+		Duration: time.Since(start),
+	}
+}
+
+// record updates the rolling score for an outcome and fires alert hooks on failure
+func (d *ConformanceDaemon) record(outcome CheckOutcome) {
+	// A check that does not apply to the endpoint says nothing about its health
+	if outcome.Skipped {
+		return
+	}
+	d.mu.Lock()
+	history := append(d.history[outcome.Endpoint], outcome.Passed)
+	if len(history) > d.window {
+		history = history[len(history)-d.window:]
+	}
+	d.history[outcome.Endpoint] = history
+
+	passed := 0
+	for _, ok := range history {
+		if ok {
+			passed++
+		}
+	}
+
+	h := d.health[outcome.Endpoint]
+	h.Score = float64(passed) / float64(len(history))
+	h.Runs++
+	if !outcome.Passed {
+		h.Failures++
+	}
+	h.Last[outcome.Check] = outcome
+	score := h.Score
+	hooks := append([]AlertHook(nil), d.hooks...)
+	d.mu.Unlock()
+
+	if !outcome.Passed {
+		for _, hook := range hooks {
+			hook(outcome, score)
+		}
+	}
+}
+
+// Health returns a snapshot of every endpoint's rolling health
+func (d *ConformanceDaemon) Health() []EndpointHealth {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]EndpointHealth, 0, len(d.health))
+	for _, h := range d.health {
+		last := make(map[string]CheckOutcome, len(h.Last))
+		for k, v := range h.Last {
+			last[k] = v
+		}
+		snapshot := *h
+		snapshot.Last = last
+		out = append(out, snapshot)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Endpoint < out[j].Endpoint })
+
+	return out
+}
+
+// ServeHTTP exposes the daemon's results in the Prometheus text exposition format
+func (d *ConformanceDaemon) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP rpc_conformance_health_score Rolling fraction of passed conformance checks")
+	fmt.Fprintln(w, "# TYPE rpc_conformance_health_score gauge")
+	health := d.Health()
+	for _, h := range health {
+		fmt.Fprintf(w, "rpc_conformance_health_score{endpoint=%q} %g\n", h.Endpoint, h.Score)
+	}
+
+	fmt.Fprintln(w, "# HELP rpc_conformance_runs_total Conformance checks run")
+	fmt.Fprintln(w, "# TYPE rpc_conformance_runs_total counter")
+	for _, h := range health {
+		fmt.Fprintf(w, "rpc_conformance_runs_total{endpoint=%q} %d\n", h.Endpoint, h.Runs)
+	}
+
+	fmt.Fprintln(w, "# HELP rpc_conformance_failures_total Conformance checks failed")
+	fmt.Fprintln(w, "# TYPE rpc_conformance_failures_total counter")
+	for _, h := range health {
+		fmt.Fprintf(w, "rpc_conformance_failures_total{endpoint=%q} %d\n", h.Endpoint, h.Failures)
+	}
+
+	fmt.Fprintln(w, "# HELP rpc_conformance_check_passed Whether the last run of a check passed")
+	fmt.Fprintln(w, "# TYPE rpc_conformance_check_passed gauge")
+	for _, h := range health {
+		names := make([]string, 0, len(h.Last))
+		for name := range h.Last {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := 0
+			if h.Last[name].Passed {
+				value = 1
+			}
+			fmt.Fprintf(w, "rpc_conformance_check_passed{endpoint=%q,check=%q} %d\n", h.Endpoint, name, value)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardWindow is the span over which TPS and p95 are computed
+const dashboardWindow = 10 * time.Second
+
+// dashboardErrors is how many recent errors the dashboard keeps
+const dashboardErrors = 5
+
+// timedLatency is one completed call in the rolling window
+type timedLatency struct {
+	at      time.Time
+	latency time.Duration
+}
+
+// LiveStats collects the progress of a running load test for the dashboard.
+// A nil *LiveStats ignores every update, so callers need not check.
+type LiveStats struct {
+	mu          sync.Mutex
+	started     time.Time
+	total       int
+	inFlight    int
+	completed   int
+	errors      int
+	window      []timedLatency
+	recentErrs  []string
+	blockHeight uint64
+	phase       string
+}
+
+// LiveSnapshot is a point-in-time view of LiveStats
+type LiveSnapshot struct {
+	Elapsed      time.Duration
+	Total        int
+	InFlight     int
+	Completed    int
+	Errors       int
+	TPS          float64
+	P95          time.Duration
+	RecentErrors []string
+	BlockHeight  uint64
+	Phase        string
+}
+
+// NewLiveStats creates stats for a run of total requests
+func NewLiveStats(total int) *LiveStats {
+	return &LiveStats{started: time.Now(), total: total}
+}
+
+// begin records a call being sent
+func (s *LiveStats) begin() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight++
+}
+
+// end records a call finishing after latency with err
+func (s *LiveStats) end(latency time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	s.completed++
+	if err != nil {
+		s.errors++
+		s.recentErrs = append(s.recentErrs, fmt.Sprintf("%s %s", time.Now().Format("15:04:05"), Redact(err.Error())))
+		if len(s.recentErrs) > dashboardErrors {
+			s.recentErrs = s.recentErrs[1:]
+		}
+		return
+	}
+
+	now := time.Now()
+	s.window = append(s.window, timedLatency{at: now, latency: latency})
+	cutoff := 0
+	for cutoff < len(s.window) && now.Sub(s.window[cutoff].at) > dashboardWindow {
+		cutoff++
+	}
+	s.window = s.window[cutoff:]
+}
+
+// SetBlockHeight records the endpoint's latest block
+func (s *LiveStats) SetBlockHeight(n uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blockHeight = n
+}
+
+// SetPhase records the run's current phase, such as warmup
+func (s *LiveStats) SetPhase(phase string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+// Snapshot returns the current progress with TPS and p95 over the rolling window
+func (s *LiveStats) Snapshot() LiveSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	snap := LiveSnapshot{
+		Elapsed:      now.Sub(s.started),
+		Total:        s.total,
+		InFlight:     s.inFlight,
+		Completed:    s.completed,
+		Errors:       s.errors,
+		RecentErrors: append([]string(nil), s.recentErrs...),
+		BlockHeight:  s.blockHeight,
+		Phase:        s.phase,
+	}
+
+	var latencies []time.Duration
+	for _, l := range s.window {
+		if now.Sub(l.at) <= dashboardWindow {
+			latencies = append(latencies, l.latency)
+		}
+	}
+	if len(latencies) > 0 {
+		span := dashboardWindow
+		if snap.Elapsed < span {
+			span = snap.Elapsed
+		}
+		snap.TPS = float64(len(latencies)) / span.Seconds()
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		snap.P95 = percentile(latencies, 95)
+	}
+	return snap
+}
+
+// RunDashboard redraws a live view of stats on w every interval until ctx is
+// cancelled, polling client for the block height, and draws a final frame
+func RunDashboard(ctx context.Context, w io.Writer, title string, stats *LiveStats, client *RPCClient, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pollCtx, cancel := context.WithTimeout(context.Background(), interval)
+		if n, err := client.GetBlockNumber(pollCtx); err == nil {
+			stats.SetBlockHeight(n.Uint64())
+		}
+		cancel()
+		renderDashboard(w, title, stats.Snapshot())
+
+		select {
+		case <-ctx.Done():
+			renderDashboard(w, title, stats.Snapshot())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderDashboard clears the terminal and draws one frame
+func renderDashboard(w io.Writer, title string, snap LiveSnapshot) {
+	var b strings.Builder
+	// Move home and clear the screen so each frame replaces the last
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s  (%s elapsed)\n\n", title, snap.Elapsed.Round(time.Second))
+	if snap.Phase != "" {
+		fmt.Fprintf(&b, "  phase         %s\n", snap.Phase)
+	}
+
+	progress := fmt.Sprintf("%d", snap.Completed)
+	if snap.Total > 0 {
+		progress = fmt.Sprintf("%d / %d (%.0f%%)", snap.Completed, snap.Total, 100*float64(snap.Completed)/float64(snap.Total))
+	}
+	fmt.Fprintf(&b, "  completed     %s\n", progress)
+	fmt.Fprintf(&b, "  in flight     %d\n", snap.InFlight)
+	fmt.Fprintf(&b, "  tps           %.1f\n", snap.TPS)
+	fmt.Fprintf(&b, "  p95 (%s)     %.3f ms\n", dashboardWindow, durationMillis(snap.P95))
+	fmt.Fprintf(&b, "  errors        %d\n", snap.Errors)
+	fmt.Fprintf(&b, "  block height  %d\n", snap.BlockHeight)
+
+	if len(snap.RecentErrors) > 0 {
+		b.WriteString("\nRecent errors:\n")
+		for _, e := range snap.RecentErrors {
+			fmt.Fprintf(&b, "  %s\n", e)
+		}
+	}
+
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// processStart is when the tester started, for uptime
+var processStart = time.Now()
+
+// RuntimeStats is a snapshot of the tester's own resource use
+type RuntimeStats struct {
+	UptimeS        float64 `json:"uptime_s"`
+	Goroutines     int     `json:"goroutines"`
+	GOMAXPROCS     int     `json:"gomaxprocs"`
+	NumCPU         int     `json:"num_cpu"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64  `json:"heap_inuse_bytes"`
+	HeapObjects    uint64  `json:"heap_objects"`
+	SysBytes       uint64  `json:"sys_bytes"`
+	NumGC          uint32  `json:"num_gc"`
+	GCPauseTotalMs float64 `json:"gc_pause_total_ms"`
+	LastGCPauseMs  float64 `json:"last_gc_pause_ms"`
+	GCCPUFraction  float64 `json:"gc_cpu_fraction"`
+}
+
+// ReadRuntimeStats returns the current runtime stats
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		UptimeS:        math.Round(time.Since(processStart).Seconds()*1000) / 1000,
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+		NumCPU:         runtime.NumCPU(),
+		HeapAllocBytes: m.HeapAlloc,
+		HeapInuseBytes: m.HeapInuse,
+		HeapObjects:    m.HeapObjects,
+		SysBytes:       m.Sys,
+		NumGC:          m.NumGC,
+		GCPauseTotalMs: durationMillis(time.Duration(m.PauseTotalNs)),
+		GCCPUFraction:  m.GCCPUFraction,
+	}
+	if m.NumGC > 0 {
+		stats.LastGCPauseMs = durationMillis(time.Duration(m.PauseNs[(m.NumGC+255)%256]))
+	}
+	return stats
+}
+
+// StartDebugServer serves pprof profiles under /debug/pprof/ and runtime
+// stats as JSON at /debug/stats on addr until the process exits. It returns
+// once the address is bound, so a port clash fails the command up front.
+func StartDebugServer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start debug server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	// pprof's cmdline handler is left out: the command line may hold --key
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/stats", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, ReadRuntimeStats())
+	})
+
+	go http.Serve(listener, mux)
+	return nil
+}
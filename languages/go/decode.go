@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fourByteAPI is the signature database consulted when lookups are enabled
+const fourByteAPI = "https://www.4byte.directory/api/v1"
+
+// knownEventsABI covers the events most contracts emit, so their logs can be
+// decoded without supplying an ABI
+const knownEventsABI = `[
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Approval","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"spender","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Deposit","inputs":[{"name":"dst","type":"address","indexed":true},{"name":"wad","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Withdrawal","inputs":[{"name":"src","type":"address","indexed":true},{"name":"wad","type":"uint256","indexed":false}]}
+]`
+
+var knownEvents, _ = abi.JSON(strings.NewReader(knownEventsABI))
+
+// DecodedArg is one decoded argument of a call or event
+type DecodedArg struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// DecodedCall is calldata broken into its method and arguments. Signature is
+// the bare selector when the method could not be identified.
+type DecodedCall struct {
+	Selector  string
+	Signature string
+	Args      []DecodedArg
+}
+
+// DecodedLog is a log entry broken into its event and arguments. Signature is
+// the bare topic0 when the event could not be identified.
+type DecodedLog struct {
+	Address   common.Address
+	Signature string
+	Args      []DecodedArg
+}
+
+// Decoder decodes calldata and logs using, in order, a user-supplied ABI,
+// the built-in ABIs, and optionally the 4byte.directory signature database
+type Decoder struct {
+	abis   []*abi.ABI
+	lookup bool
+	cache  map[string][]string
+}
+
+// NewDecoder creates a decoder; contract may be nil. With lookup set,
+// unknown selectors and topics are resolved through 4byte.directory.
+func NewDecoder(contract *abi.ABI, lookup bool) *Decoder {
+	var abis []*abi.ABI
+	if contract != nil {
+		abis = append(abis, contract)
+	}
+	abis = append(abis, &knownCalldata, &knownEvents)
+
+	return &Decoder{abis: abis, lookup: lookup, cache: make(map[string][]string)}
+}
+
+// DecodeCall identifies the method called by data and decodes its arguments,
+// falling back to 32-byte words when the method is unknown
+func (d *Decoder) DecodeCall(ctx context.Context, data []byte) (*DecodedCall, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("calldata is %d bytes, shorter than a selector", len(data))
+	}
+	call := &DecodedCall{Selector: hexutil.Encode(data[:4])}
+
+	for _, contract := range d.abis {
+		method, err := contract.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		if args, err := decodeArgs(method.Inputs, data[4:]); err == nil {
+			call.Signature, call.Args = method.Sig, args
+			return call, nil
+		}
+	}
+
+	if d.lookup {
+		signatures, err := d.lookupSignatures(ctx, "signatures", call.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, sig := range signatures {
+			name, inputs, err := parseSignature(sig)
+			if err != nil {
+				continue
+			}
+			// Colliding selectors are common; keep the first that decodes
+			if args, err := decodeArgs(inputs, data[4:]); err == nil {
+				method := abi.NewMethod(name, name, abi.Function, "", false, false, inputs, nil)
+				call.Signature, call.Args = method.Sig, args
+				return call, nil
+			}
+		}
+	}
+
+	call.Signature = call.Selector
+	call.Args = wordArgs("word", data[4:])
+	return call, nil
+}
+
+// DecodeLog identifies the event that emitted log and decodes its arguments,
+// falling back to raw topics and data words when the event is unknown
+func (d *Decoder) DecodeLog(ctx context.Context, log types.Log) (*DecodedLog, error) {
+	decoded := &DecodedLog{Address: log.Address}
+	if len(log.Topics) == 0 {
+		// Anonymous events carry no signature topic to identify them by
+		decoded.Signature = "(anonymous)"
+		decoded.Args = wordArgs("word", log.Data)
+		return decoded, nil
+	}
+
+	for _, contract := range d.abis {
+		event, err := contract.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+		if args, err := decodeEvent(event.Inputs, log); err == nil {
+			decoded.Signature, decoded.Args = event.Sig, args
+			return decoded, nil
+		}
+	}
+
+	if d.lookup {
+		signatures, err := d.lookupSignatures(ctx, "event-signatures", log.Topics[0].Hex())
+		if err != nil {
+			return nil, err
+		}
+		for _, sig := range signatures {
+			name, inputs, err := parseSignature(sig)
+			if err != nil || len(inputs) < len(log.Topics)-1 {
+				continue
+			}
+			// Signatures do not record which arguments are indexed; assume
+			// the leading ones are, as they are in most contracts
+			for i := range inputs {
+				inputs[i].Indexed = i < len(log.Topics)-1
+			}
+			if args, err := decodeEvent(inputs, log); err == nil {
+				event := abi.NewEvent(name, name, false, inputs)
+				decoded.Signature, decoded.Args = event.Sig, args
+				return decoded, nil
+			}
+		}
+	}
+
+	decoded.Signature = log.Topics[0].Hex()
+	for i, topic := range log.Topics[1:] {
+		decoded.Args = append(decoded.Args, DecodedArg{Name: fmt.Sprintf("topic%d", i+1), Type: "bytes32", Value: topic.Hex()})
+	}
+	decoded.Args = append(decoded.Args, wordArgs("word", log.Data)...)
+	return decoded, nil
+}
+
+// decodeArgs unpacks data against inputs into named arguments
+func decodeArgs(inputs abi.Arguments, data []byte) ([]DecodedArg, error) {
+	values, err := inputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]DecodedArg, len(values))
+	for i, v := range values {
+		args[i] = DecodedArg{Name: argName(inputs[i], i), Type: inputs[i].Type.String(), Value: displayABIValue(v)}
+	}
+	return args, nil
+}
+
+// decodeEvent decodes indexed arguments from topics and the rest from data,
+// returning them in declaration order
+func decodeEvent(inputs abi.Arguments, log types.Log) ([]DecodedArg, error) {
+	var indexed abi.Arguments
+	for _, input := range inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(indexed) != len(log.Topics)-1 {
+		return nil, fmt.Errorf("event has %d indexed arguments, log has %d topics", len(indexed), len(log.Topics)-1)
+	}
+
+	values, err := inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, err
+	}
+	topics := make(map[string]interface{})
+	if err := abi.ParseTopicsIntoMap(topics, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+
+	args := make([]DecodedArg, 0, len(inputs))
+	topic, data := 0, 0
+	for i, input := range inputs {
+		arg := DecodedArg{Name: argName(input, i), Type: input.Type.String()}
+		if input.Indexed {
+			arg.Value = displayABIValue(topics[input.Name])
+			if input.Name == "" {
+				// Unnamed topics cannot be looked up by name, so show them raw
+				arg.Value = log.Topics[1+topic].Hex()
+			}
+			topic++
+		} else {
+			arg.Value = displayABIValue(values[data])
+			data++
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// argName returns the declared name of an argument, or argN when it has none
+func argName(arg abi.Argument, i int) string {
+	if arg.Name != "" {
+		return arg.Name
+	}
+	return fmt.Sprintf("arg%d", i)
+}
+
+// wordArgs splits data into 32-byte words named prefix0, prefix1, ...
+func wordArgs(prefix string, data []byte) []DecodedArg {
+	var args []DecodedArg
+	for i := 0; i < len(data); i += 32 {
+		end := i + 32
+		if end > len(data) {
+			end = len(data)
+		}
+		args = append(args, DecodedArg{Name: fmt.Sprintf("%s%d", prefix, i/32), Type: "bytes32", Value: hexutil.Encode(data[i:end])})
+	}
+	return args
+}
+
+// parseSignature turns a text signature such as transfer(address,uint256)
+// into a name and arguments named arg0, arg1, ... Tuple arguments are not supported.
+func parseSignature(sig string) (string, abi.Arguments, error) {
+	open := strings.IndexByte(sig, '(')
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("malformed signature %q", sig)
+	}
+	name, list := sig[:open], sig[open+1:len(sig)-1]
+
+	var inputs abi.Arguments
+	if list == "" {
+		return name, inputs, nil
+	}
+	for i, typeName := range strings.Split(list, ",") {
+		t, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			return "", nil, fmt.Errorf("unsupported type in %q: %w", sig, err)
+		}
+		inputs = append(inputs, abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: t})
+	}
+	return name, inputs, nil
+}
+
+// lookupSignatures asks 4byte.directory for the text signatures matching a
+// selector or event topic, oldest first since later entries are often
+// deliberate collisions
+func (d *Decoder) lookupSignatures(ctx context.Context, kind, hex string) ([]string, error) {
+	key := kind + hex
+	if cached, ok := d.cache[key]; ok {
+		return cached, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/?hex_signature=%s", fourByteAPI, kind, url.QueryEscape(hex))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signature lookup: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s: %w", hex, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signature lookup for %s returned status %d", hex, resp.StatusCode)
+	}
+
+	var page struct {
+		Results []struct {
+			ID            int    `json:"id"`
+			TextSignature string `json:"text_signature"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode signature lookup: %w", err)
+	}
+	sort.Slice(page.Results, func(i, j int) bool { return page.Results[i].ID < page.Results[j].ID })
+
+	signatures := make([]string, len(page.Results))
+	for i, r := range page.Results {
+		signatures[i] = r.TextSignature
+	}
+	d.cache[key] = signatures
+	return signatures, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// DefaultIgnoredFields lists response fields that legitimately differ between
+// client implementations and are dropped before comparison
+var DefaultIgnoredFields = []string{"totalDifficulty", "size"}
+
+// DiffRequest is a single JSON-RPC call issued to both endpoints
+type DiffRequest struct {
+	Method string
+	Params []interface{}
+}
+
+// Difference describes a semantic mismatch between target and reference responses
+type Difference struct {
+	Method    string
+	Params    []interface{}
+	Path      string
+	Target    interface{}
+	Reference interface{}
+}
+
+// String formats the difference for reports
+func (d Difference) String() string {
+	return fmt.Sprintf("%s %v at %s: target=%v reference=%v", d.Method, d.Params, d.Path, d.Target, d.Reference)
+}
+
+// Normalizer strips ignored fields and hex formatting noise from responses before comparison
+type Normalizer struct {
+	ignored map[string]bool
+}
+
+// NewNormalizer creates a normalizer; ignoredFields are added to DefaultIgnoredFields
+func NewNormalizer(ignoredFields ...string) *Normalizer {
+	ignored := make(map[string]bool)
+	for _, f := range DefaultIgnoredFields {
+		ignored[f] = true
+	}
+	for _, f := range ignoredFields {
+		ignored[f] = true
+	}
+
+	return &Normalizer{ignored: ignored}
+}
+
+// Differ issues the same request stream to a target and a reference endpoint
+// and reports semantic differences in their responses
+type Differ struct {
+	target     *RPCClient
+	reference  *RPCClient
+	normalizer *Normalizer
+}
+
+// NewDiffer creates a differ; ignoredFields are added to DefaultIgnoredFields
+func NewDiffer(target, reference *RPCClient, ignoredFields ...string) *Differ {
+	return &Differ{
+		target:     target,
+		reference:  reference,
+		normalizer: NewNormalizer(ignoredFields...),
+	}
+}
+
+// DefaultDiffRequests returns a request stream covering common read methods
+func DefaultDiffRequests(blockNumber uint64) []DiffRequest {
+	block := fmt.Sprintf("0x%x", blockNumber)
+	return []DiffRequest{
+		{Method: "eth_chainId"},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{block, false}},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{block, true}},
+		{Method: "eth_getBlockTransactionCountByNumber", Params: []interface{}{block}},
+		{Method: "eth_getBalance", Params: []interface{}{"0x0000000000000000000000000000000000000000", block}},
+		{Method: "eth_getCode", Params: []interface{}{"0x0000000000000000000000000000000000000000", block}},
+		{Method: "eth_getBlockReceipts", Params: []interface{}{block}},
+	}
+}
+
+// Run issues every request to both endpoints and returns all differences found
+func (d *Differ) Run(ctx context.Context, requests []DiffRequest) ([]Difference, error) {
+	var diffs []Difference
+	for _, req := range requests {
+		found, err := d.Compare(ctx, req)
+		if err != nil {
+			return diffs, err
+		}
+		diffs = append(diffs, found...)
+	}
+
+	return diffs, nil
+}
+
+// Compare issues one request to both endpoints and returns the differences found.
+// An error on only one side, or different errors on both, is reported as a
+// difference, not as a failure.
+func (d *Differ) Compare(ctx context.Context, req DiffRequest) ([]Difference, error) {
+	targetRaw, targetErr := d.target.CallRaw(ctx, req.Method, req.Params...)
+	referenceRaw, referenceErr := d.reference.CallRaw(ctx, req.Method, req.Params...)
+
+	if targetErr != nil || referenceErr != nil {
+		if targetErr != nil && referenceErr != nil && sameError(targetErr, referenceErr) {
+			return nil, nil
+		}
+		return []Difference{{
+			Method:    req.Method,
+			Params:    req.Params,
+			Path:      "$",
+			Target:    errString(targetErr),
+			Reference: errString(referenceErr),
+		}}, nil
+	}
+
+	return d.normalizer.Diff(req, targetRaw, referenceRaw)
+}
+
+// Diff normalizes two raw responses to the same request and returns their differences
+func (n *Normalizer) Diff(req DiffRequest, targetRaw, referenceRaw json.RawMessage) ([]Difference, error) {
+	targetVal, err := n.normalize(req.Method, targetRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode target response: %w", err)
+	}
+	referenceVal, err := n.normalize(req.Method, referenceRaw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode reference response: %w", err)
+	}
+
+	var diffs []Difference
+	diffValues("$", targetVal, referenceVal, func(path string, t, r interface{}) {
+		diffs = append(diffs, Difference{
+			Method:    req.Method,
+			Params:    req.Params,
+			Path:      path,
+			Target:    t,
+			Reference: r,
+		})
+	})
+
+	return diffs, nil
+}
+
+// Normalize decodes a raw response and strips ignored fields and hex formatting noise
+func (n *Normalizer) Normalize(raw json.RawMessage) (interface{}, error) {
+	return n.normalize("", raw)
+}
+
+// normalize is Normalize for a response to method, whose result is a
+// quantity when the method is in quantityMethods
+func (n *Normalizer) normalize(method string, raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	if s, ok := v.(string); ok && quantityMethods[method] {
+		return normalizeQuantity(s), nil
+	}
+	return n.normalizeValue("", v), nil
+}
+
+// normalizeValue normalizes v, the value of field key ("" at the top level
+// and for array items outside a quantity field)
+func (n *Normalizer) normalizeValue(key string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if n.ignored[k] {
+				continue
+			}
+			out[k] = n.normalizeValue(k, item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = n.normalizeValue(key, item)
+		}
+		return out
+	case string:
+		if quantityFields[key] {
+			return normalizeQuantity(val)
+		}
+		return normalizeHex(val)
+	default:
+		return val
+	}
+}
+
+// quantityMethods are the methods whose result is a single hex quantity
+var quantityMethods = map[string]bool{
+	"eth_blockNumber":                      true,
+	"eth_chainId":                          true,
+	"eth_gasPrice":                         true,
+	"eth_maxPriorityFeePerGas":             true,
+	"eth_blobBaseFee":                      true,
+	"eth_getBalance":                       true,
+	"eth_getTransactionCount":              true,
+	"eth_estimateGas":                      true,
+	"eth_getBlockTransactionCountByNumber": true,
+	"eth_getBlockTransactionCountByHash":   true,
+	"eth_getUncleCountByBlockNumber":       true,
+	"eth_getUncleCountByBlockHash":         true,
+}
+
+// quantityFields are the block, transaction, receipt, log, and withdrawal
+// fields the execution-apis schemas define as hex quantities. A block's
+// nonce is 8 bytes of data, but no client returns it in quantity form, so it
+// is listed for the transaction nonce.
+var quantityFields = map[string]bool{
+	"number": true, "difficulty": true, "totalDifficulty": true, "gasLimit": true, "gasUsed": true,
+	"timestamp": true, "size": true, "baseFeePerGas": true, "blobGasUsed": true, "excessBlobGas": true,
+	"nonce": true, "gas": true, "gasPrice": true, "maxFeePerGas": true, "maxPriorityFeePerGas": true,
+	"maxFeePerBlobGas": true, "value": true, "type": true, "chainId": true, "v": true, "r": true,
+	"s": true, "yParity": true, "blockNumber": true, "transactionIndex": true, "logIndex": true,
+	"blockTimestamp": true, "cumulativeGasUsed": true, "effectiveGasPrice": true, "status": true,
+	"blobGasPrice": true, "index": true, "validatorIndex": true, "amount": true,
+}
+
+// normalizeHex lowercases hex data so that checksummed and lowercase
+// addresses compare equal; its length, leading zeros included, is kept
+func normalizeHex(s string) string {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + strings.ToLower(s[2:])
+}
+
+// normalizeQuantity lowercases a hex quantity and strips its leading zeros
+// so that "0x01" and "0x1" compare equal. "0x", which is not a quantity, is
+// left alone rather than turned into "0x0".
+func normalizeQuantity(s string) string {
+	s = normalizeHex(s)
+	if !strings.HasPrefix(s, "0x") || len(s) == 2 {
+		return s
+	}
+	trimmed := strings.TrimLeft(s[2:], "0")
+	if trimmed == "" {
+		trimmed = "0"
+	}
+	return "0x" + trimmed
+}
+
+// normalizeResult normalizes a raw result string of method
+func normalizeResult(method, s string) string {
+	if quantityMethods[method] {
+		return normalizeQuantity(s)
+	}
+	return normalizeHex(s)
+}
+
+// sameError reports whether two errors are the same answer: the same
+// JSON-RPC error code when both are JSON-RPC errors, otherwise the same message
+func sameError(a, b error) bool {
+	var aRPC, bRPC rpc.Error
+	if errors.As(a, &aRPC) && errors.As(b, &bRPC) {
+		return aRPC.ErrorCode() == bRPC.ErrorCode()
+	}
+	return a.Error() == b.Error()
+}
+
+// diffValues walks two decoded JSON values and reports every differing leaf
+func diffValues(path string, a, b interface{}, report func(path string, a, b interface{})) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			report(path, a, b)
+			return
+		}
+		keys := make(map[string]bool)
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffValues(path+"."+k, av[k], bv[k], report)
+		}
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			report(path, a, b)
+			return
+		}
+		for i := range av {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], report)
+		}
+	default:
+		if !reflect.DeepEqual(a, b) {
+			report(path, a, b)
+		}
+	}
+}
+
+func errString(err error) interface{} {
+	if err == nil {
+		return nil
+	}
+	return err.Error()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestNormalizeHex(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0xAbCd", "0xabcd"},
+		{"0XABCD", "0xabcd"},
+		// Leading zeros are part of hex data
+		{"0x00ff", "0x00ff"},
+		{"0x", "0x"},
+		{"latest", "latest"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeHex(tt.in); got != tt.want {
+			t.Errorf("normalizeHex(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeQuantity(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0x01", "0x1"},
+		{"0x0001A", "0x1a"},
+		{"0x1", "0x1"},
+		{"0x0", "0x0"},
+		{"0x000", "0x0"},
+		// Not a quantity, so not turned into 0x0
+		{"0x", "0x"},
+		{"pending", "pending"},
+	}
+	for _, tt := range tests {
+		if got := normalizeQuantity(tt.in); got != tt.want {
+			t.Errorf("normalizeQuantity(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeResult(t *testing.T) {
+	if got := normalizeResult("eth_blockNumber", "0x0A"); got != "0xa" {
+		t.Errorf("quantity result normalized to %q", got)
+	}
+	if got := normalizeResult("eth_getCode", "0x00AB"); got != "0x00ab" {
+		t.Errorf("data result normalized to %q", got)
+	}
+}
+
+// testRPCError is a JSON-RPC error with a code
+type testRPCError struct {
+	code    int
+	message string
+}
+
+func (e testRPCError) Error() string  { return e.message }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+var _ rpc.Error = testRPCError{}
+
+func TestSameError(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b error
+		want bool
+	}{
+		{"same code, different message", testRPCError{-32000, "header not found"}, testRPCError{-32000, "block not found"}, true},
+		{"different code", testRPCError{-32000, "not found"}, testRPCError{-32601, "not found"}, false},
+		{"same message", errors.New("timeout"), errors.New("timeout"), true},
+		{"different message", errors.New("timeout"), errors.New("refused"), false},
+		{"one JSON-RPC error", testRPCError{-32000, "timeout"}, errors.New("timeout"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameError(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameError(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
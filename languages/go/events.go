@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// EventKind names a test lifecycle event
+type EventKind string
+
+// Lifecycle events published on the bus
+const (
+	EventRunStarted        EventKind = "run_started"
+	EventRunFinished       EventKind = "run_finished"
+	EventPhaseChanged      EventKind = "phase_changed"
+	EventTxConfirmed       EventKind = "tx_confirmed"
+	EventThresholdBreached EventKind = "threshold_breached"
+	EventThresholdCleared  EventKind = "threshold_cleared"
+	EventAccountsRotated   EventKind = "accounts_rotated"
+	// EventEndpointFailover is for clients that move traffic to a fallback
+	// endpoint; Endpoint is the one left and Fallback the one taken over
+	EventEndpointFailover EventKind = "endpoint_failover"
+)
+
+// Event is one thing that happened during a run. Which fields are set
+// depends on Kind.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	At   time.Time `json:"at"`
+	// Source is the command that published the event, e.g. "bench eth_call"
+	Source   string `json:"source"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Phase    string `json:"phase,omitempty"`
+	TxHash   string `json:"tx_hash,omitempty"`
+	Fallback string `json:"fallback,omitempty"`
+	// Alert describes the threshold on breached and cleared events
+	Alert   *Alert `json:"alert,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// EventHandler receives events from the bus
+type EventHandler func(Event)
+
+// subscription is one handler and the kinds it wants, all when empty
+type subscription struct {
+	id      int
+	kinds   map[EventKind]bool
+	handler EventHandler
+}
+
+// EventBus delivers lifecycle events from the code that measures a run to
+// the sinks that present it. Handlers run synchronously in the publisher's
+// goroutine, in subscription order, so they should return quickly. A nil
+// *EventBus drops every event, so publishers need not check.
+type EventBus struct {
+	mu     sync.Mutex
+	subs   []subscription
+	nextID int
+}
+
+// NewEventBus creates a bus with no subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers handler for the given kinds, or for every event when
+// none are given, and returns a function that removes it
+func (b *EventBus) Subscribe(handler EventHandler, kinds ...EventKind) func() {
+	sub := subscription{handler: handler}
+	if len(kinds) > 0 {
+		sub.kinds = make(map[EventKind]bool, len(kinds))
+		for _, k := range kinds {
+			sub.kinds[k] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish stamps the event if needed and hands it to every subscriber
+func (b *EventBus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	subs := append([]subscription(nil), b.subs...)
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		if s.kinds == nil || s.kinds[event.Kind] {
+			s.handler(event)
+		}
+	}
+}
+
+// logEvents returns a handler that logs every event at debug level
+func logEvents(logger *slog.Logger) EventHandler {
+	return func(e Event) {
+		attrs := []interface{}{"kind", e.Kind, "source", e.Source}
+		for _, f := range []struct{ key, value string }{
+			{"endpoint", e.Endpoint}, {"phase", e.Phase}, {"tx_hash", e.TxHash},
+			{"fallback", e.Fallback}, {"message", e.Message},
+		} {
+			if f.value != "" {
+				attrs = append(attrs, f.key, f.value)
+			}
+		}
+		if e.Alert != nil {
+			attrs = append(attrs, "metric", e.Alert.Metric)
+		}
+		logger.Debug("event", attrs...)
+	}
+}
+
+// alertEvents returns a handler that fires threshold breaches at the alerter
+// and resolves them once cleared
+func alertEvents(alerter *Alerter) EventHandler {
+	return func(e Event) {
+		if e.Alert == nil {
+			return
+		}
+		switch e.Kind {
+		case EventThresholdBreached:
+			alert := *e.Alert
+			if alert.At.IsZero() {
+				alert.At = e.At
+			}
+			// Alerts get their own deadline: the run's context may already be done
+			alerter.Fire(context.Background(), alert)
+		case EventThresholdCleared:
+			alerter.Resolve(e.Alert.Source, e.Alert.Endpoint, e.Alert.Metric)
+		}
+	}
+}
+
+// EventLog writes every event it receives to a file as one line of JSON. The
+// file stays open until the process exits; it is safe for concurrent use.
+type EventLog struct {
+	mu     sync.Mutex
+	f      *os.File
+	logger *slog.Logger
+}
+
+// OpenEventLog opens path for appending, creating it if needed. Write
+// failures are logged to logger rather than interrupting the run.
+func OpenEventLog(path string, logger *slog.Logger) (*EventLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	return &EventLog{f: f, logger: logger}, nil
+}
+
+// Handle is an EventHandler
+func (l *EventLog) Handle(e Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		l.logger.Warn("failed to encode event", "kind", e.Kind, "error", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		l.logger.Warn("failed to write event log", "error", err)
+	}
+}
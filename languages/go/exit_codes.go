@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// Process exit codes, so scripts and CI can tell failures apart
+const (
+	// ExitOK means the command succeeded
+	ExitOK = 0
+	// ExitError is any failure without a more specific code, including bad usage
+	ExitError = 1
+	// ExitThresholdBreach means a measured value crossed a --max-* limit
+	ExitThresholdBreach = 2
+	// ExitUnreachable means the endpoint could not be reached
+	ExitUnreachable = 3
+	// ExitCheckFailure means a conformance check or script step did not pass
+	ExitCheckFailure = 4
+)
+
+// exitError carries the exit code a command failed with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with the process exit code it should produce
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode maps a command error to the process exit code
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var coded *exitError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	if isUnreachable(err) {
+		return ExitUnreachable
+	}
+	return ExitError
+}
+
+// isUnreachable reports whether err comes from failing to connect to or
+// hear back from the endpoint
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &opErr), errors.As(err, &dnsErr):
+		return true
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return true
+	case errors.As(err, &urlErr) && urlErr.Timeout():
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FinalitySample is one observation of the head, safe, and finalized tags
+type FinalitySample struct {
+	Time       time.Time
+	Head       uint64
+	Safe       uint64
+	Finalized  uint64
+	LagBlocks  uint64
+	LagSeconds uint64
+}
+
+// FinalityViolation describes a previously finalized block whose hash changed
+type FinalityViolation struct {
+	Block   uint64
+	OldHash common.Hash
+	NewHash common.Hash
+}
+
+// FinalityReport summarizes finalization progress over an observation window
+type FinalityReport struct {
+	Samples    []FinalitySample
+	Stalls     []time.Duration
+	Violations []FinalityViolation
+	MaxLag     uint64
+}
+
+// Passed reports whether finality never reverted and never stalled
+func (f FinalityReport) Passed() bool {
+	return len(f.Violations) == 0 && len(f.Stalls) == 0
+}
+
+// TrackFinality polls the latest, safe, and finalized tags every interval for
+// the given duration, recording finalization lag behind head, stalls longer
+// than stallAfter, and any previously finalized block whose hash changes
+func (r *RPCClient) TrackFinality(ctx context.Context, duration, interval, stallAfter time.Duration) (*FinalityReport, error) {
+	report := &FinalityReport{}
+	finalized := make(map[uint64]common.Hash)
+
+	var lastFinalized uint64
+	lastProgress := time.Now()
+	stalled := false
+
+	deadline := time.Now().Add(duration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		head, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", "latest")
+		if err != nil {
+			return report, err
+		}
+		safe, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", "safe")
+		if err != nil {
+			return report, err
+		}
+		final, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", "finalized")
+		if err != nil {
+			return report, err
+		}
+		if head == nil || safe == nil || final == nil {
+			return report, fmt.Errorf("endpoint does not resolve latest, safe, and finalized tags")
+		}
+
+		now := time.Now()
+		sample := FinalitySample{
+			Time:      now,
+			Head:      uint64(head.Number),
+			Safe:      uint64(safe.Number),
+			Finalized: uint64(final.Number),
+		}
+		if sample.Head > sample.Finalized {
+			sample.LagBlocks = sample.Head - sample.Finalized
+		}
+		if head.Timestamp > final.Timestamp {
+			sample.LagSeconds = uint64(head.Timestamp - final.Timestamp)
+		}
+		if sample.LagBlocks > report.MaxLag {
+			report.MaxLag = sample.LagBlocks
+		}
+		report.Samples = append(report.Samples, sample)
+
+		if sample.Finalized > lastFinalized {
+			lastFinalized = sample.Finalized
+			lastProgress = now
+			stalled = false
+		} else if !stalled && now.Sub(lastProgress) > stallAfter {
+			report.Stalls = append(report.Stalls, now.Sub(lastProgress))
+			stalled = true
+		}
+
+		if err := r.recheckFinalized(ctx, finalized, sample.Finalized, report); err != nil {
+			return report, err
+		}
+		if old, ok := finalized[sample.Finalized]; ok && old != final.Hash {
+			report.Violations = append(report.Violations, FinalityViolation{Block: sample.Finalized, OldHash: old, NewHash: final.Hash})
+		}
+		finalized[sample.Finalized] = final.Hash
+
+		if now.After(deadline) {
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// recheckFinalized re-fetches the blocks previously seen as finalized and
+// records a violation if a hash has changed since. Blocks below height, the
+// newest finalized block, are then dropped: each is rechecked once after
+// finality moves past it, so the cost stays constant over long runs.
+func (r *RPCClient) recheckFinalized(ctx context.Context, finalized map[uint64]common.Hash, height uint64, report *FinalityReport) error {
+	for number, hash := range finalized {
+		current, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(number))
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Hash != hash {
+			var newHash common.Hash
+			if current != nil {
+				newHash = current.Hash
+			}
+			report.Violations = append(report.Violations, FinalityViolation{Block: number, OldHash: hash, NewHash: newHash})
+			finalized[number] = newHash
+		}
+	}
+
+	for number := range finalized {
+		if number < height {
+			delete(finalized, number)
+		}
+	}
+	return nil
+}
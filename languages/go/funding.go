@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// transferGas is the gas used by a plain value transfer
+const transferGas = 21000
+
+// PlannedTransfer is one transfer of a funding or sweep plan. Tx stays
+// unsigned until SignPlan, so a plan can be previewed without a signer
+// prompt.
+type PlannedTransfer struct {
+	Path    string
+	From    common.Address
+	To      common.Address
+	Tx      *types.Transaction
+	ChainID *big.Int
+	// Err is set when the transfer could not be submitted
+	Err error
+
+	signer Signer
+}
+
+// withKey returns a client that signs with key and shares r's connection.
+// Only r should be closed.
+func (r *RPCClient) withKey(key *ecdsa.PrivateKey) *RPCClient {
+	clone := *r
+	clone.privateKey = key
+	clone.signer = NewKeySigner(key)
+	clone.address = crypto.PubkeyToAddress(key.PublicKey)
+	return &clone
+}
+
+// transferCost is the most a transfer priced like template can spend on gas
+func transferCost(template *types.Transaction) *big.Int {
+	return new(big.Int).Mul(template.GasFeeCap(), big.NewInt(transferGas))
+}
+
+// repriceTransfer builds an unsigned transfer with the fees of template, so
+// a whole plan is priced once
+func repriceTransfer(template *types.Transaction, chainID *big.Int, nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+	if template.Type() == types.DynamicFeeTxType {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: template.GasTipCap(),
+			GasFeeCap: template.GasFeeCap(),
+			Gas:       transferGas,
+			To:        &to,
+			Value:     value,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: template.GasPrice(),
+		Gas:      transferGas,
+		To:       &to,
+		Value:    value,
+	})
+}
+
+// PlanFunding plans one transfer from the client's key to each recipient with
+// consecutive nonces. With amount nil the balance, less keep and gas, is split
+// evenly; otherwise every recipient gets amount.
+func (r *RPCClient) PlanFunding(ctx context.Context, recipients []HDAccount, amount, keep *big.Int) ([]PlannedTransfer, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no accounts to fund")
+	}
+	template, chainID, err := r.PrepareTransaction(ctx, TxRequest{To: &r.address, Gas: transferGas})
+	if err != nil {
+		return nil, err
+	}
+	balance, err := r.GetBalance(ctx, r.address)
+	if err != nil {
+		return nil, err
+	}
+
+	n := big.NewInt(int64(len(recipients)))
+	gas := new(big.Int).Mul(transferCost(template), n)
+	available := new(big.Int).Sub(balance, gas)
+	if keep != nil {
+		available.Sub(available, keep)
+	}
+
+	value := amount
+	if value == nil {
+		value = new(big.Int).Div(available, n)
+		if value.Sign() <= 0 {
+			return nil, fmt.Errorf("balance of %s wei does not cover gas for %d transfers", balance, len(recipients))
+		}
+	} else if total := new(big.Int).Mul(value, n); total.Cmp(available) > 0 {
+		return nil, fmt.Errorf("funding %d accounts with %s wei needs %s wei plus gas, but only %s wei is available", len(recipients), value, total, available)
+	}
+
+	plan := make([]PlannedTransfer, len(recipients))
+	for i, account := range recipients {
+		tx := repriceTransfer(template, chainID, template.Nonce()+uint64(i), account.Address, value)
+		plan[i] = PlannedTransfer{Path: account.Path, From: r.address, To: account.Address, Tx: tx, ChainID: chainID, signer: r.signer}
+	}
+	return plan, nil
+}
+
+// PlanSweep plans one transfer from each account to dest of its whole balance
+// less gas. Accounts whose balance does not cover gas are left out.
+func (r *RPCClient) PlanSweep(ctx context.Context, accounts []HDAccount, dest common.Address) ([]PlannedTransfer, error) {
+	var plan []PlannedTransfer
+	for _, account := range accounts {
+		signer := r.withKey(account.PrivateKey)
+		balance, err := signer.GetBalance(ctx, account.Address)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		template, chainID, err := signer.PrepareTransaction(ctx, TxRequest{To: &dest, Gas: transferGas})
+		if err != nil {
+			return nil, fmt.Errorf("failed to price sweep from %s: %w", account.Address.Hex(), err)
+		}
+		value := new(big.Int).Sub(balance, transferCost(template))
+		if value.Sign() <= 0 {
+			continue
+		}
+
+		tx := repriceTransfer(template, chainID, template.Nonce(), dest, value)
+		plan = append(plan, PlannedTransfer{Path: account.Path, From: account.Address, To: dest, Tx: tx, ChainID: chainID, signer: signer.signer})
+	}
+	return plan, nil
+}
+
+// SignPlan signs every transfer of the plan in place
+func SignPlan(ctx context.Context, plan []PlannedTransfer) error {
+	for i := range plan {
+		tx, err := plan[i].signer.SignTx(ctx, plan[i].Tx, plan[i].ChainID)
+		if err != nil {
+			return fmt.Errorf("failed to sign transfer from %s: %w", plan[i].From.Hex(), err)
+		}
+		plan[i].Tx = tx
+	}
+	return nil
+}
+
+// BroadcastBatch submits the plan's transactions with eth_sendRawTransaction
+// in JSON-RPC batches of batchSize, recording each failure on its transfer.
+// Transfers from one sender must be in nonce order.
+func (r *RPCClient) BroadcastBatch(ctx context.Context, plan []PlannedTransfer, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	for start := 0; start < len(plan); start += batchSize {
+		end := start + batchSize
+		if end > len(plan) {
+			end = len(plan)
+		}
+
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for _, t := range plan[start:end] {
+			raw, err := t.Tx.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("failed to encode transaction: %w", err)
+			}
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_sendRawTransaction",
+				Args:   []interface{}{hexutil.Encode(raw)},
+				Result: new(common.Hash),
+			})
+		}
+		if err := r.client.Client().BatchCallContext(ctx, batch); err != nil {
+			return fmt.Errorf("failed to send batch: %w", err)
+		}
+		failed := 0
+		for i, elem := range batch {
+			plan[start+i].Err = elem.Error
+			if elem.Error != nil {
+				failed++
+			}
+		}
+		r.logger.Debug("batch submitted", "endpoint", r.DisplayURL(), "transactions", len(batch), "failed", failed)
+	}
+	return nil
+}
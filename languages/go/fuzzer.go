@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// FuzzExpectation describes what a well-behaved endpoint should return for a fuzz case
+type FuzzExpectation int
+
+const (
+	// ExpectError requires a JSON-RPC error object in the response
+	ExpectError FuzzExpectation = iota
+	// ExpectWellFormed only requires a parseable JSON-RPC response
+	ExpectWellFormed
+	// ExpectBatch requires a JSON array with one response per valid batch entry
+	ExpectBatch
+)
+
+// FuzzCase is a single structurally invalid JSON-RPC payload
+type FuzzCase struct {
+	Name    string
+	Payload []byte
+	Expect  FuzzExpectation
+}
+
+// FuzzResult holds the outcome of sending one fuzz case to an endpoint
+type FuzzResult struct {
+	Case       string
+	StatusCode int
+	Duration   time.Duration
+	Passed     bool
+	Reason     string
+}
+
+// rpcResponse is the generic shape of a JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error object of a JSON-RPC 2.0 response
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// DefaultFuzzCases returns the built-in set of malformed payloads
+func DefaultFuzzCases() []FuzzCase {
+	return []FuzzCase{
+		{Name: "empty body", Payload: []byte(``), Expect: ExpectError},
+		{Name: "truncated object", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":1`), Expect: ExpectError},
+		{Name: "truncated string", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_block`), Expect: ExpectError},
+		{Name: "not json", Payload: []byte(`hello world`), Expect: ExpectError},
+		{Name: "bare number", Payload: []byte(`42`), Expect: ExpectError},
+		{Name: "method is number", Payload: []byte(`{"jsonrpc":"2.0","method":1,"id":1}`), Expect: ExpectError},
+		{Name: "params is string", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_getBalance","params":"0x0","id":1}`), Expect: ExpectError},
+		{Name: "id is object", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":{"a":1}}`), Expect: ExpectError},
+		{Name: "wrong jsonrpc version", Payload: []byte(`{"jsonrpc":"1.0","method":"eth_blockNumber","id":1}`), Expect: ExpectWellFormed},
+		{Name: "missing method", Payload: []byte(`{"jsonrpc":"2.0","id":1}`), Expect: ExpectError},
+		{Name: "unknown method", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_doesNotExist","id":1}`), Expect: ExpectError},
+		{Name: "huge numeric id", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":123456789012345678901234567890}`), Expect: ExpectWellFormed},
+		{Name: "huge string id", Payload: append(append([]byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":"`), bytes.Repeat([]byte("a"), 64*1024)...), []byte(`"}`)...), Expect: ExpectWellFormed},
+		{Name: "negative id", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","id":-1}`), Expect: ExpectWellFormed},
+		{Name: "duplicate keys", Payload: []byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","method":"eth_chainId","id":1}`), Expect: ExpectWellFormed},
+		{Name: "empty batch", Payload: []byte(`[]`), Expect: ExpectError},
+		{Name: "batch of garbage", Payload: []byte(`[1,2,3]`), Expect: ExpectBatch},
+		{Name: "mixed batch", Payload: []byte(`[{"jsonrpc":"2.0","method":"eth_blockNumber","id":1},{"jsonrpc":"2.0","method":1,"id":2},"x"]`), Expect: ExpectBatch},
+		{Name: "nested batch", Payload: []byte(`[[{"jsonrpc":"2.0","method":"eth_blockNumber","id":1}]]`), Expect: ExpectBatch},
+		{Name: "deeply nested params", Payload: append(append([]byte(`{"jsonrpc":"2.0","method":"eth_call","params":`), append(bytes.Repeat([]byte("["), 10000), bytes.Repeat([]byte("]"), 10000)...)...), []byte(`,"id":1}`)...), Expect: ExpectError},
+	}
+}
+
+// Fuzz sends each malformed payload to the endpoint and verifies it answers
+// with a proper JSON-RPC error rather than hanging, crashing, or returning garbage
+func (r *RPCClient) Fuzz(ctx context.Context, cases []FuzzCase, timeout time.Duration) ([]FuzzResult, error) {
+	if len(cases) == 0 {
+		cases = DefaultFuzzCases()
+	}
+
+	results := make([]FuzzResult, 0, len(cases))
+	for _, c := range cases {
+		if err := ctx.Err(); err != nil {
+			return results, fmt.Errorf("fuzzing aborted: %w", err)
+		}
+
+		caseCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		status, body, err := r.postJSON(caseCtx, c.Payload)
+		cancel()
+
+		result := FuzzResult{Case: c.Name, StatusCode: status, Duration: time.Since(start)}
+		if err != nil {
+			result.Reason = fmt.Sprintf("no response: %v", err)
+		} else {
+			result.Reason = checkFuzzResponse(c.Expect, status, body)
+		}
+		result.Passed = result.Reason == ""
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// postJSON posts a raw payload to the HTTP JSON-RPC endpoint and returns the status and body
+func (r *RPCClient) postJSON(ctx context.Context, payload []byte) (int, []byte, error) {
+	status, _, body, err := r.postJSONWithHeaders(ctx, payload)
+	return status, body, err
+}
+
+// postJSONWithHeaders is postJSON that also returns the response headers
+func (r *RPCClient) postJSONWithHeaders(ctx context.Context, payload []byte) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for k, v := range r.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// checkFuzzResponse returns an empty string when the response meets the expectation,
+// or a human readable reason otherwise
+func checkFuzzResponse(expect FuzzExpectation, status int, body []byte) string {
+	if status >= 500 {
+		return fmt.Sprintf("server error status %d", status)
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		// Some endpoints reject malformed requests at the HTTP layer; that is fine
+		// as long as the status says so
+		if status >= 400 {
+			return ""
+		}
+		return fmt.Sprintf("empty body with status %d", status)
+	}
+
+	if expect == ExpectBatch && body[0] == '[' {
+		var batch []rpcResponse
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Sprintf("invalid batch response: %v", err)
+		}
+		if len(batch) == 0 {
+			return "empty batch response"
+		}
+		for i, resp := range batch {
+			if resp.Error == nil && resp.Result == nil {
+				return fmt.Sprintf("batch entry %d has neither result nor error", i)
+			}
+		}
+		return ""
+	}
+
+	var resp rpcResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		if status >= 400 {
+			return ""
+		}
+		return fmt.Sprintf("garbage response with status %d: %v", status, err)
+	}
+
+	switch expect {
+	case ExpectError, ExpectBatch:
+		if resp.Error == nil {
+			return "expected error object, got result"
+		}
+	case ExpectWellFormed:
+		if resp.Error == nil && resp.Result == nil {
+			return "response has neither result nor error"
+		}
+	}
+
+	return ""
+}
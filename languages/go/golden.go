@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// GoldenEntry is the canonical response to one pinned request
+type GoldenEntry struct {
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// GoldenFile is a versioned snapshot of responses to a pinned request set
+type GoldenFile struct {
+	Version    string        `json:"version"`
+	Endpoint   string        `json:"endpoint"`
+	RecordedAt time.Time     `json:"recordedAt"`
+	Entries    []GoldenEntry `json:"entries"`
+}
+
+// GoldenPath returns the file a golden version is stored at inside dir
+func GoldenPath(dir, version string) string {
+	return filepath.Join(dir, fmt.Sprintf("golden-%s.json", version))
+}
+
+// RecordGoldens issues every pinned request and writes the responses to a
+// versioned golden file in dir, returning its path
+func (r *RPCClient) RecordGoldens(ctx context.Context, dir, version string, requests []DiffRequest) (string, error) {
+	golden := GoldenFile{
+		Version:    version,
+		Endpoint:   r.DisplayURL(),
+		RecordedAt: time.Now().UTC(),
+	}
+
+	for _, req := range requests {
+		entry := GoldenEntry{Method: req.Method, Params: req.Params}
+		raw, err := r.CallRaw(ctx, req.Method, req.Params...)
+		if err != nil {
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) {
+				return "", err
+			}
+			entry.Error = rpcErr.Error()
+		} else {
+			entry.Result = raw
+		}
+		golden.Entries = append(golden.Entries, entry)
+	}
+
+	data, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode golden file: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create golden directory: %w", err)
+	}
+
+	path := GoldenPath(dir, version)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write golden file: %w", err)
+	}
+
+	return path, nil
+}
+
+// LoadGoldens reads a golden file written by RecordGoldens
+func LoadGoldens(path string) (*GoldenFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden file: %w", err)
+	}
+
+	var golden GoldenFile
+	if err := json.Unmarshal(data, &golden); err != nil {
+		return nil, fmt.Errorf("failed to decode golden file: %w", err)
+	}
+	return &golden, nil
+}
+
+// ReplayGoldens replays the request set of a golden file against the endpoint
+// and diffs the live responses against the recorded ones after normalization.
+// In the returned differences Target is the live value and Reference the golden one.
+func (r *RPCClient) ReplayGoldens(ctx context.Context, path string, normalizer *Normalizer) ([]Difference, error) {
+	golden, err := LoadGoldens(path)
+	if err != nil {
+		return nil, err
+	}
+	if normalizer == nil {
+		normalizer = NewNormalizer()
+	}
+
+	var diffs []Difference
+	for _, entry := range golden.Entries {
+		req := DiffRequest{Method: entry.Method, Params: entry.Params}
+
+		raw, err := r.CallRaw(ctx, entry.Method, entry.Params...)
+		var liveErr string
+		if err != nil {
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) {
+				return diffs, err
+			}
+			liveErr = rpcErr.Error()
+		}
+
+		if liveErr != "" || entry.Error != "" {
+			if liveErr != entry.Error {
+				diffs = append(diffs, Difference{Method: req.Method, Params: req.Params, Path: "$", Target: liveErr, Reference: entry.Error})
+			}
+			continue
+		}
+
+		found, err := normalizer.Diff(req, raw, entry.Result)
+		if err != nil {
+			return diffs, err
+		}
+		diffs = append(diffs, found...)
+	}
+
+	return diffs, nil
+}
+
+// GoldenCheck returns a conformance check that replays the golden file at path
+// and fails on any response that no longer matches it
+func GoldenCheck(path string) ConformanceCheck {
+	return ConformanceCheck{Name: "golden", Group: "consistency", Run: func(ctx context.Context, c *RPCClient) (bool, string, error) {
+		diffs, err := c.ReplayGoldens(ctx, path, nil)
+		if err != nil {
+			return false, "", err
+		}
+		problems := make([]string, len(diffs))
+		for i, d := range diffs {
+			problems[i] = d.String()
+		}
+		passed, detail := summarize(problems)
+		return passed, detail, nil
+	}}
+}
+
+// goldenBlock picks the block goldens are pinned to: the finalized block,
+// whose responses cannot change, or the head when the endpoint has no finality
+func (r *RPCClient) goldenBlock(ctx context.Context) (uint64, error) {
+	final, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", "finalized")
+	if err == nil && final != nil {
+		return uint64(final.Number), nil
+	}
+	return latestBlock(ctx, r)
+}
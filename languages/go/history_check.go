@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// missingStateErrors are the error fragments clients use to signal pruned state
+var missingStateErrors = []string{"missing trie node", "state not available", "historical state", "pruned", "state is not available", "header not found", "required historical state unavailable"}
+
+// HistoricalQuery is the endpoint's answer to one state query at a past block
+type HistoricalQuery struct {
+	Method    string
+	Block     uint64
+	Value     string
+	ErrCode   int
+	ErrMsg    string
+	Reference string
+}
+
+// HistoricalCheck holds the outcome of querying deep-historical state
+type HistoricalCheck struct {
+	Address  common.Address
+	Queries  []HistoricalQuery
+	Problems []string
+}
+
+// Passed reports whether the endpoint either served correct data or failed consistently
+func (c HistoricalCheck) Passed() bool {
+	return len(c.Problems) == 0
+}
+
+// CheckHistoricalState queries balance, nonce, code, and eth_call for address at
+// each block and verifies the endpoint either serves data or returns a
+// well-formed, consistent "state not available" error. When reference is not
+// nil, served values are compared against it to catch silently wrong answers.
+func (r *RPCClient) CheckHistoricalState(ctx context.Context, address common.Address, blocks []uint64, reference *RPCClient) (*HistoricalCheck, error) {
+	check := &HistoricalCheck{Address: address}
+	errorCodes := make(map[int]bool)
+
+	for _, block := range blocks {
+		tag := hexutil.EncodeUint64(block)
+		requests := []DiffRequest{
+			{Method: "eth_getBalance", Params: []interface{}{address, tag}},
+			{Method: "eth_getTransactionCount", Params: []interface{}{address, tag}},
+			{Method: "eth_getCode", Params: []interface{}{address, tag}},
+			{Method: "eth_call", Params: []interface{}{map[string]interface{}{"to": address, "data": "0x"}, tag}},
+		}
+
+		served, failed := 0, 0
+		for _, req := range requests {
+			q := HistoricalQuery{Method: req.Method, Block: block}
+
+			raw, err := r.CallRaw(ctx, req.Method, req.Params...)
+			if err != nil {
+				var rpcErr rpc.Error
+				if !errors.As(err, &rpcErr) {
+					return check, err
+				}
+				q.ErrCode = rpcErr.ErrorCode()
+				q.ErrMsg = rpcErr.Error()
+				failed++
+				errorCodes[q.ErrCode] = true
+
+				if !isMissingStateError(q.ErrMsg) {
+					check.Problems = append(check.Problems, fmt.Sprintf("%s at %d: unrecognized error %d %q", req.Method, block, q.ErrCode, q.ErrMsg))
+				}
+			} else {
+				q.Value = string(raw)
+				served++
+
+				if reference != nil {
+					refRaw, refErr := reference.CallRaw(ctx, req.Method, req.Params...)
+					if refErr == nil {
+						q.Reference = string(refRaw)
+						if normalizeResult(req.Method, strings.Trim(q.Value, `"`)) != normalizeResult(req.Method, strings.Trim(q.Reference, `"`)) {
+							check.Problems = append(check.Problems, fmt.Sprintf("%s at %d: served %s, reference %s", req.Method, block, q.Value, q.Reference))
+						}
+					}
+				}
+			}
+
+			check.Queries = append(check.Queries, q)
+		}
+
+		if served > 0 && failed > 0 {
+			check.Problems = append(check.Problems, fmt.Sprintf("block %d: state served for %d methods but unavailable for %d", block, served, failed))
+		}
+	}
+
+	if len(errorCodes) > 1 {
+		codes := make([]string, 0, len(errorCodes))
+		for code := range errorCodes {
+			codes = append(codes, fmt.Sprint(code))
+		}
+		check.Problems = append(check.Problems, fmt.Sprintf("inconsistent error codes for missing state: %s", strings.Join(codes, ", ")))
+	}
+
+	return check, nil
+}
+
+// isMissingStateError reports whether an error message signals unavailable historical state
+func isMissingStateError(msg string) bool {
+	lower := strings.ToLower(msg)
+	for _, fragment := range missingStateErrors {
+		if strings.Contains(lower, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HTTPCheck is the outcome of one HTTP-layer conformance probe
+type HTTPCheck struct {
+	Name   string
+	Passed bool
+	Status int
+	Detail string
+}
+
+// httpProbe describes a raw HTTP request for the conformance probe
+type httpProbe struct {
+	method  string
+	headers map[string]string
+	body    io.Reader
+	chunked bool
+}
+
+// doHTTP sends a raw HTTP request to the endpoint
+func (r *RPCClient) doHTTP(ctx context.Context, p httpProbe) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, p.method, r.rpcURL, p.body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	for k, v := range r.headers {
+		req.Header[k] = v
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	if p.chunked {
+		// An unknown length forces Transfer-Encoding: chunked
+		req.ContentLength = -1
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, resp.Header, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// isRPCResult reports whether body is a JSON-RPC response carrying a result
+func isRPCResult(body []byte) bool {
+	var resp rpcResponse
+	return json.Unmarshal(body, &resp) == nil && resp.Error == nil && resp.Result != nil
+}
+
+// CheckHTTPConformance probes Content-Type handling, GET vs POST, oversized and
+// chunked bodies, and CORS preflight behavior of an HTTP JSON-RPC endpoint.
+// origin is the browser origin used for the CORS checks.
+func (r *RPCClient) CheckHTTPConformance(ctx context.Context, origin string, maxBody int) ([]HTTPCheck, error) {
+	call := `{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`
+	var checks []HTTPCheck
+
+	add := func(name string, status int, passed bool, detail string) {
+		checks = append(checks, HTTPCheck{Name: name, Passed: passed, Status: status, Detail: detail})
+	}
+
+	contentTypes := []struct {
+		name        string
+		contentType string
+		mustServe   bool
+	}{
+		{"content-type application/json", "application/json", true},
+		{"content-type with charset", "application/json; charset=utf-8", true},
+		{"content-type text/plain", "text/plain", false},
+		{"content-type missing", "", false},
+	}
+	for _, ct := range contentTypes {
+		headers := map[string]string{}
+		if ct.contentType != "" {
+			headers["Content-Type"] = ct.contentType
+		}
+		status, _, body, err := r.doHTTP(ctx, httpProbe{method: http.MethodPost, headers: headers, body: strings.NewReader(call)})
+		if err != nil {
+			return checks, err
+		}
+		served := status == http.StatusOK && isRPCResult(body)
+		switch {
+		case served:
+			add(ct.name, status, true, "served")
+		case ct.mustServe:
+			add(ct.name, status, false, "valid request was not served")
+		default:
+			// Rejecting is fine as long as it is a clean 4xx rather than a server error
+			add(ct.name, status, status >= 400 && status < 500, "rejected")
+		}
+	}
+
+	status, _, _, err := r.doHTTP(ctx, httpProbe{method: http.MethodGet})
+	if err != nil {
+		return checks, err
+	}
+	add("GET request", status, status < 500, fmt.Sprintf("status %d", status))
+
+	status, _, body, err := r.doHTTP(ctx, httpProbe{method: http.MethodPut, headers: map[string]string{"Content-Type": "application/json"}, body: strings.NewReader(call)})
+	if err != nil {
+		return checks, err
+	}
+	add("PUT request", status, status == http.StatusMethodNotAllowed || (status >= 400 && status < 500) || isRPCResult(body), fmt.Sprintf("status %d", status))
+
+	oversized := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_chainId","params":["%s"],"id":1}`, strings.Repeat("a", maxBody))
+	status, _, _, err = r.doHTTP(ctx, httpProbe{method: http.MethodPost, headers: map[string]string{"Content-Type": "application/json"}, body: strings.NewReader(oversized)})
+	if err != nil {
+		add("oversized body", 0, true, fmt.Sprintf("connection closed: %v", err))
+	} else {
+		add("oversized body", status, status < 500, fmt.Sprintf("status %d for %d byte body", status, len(oversized)))
+	}
+
+	status, _, body, err = r.doHTTP(ctx, httpProbe{method: http.MethodPost, headers: map[string]string{"Content-Type": "application/json"}, body: bytes.NewBufferString(call), chunked: true})
+	if err != nil {
+		return checks, err
+	}
+	add("chunked body", status, status == http.StatusOK && isRPCResult(body), fmt.Sprintf("status %d", status))
+
+	status, header, _, err := r.doHTTP(ctx, httpProbe{method: http.MethodOptions, headers: map[string]string{
+		"Origin":                         origin,
+		"Access-Control-Request-Method":  http.MethodPost,
+		"Access-Control-Request-Headers": "content-type",
+	}})
+	if err != nil {
+		return checks, err
+	}
+	allowOrigin := header.Get("Access-Control-Allow-Origin")
+	allowMethods := strings.ToUpper(header.Get("Access-Control-Allow-Methods"))
+	allowHeaders := strings.ToLower(header.Get("Access-Control-Allow-Headers"))
+	add("CORS preflight status", status, status >= 200 && status < 300, fmt.Sprintf("status %d", status))
+	add("CORS preflight allow-origin", status, allowOrigin == "*" || allowOrigin == origin, fmt.Sprintf("Access-Control-Allow-Origin: %q", allowOrigin))
+	add("CORS preflight allow-methods", status, allowMethods == "" || allowMethods == "*" || strings.Contains(allowMethods, "POST"), fmt.Sprintf("Access-Control-Allow-Methods: %q", allowMethods))
+	add("CORS preflight allow-headers", status, allowHeaders == "*" || strings.Contains(allowHeaders, "content-type"), fmt.Sprintf("Access-Control-Allow-Headers: %q", allowHeaders))
+
+	status, header, _, err = r.doHTTP(ctx, httpProbe{method: http.MethodPost, headers: map[string]string{"Content-Type": "application/json", "Origin": origin}, body: strings.NewReader(call)})
+	if err != nil {
+		return checks, err
+	}
+	allowOrigin = header.Get("Access-Control-Allow-Origin")
+	add("CORS simple request allow-origin", status, allowOrigin == "*" || allowOrigin == origin, fmt.Sprintf("Access-Control-Allow-Origin: %q", allowOrigin))
+
+	return checks, nil
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// ibcChannelStates names the IBC channel State enum values
+var ibcChannelStates = []string{"UNINITIALIZED", "INIT", "TRYOPEN", "OPEN", "CLOSED", "FLUSHING", "FLUSHCOMPLETE"}
+
+// tendermintClientType is the type URL of a Tendermint light client state
+const tendermintClientType = "/ibc.lightclients.tendermint.v1.ClientState"
+
+// IBCChannel is one end of an IBC channel
+type IBCChannel struct {
+	PortID                string
+	ChannelID             string
+	State                 string
+	CounterpartyPortID    string
+	CounterpartyChannelID string
+}
+
+// ibcChannelFrom decodes a Channel or IdentifiedChannel: state = 1,
+// counterparty = 3 (port_id = 1, channel_id = 2), and for IdentifiedChannel
+// port_id = 6, channel_id = 7
+func ibcChannelFrom(f pbFields) (IBCChannel, error) {
+	counterparty, err := f.msg(3)
+	if err != nil {
+		return IBCChannel{}, err
+	}
+	state := fmt.Sprint(f.varint(1))
+	if s := f.varint(1); s < uint64(len(ibcChannelStates)) {
+		state = ibcChannelStates[s]
+	}
+	return IBCChannel{
+		PortID:                f.str(6),
+		ChannelID:             f.str(7),
+		State:                 state,
+		CounterpartyPortID:    counterparty.str(1),
+		CounterpartyChannelID: counterparty.str(2),
+	}, nil
+}
+
+// portChannel encodes the port_id = 1, channel_id = 2 fields that start most
+// IBC channel queries
+func portChannel(port, channel string) []byte {
+	return pbString(pbString(nil, 1, port), 2, channel)
+}
+
+// IBCChannels lists every channel end on the chain
+func (c *CosmosQueryClient) IBCChannels(ctx context.Context) ([]IBCChannel, error) {
+	// QueryChannelsRequest: pagination = 1; the response has channels = 1,
+	// pagination = 2
+	items, err := c.queryPages(ctx, "/ibc.core.channel.v1.Query/Channels", func(page []byte) []byte {
+		return pbBytes(nil, 1, page)
+	}, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]IBCChannel, len(items))
+	for i, item := range items {
+		if channels[i], err = ibcChannelFrom(item); err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// IBCChannel returns one channel end
+func (c *CosmosQueryClient) IBCChannel(ctx context.Context, port, channel string) (*IBCChannel, error) {
+	// QueryChannelResponse: channel = 1
+	fields, err := c.query(ctx, "/ibc.core.channel.v1.Query/Channel", portChannel(port, channel))
+	if err != nil {
+		return nil, err
+	}
+	end, err := fields.msg(1)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := ibcChannelFrom(end)
+	if err != nil {
+		return nil, err
+	}
+	ch.PortID, ch.ChannelID = port, channel
+	return &ch, nil
+}
+
+// IBCChannelChainID returns the chain ID of the light client a channel runs
+// over, the chain at its other end, or "" for non-Tendermint clients
+func (c *CosmosQueryClient) IBCChannelChainID(ctx context.Context, port, channel string) (string, error) {
+	// QueryChannelClientStateResponse: identified_client_state = 1
+	// (client_state = 2, an Any); the Tendermint ClientState has chain_id = 1
+	fields, err := c.query(ctx, "/ibc.core.channel.v1.Query/ChannelClientState", portChannel(port, channel))
+	if err != nil {
+		return "", err
+	}
+	identified, err := fields.msg(1)
+	if err != nil {
+		return "", err
+	}
+	packed, err := identified.msg(2)
+	if err != nil {
+		return "", err
+	}
+	if packed.str(1) != tendermintClientType {
+		return "", nil
+	}
+	state, err := packed.msg(2)
+	if err != nil {
+		return "", err
+	}
+	return state.str(1), nil
+}
+
+// PacketCommitments returns the sequences of the packets sent over a channel
+// that are neither acknowledged nor timed out
+func (c *CosmosQueryClient) PacketCommitments(ctx context.Context, port, channel string) ([]uint64, error) {
+	// QueryPacketCommitmentsRequest: pagination = 3; the response has
+	// commitments = 1 (PacketState, sequence = 3), pagination = 2
+	items, err := c.queryPages(ctx, "/ibc.core.channel.v1.Query/PacketCommitments", func(page []byte) []byte {
+		return pbBytes(portChannel(port, channel), 3, page)
+	}, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	sequences := make([]uint64, len(items))
+	for i, item := range items {
+		sequences[i] = item.varint(3)
+	}
+	return sequences, nil
+}
+
+// UnreceivedPackets returns which of the sequences, committed on the other
+// end, this channel end has not received
+func (c *CosmosQueryClient) UnreceivedPackets(ctx context.Context, port, channel string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+	// packet_commitment_sequences = 3; the response has sequences = 1
+	fields, err := c.query(ctx, "/ibc.core.channel.v1.Query/UnreceivedPackets", pbPacked(portChannel(port, channel), 3, sequences))
+	if err != nil {
+		return nil, err
+	}
+	return fields.uints(1)
+}
+
+// PacketAcknowledgements returns which of the sequences this channel end has
+// written an acknowledgement for
+func (c *CosmosQueryClient) PacketAcknowledgements(ctx context.Context, port, channel string, sequences []uint64) ([]uint64, error) {
+	// Without sequences the query lists every acknowledgement instead
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+	// pagination = 3, packet_commitment_sequences = 4; the response has
+	// acknowledgements = 1 (PacketState, sequence = 3), pagination = 2
+	items, err := c.queryPages(ctx, "/ibc.core.channel.v1.Query/PacketAcknowledgements", func(page []byte) []byte {
+		return pbPacked(pbBytes(portChannel(port, channel), 3, page), 4, sequences)
+	}, 1, 2)
+	if err != nil {
+		return nil, err
+	}
+	acked := make([]uint64, len(items))
+	for i, item := range items {
+		acked[i] = item.varint(3)
+	}
+	return acked, nil
+}
+
+// UnreceivedAcks returns which of the sequences, acknowledged on the other
+// end, still hold a commitment here: the acknowledgement was not relayed back
+func (c *CosmosQueryClient) UnreceivedAcks(ctx context.Context, port, channel string, sequences []uint64) ([]uint64, error) {
+	if len(sequences) == 0 {
+		return nil, nil
+	}
+	// packet_ack_sequences = 3; the response has sequences = 1
+	fields, err := c.query(ctx, "/ibc.core.channel.v1.Query/UnreceivedAcks", pbPacked(portChannel(port, channel), 3, sequences))
+	if err != nil {
+		return nil, err
+	}
+	return fields.uints(1)
+}
+
+// IBCChain is one of the two chains an IBC check compares
+type IBCChain struct {
+	Name    string
+	ChainID string
+	Comet   *CometClient
+	Queries *CosmosQueryClient
+
+	// blockTimes caches block times by height for age and latency lookups
+	blockTimes map[int64]time.Time
+	// noTxIndex is set once tx_search fails, so ages and latencies are skipped
+	noTxIndex bool
+}
+
+// NewIBCChain prepares a chain for IBC checks, reading its chain ID
+func NewIBCChain(ctx context.Context, name string, comet *CometClient, queries *CosmosQueryClient) (*IBCChain, error) {
+	status, err := comet.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s status: %w", name, err)
+	}
+	return &IBCChain{
+		Name:       name,
+		ChainID:    status.NodeInfo.Network,
+		Comet:      comet,
+		Queries:    queries,
+		blockTimes: map[int64]time.Time{},
+	}, nil
+}
+
+// blockTime returns the time of the block at height
+func (c *IBCChain) blockTime(ctx context.Context, height int64) (time.Time, error) {
+	if t, ok := c.blockTimes[height]; ok {
+		return t, nil
+	}
+	block, err := c.Comet.Block(ctx, height)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := block.Block.Header.Time
+	c.blockTimes[height] = t
+	return t, nil
+}
+
+// searchTxs runs tx_search, remembering when the node does not index
+// transactions so later lookups are skipped
+func (c *IBCChain) searchTxs(ctx context.Context, query string, perPage int, logger *slog.Logger) []CometTx {
+	if c.noTxIndex {
+		return nil
+	}
+	result, err := c.Comet.TxSearch(ctx, query, 1, perPage, false)
+	if err != nil {
+		logger.Warn("tx_search failed; packet ages and relay latencies are unavailable", "chain", c.Name, "error", err)
+		c.noTxIndex = true
+		return nil
+	}
+	return result.Txs
+}
+
+// sendTime returns the block time of the transaction that sent a packet, or
+// the zero time when it cannot be found
+func (c *IBCChain) sendTime(ctx context.Context, port, channel string, sequence uint64, logger *slog.Logger) time.Time {
+	query := fmt.Sprintf("send_packet.packet_src_port='%s' AND send_packet.packet_src_channel='%s' AND send_packet.packet_sequence='%d'", port, channel, sequence)
+	txs := c.searchTxs(ctx, query, 1, logger)
+	if len(txs) == 0 {
+		return time.Time{}
+	}
+	t, err := c.blockTime(ctx, txs[0].Height)
+	if err != nil {
+		logger.Debug("failed to read block time", "chain", c.Name, "height", txs[0].Height, "error", err)
+	}
+	return t
+}
+
+// IBCCheckOptions select the channels an IBC check covers and when it
+// calls them stuck
+type IBCCheckOptions struct {
+	// Port and Channel restrict the check to one port or to one channel of
+	// the first chain, matched on either end; empty matches all
+	Port    string
+	Channel string
+	// StuckAfter is how old the oldest unrelayed packet or acknowledgement
+	// may get before its channel is stuck
+	StuckAfter time.Duration
+	// LatencySamples is how many recently relayed packets to time
+	LatencySamples int
+}
+
+// IBCChannelReport is the state of packets flowing one way over a channel
+type IBCChannelReport struct {
+	Source              string
+	Destination         string
+	Port                string
+	Channel             string
+	State               string
+	CounterpartyPort    string
+	CounterpartyChannel string
+	CounterpartyState   string
+	// Pending counts packets sent and not yet acknowledged or timed out
+	Pending int
+	// Unreceived counts pending packets the destination has not received
+	Unreceived int
+	// AwaitingAck counts received packets the destination has not yet
+	// written an acknowledgement for
+	AwaitingAck int
+	// UnrelayedAcks counts acknowledgements not yet relayed back
+	UnrelayedAcks int
+	// OldestSequence and OldestAge describe the oldest unreceived packet or
+	// unrelayed acknowledgement; OldestAge is zero when unknown
+	OldestSequence uint64
+	OldestAge      time.Duration
+	// RelayLatency and MaxRelayLatency are the median and maximum time from
+	// send to receipt over LatencySamples recently relayed packets
+	RelayLatency    time.Duration
+	MaxRelayLatency time.Duration
+	LatencySamples  int
+	// Stuck says why the channel is stuck, "" when it is not
+	Stuck string
+}
+
+// CheckIBC reports the packet flow over every channel between chains a and
+// b, in both directions
+func CheckIBC(ctx context.Context, a, b *IBCChain, opts IBCCheckOptions, logger *slog.Logger) ([]IBCChannelReport, error) {
+	var reports []IBCChannelReport
+	for _, pair := range [][2]*IBCChain{{a, b}, {b, a}} {
+		src, dst := pair[0], pair[1]
+		channels, err := src.Queries.IBCChannels(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s channels: %w", src.Name, err)
+		}
+		for _, ch := range channels {
+			if opts.Port != "" && ch.PortID != opts.Port {
+				continue
+			}
+			if opts.Channel != "" && ((src == a && ch.ChannelID != opts.Channel) || (src == b && ch.CounterpartyChannelID != opts.Channel)) {
+				continue
+			}
+			// Channel IDs repeat across chains, so match on the light client
+			chainID, err := src.Queries.IBCChannelChainID(ctx, ch.PortID, ch.ChannelID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the client of %s %s/%s: %w", src.Name, ch.PortID, ch.ChannelID, err)
+			}
+			if chainID != dst.ChainID || ch.CounterpartyChannelID == "" {
+				continue
+			}
+			report, err := checkIBCChannel(ctx, src, dst, ch, opts, logger)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// checkIBCChannel follows the packets sent from src over one channel
+func checkIBCChannel(ctx context.Context, src, dst *IBCChain, ch IBCChannel, opts IBCCheckOptions, logger *slog.Logger) (IBCChannelReport, error) {
+	report := IBCChannelReport{
+		Source:              src.Name,
+		Destination:         dst.Name,
+		Port:                ch.PortID,
+		Channel:             ch.ChannelID,
+		State:               ch.State,
+		CounterpartyPort:    ch.CounterpartyPortID,
+		CounterpartyChannel: ch.CounterpartyChannelID,
+	}
+	fail := func(what string, err error) (IBCChannelReport, error) {
+		return report, fmt.Errorf("failed to read %s of %s %s/%s: %w", what, src.Name, ch.PortID, ch.ChannelID, err)
+	}
+
+	counterparty, err := dst.Queries.IBCChannel(ctx, ch.CounterpartyPortID, ch.CounterpartyChannelID)
+	if err != nil {
+		return fail("the counterparty", err)
+	}
+	report.CounterpartyState = counterparty.State
+
+	// A packet's commitment lives on the source until its acknowledgement is
+	// relayed back; the destination's receipts and acknowledgements show how
+	// far it got
+	committed, err := src.Queries.PacketCommitments(ctx, ch.PortID, ch.ChannelID)
+	if err != nil {
+		return fail("packet commitments", err)
+	}
+	unreceived, err := dst.Queries.UnreceivedPackets(ctx, ch.CounterpartyPortID, ch.CounterpartyChannelID, committed)
+	if err != nil {
+		return fail("unreceived packets", err)
+	}
+	received := subtractSequences(committed, unreceived)
+	acked, err := dst.Queries.PacketAcknowledgements(ctx, ch.CounterpartyPortID, ch.CounterpartyChannelID, received)
+	if err != nil {
+		return fail("acknowledgements", err)
+	}
+	unrelayed, err := src.Queries.UnreceivedAcks(ctx, ch.PortID, ch.ChannelID, acked)
+	if err != nil {
+		return fail("unrelayed acknowledgements", err)
+	}
+	report.Pending = len(committed)
+	report.Unreceived = len(unreceived)
+	report.AwaitingAck = len(received) - len(acked)
+	report.UnrelayedAcks = len(unrelayed)
+
+	inFlight := append(append([]uint64(nil), unreceived...), unrelayed...)
+	if len(inFlight) > 0 {
+		sort.Slice(inFlight, func(i, j int) bool { return inFlight[i] < inFlight[j] })
+		report.OldestSequence = inFlight[0]
+		if sent := src.sendTime(ctx, ch.PortID, ch.ChannelID, inFlight[0], logger); !sent.IsZero() {
+			report.OldestAge = time.Since(sent)
+		}
+	}
+
+	switch {
+	case ch.State != "OPEN" && len(inFlight) > 0:
+		report.Stuck = fmt.Sprintf("%d packets in flight on a %s channel", len(inFlight), ch.State)
+	case counterparty.State != "OPEN" && len(inFlight) > 0:
+		report.Stuck = fmt.Sprintf("%d packets in flight to a %s counterparty", len(inFlight), counterparty.State)
+	case opts.StuckAfter > 0 && report.OldestAge > opts.StuckAfter:
+		report.Stuck = fmt.Sprintf("packet %d unrelayed for %s", report.OldestSequence, report.OldestAge.Round(time.Second))
+	}
+
+	if opts.LatencySamples > 0 {
+		latencies := relayLatencies(ctx, src, dst, ch, opts.LatencySamples, logger)
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			report.RelayLatency = latencies[len(latencies)/2]
+			report.MaxRelayLatency = latencies[len(latencies)-1]
+			report.LatencySamples = len(latencies)
+		}
+	}
+	return report, nil
+}
+
+// relayLatencies times up to samples recently received packets from the
+// source's send block to the destination's receive block
+func relayLatencies(ctx context.Context, src, dst *IBCChain, ch IBCChannel, samples int, logger *slog.Logger) []time.Duration {
+	query := fmt.Sprintf("recv_packet.packet_dst_port='%s' AND recv_packet.packet_dst_channel='%s'", ch.CounterpartyPortID, ch.CounterpartyChannelID)
+	var latencies []time.Duration
+	for _, tx := range dst.searchTxs(ctx, query, samples, logger) {
+		received, err := dst.blockTime(ctx, tx.Height)
+		if err != nil {
+			logger.Debug("failed to read block time", "chain", dst.Name, "height", tx.Height, "error", err)
+			continue
+		}
+		// A relayer transaction can carry packets of several channels
+		for _, event := range tx.TxResult.Events {
+			if event.Type != "recv_packet" || event.Attr("packet_dst_channel") != ch.CounterpartyChannelID {
+				continue
+			}
+			var sequence uint64
+			if _, err := fmt.Sscan(event.Attr("packet_sequence"), &sequence); err != nil {
+				continue
+			}
+			if sent := src.sendTime(ctx, ch.PortID, ch.ChannelID, sequence, logger); !sent.IsZero() {
+				latencies = append(latencies, received.Sub(sent))
+			}
+			if len(latencies) >= samples {
+				return latencies
+			}
+		}
+	}
+	return latencies
+}
+
+// subtractSequences returns the sequences of all that are not in remove
+func subtractSequences(all, remove []uint64) []uint64 {
+	removed := make(map[uint64]bool, len(remove))
+	for _, s := range remove {
+		removed[s] = true
+	}
+	var out []uint64
+	for _, s := range all {
+		if !removed[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
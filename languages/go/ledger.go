@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultLedgerPath is the first account of Ledger Live's derivation scheme
+const DefaultLedgerPath = "m/44'/60'/0'/0/0"
+
+// Ethereum app APDU instructions
+const (
+	ledgerCLA        = 0xe0
+	ledgerInsAddress = 0x02
+	ledgerInsSignTx  = 0x04
+)
+
+// ledgerChunk is the most APDU data the Ethereum app accepts per exchange
+const ledgerChunk = 255
+
+// Ledger status words
+const (
+	ledgerStatusOK       = 0x9000
+	ledgerStatusRejected = 0x6985
+	ledgerStatusLocked   = 0x6b0c
+)
+
+// ErrLedgerRejected is returned when the user declines on the device
+var ErrLedgerRejected = errors.New("rejected on the Ledger device")
+
+// ledgerReport is the HID report size of Ledger devices
+const ledgerReport = 64
+
+// LedgerSigner signs with the Ethereum app of a Ledger device over USB HID.
+// Every transaction is shown on the device and must be approved there.
+type LedgerSigner struct {
+	device  io.ReadWriteCloser
+	path    accounts.DerivationPath
+	address common.Address
+
+	// mu serializes exchanges: the device handles one APDU at a time
+	mu sync.Mutex
+}
+
+// OpenLedger connects to the first Ledger device with the Ethereum app open
+// and reads the address at path. With confirm set the address is also shown
+// on the device, which waits for the user to approve it.
+func OpenLedger(path string, confirm bool) (*LedgerSigner, error) {
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path: %w", err)
+	}
+	device, err := openLedgerDevice()
+	if err != nil {
+		return nil, err
+	}
+	signer := &LedgerSigner{device: device, path: derivation}
+	if signer.address, err = signer.deriveAddress(derivation, confirm); err != nil {
+		device.Close()
+		return nil, err
+	}
+	return signer, nil
+}
+
+// Address implements Signer
+func (l *LedgerSigner) Address() common.Address {
+	return l.address
+}
+
+// Path returns the derivation path of the signing account
+func (l *LedgerSigner) Path() string {
+	return l.path.String()
+}
+
+// DeriveAddress returns the address at path on the same device, showing it
+// for approval when confirm is set
+func (l *LedgerSigner) DeriveAddress(path string, confirm bool) (common.Address, error) {
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid derivation path: %w", err)
+	}
+	return l.deriveAddress(derivation, confirm)
+}
+
+// deriveAddress asks the Ethereum app for the address at path
+func (l *LedgerSigner) deriveAddress(path accounts.DerivationPath, confirm bool) (common.Address, error) {
+	var p1 byte
+	if confirm {
+		p1 = 0x01
+	}
+	reply, err := l.exchange(ledgerInsAddress, p1, 0, encodeLedgerPath(path))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to read Ledger address: %w", err)
+	}
+
+	// Reply: public key length and key, then address length and hex address
+	if len(reply) < 1 || len(reply) < 1+int(reply[0])+1 {
+		return common.Address{}, fmt.Errorf("short Ledger address reply")
+	}
+	reply = reply[1+int(reply[0]):]
+	if len(reply) < 1+int(reply[0]) || reply[0] != 40 {
+		return common.Address{}, fmt.Errorf("malformed Ledger address reply")
+	}
+	hexAddress := string(reply[1 : 1+int(reply[0])])
+	if !common.IsHexAddress(hexAddress) {
+		return common.Address{}, fmt.Errorf("malformed Ledger address %q", hexAddress)
+	}
+	return common.HexToAddress(hexAddress), nil
+}
+
+// SignTx implements Signer. It blocks until the transaction is approved or
+// rejected on the device, or ctx is done.
+func (l *LedgerSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var payload []byte
+	var err error
+	switch tx.Type() {
+	case types.LegacyTxType:
+		// EIP-155 signing payload
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, uint(0), uint(0),
+		})
+	case types.DynamicFeeTxType:
+		payload, err = rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce(), tx.GasTipCap(), tx.GasFeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList(),
+		})
+		payload = append([]byte{types.DynamicFeeTxType}, payload...)
+	default:
+		return nil, fmt.Errorf("Ledger signing of transaction type %d is not supported", tx.Type())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	type result struct {
+		reply []byte
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reply, err := l.signPayload(append(encodeLedgerPath(l.path), payload...))
+		done <- result{reply, err}
+	}()
+
+	var reply []byte
+	select {
+	case <-ctx.Done():
+		// The device keeps waiting for the user; its answer is discarded
+		return nil, fmt.Errorf("waiting for Ledger approval: %w", ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		reply = r.reply
+	}
+	if len(reply) != 65 {
+		return nil, fmt.Errorf("malformed Ledger signature reply (%d bytes)", len(reply))
+	}
+
+	// The device returns v first; go-ethereum wants [r || s || v]
+	signature := append(append([]byte{}, reply[1:]...), reply[0])
+	if tx.Type() == types.LegacyTxType {
+		// v is chainID*2+35+parity truncated to a byte; keep only the parity
+		signature[64] -= byte(chainID.Uint64()*2 + 35)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	signed, err := tx.WithSignature(signer, signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ledger signature: %w", err)
+	}
+	if from, err := types.Sender(signer, signed); err != nil || from != l.address {
+		return nil, fmt.Errorf("Ledger signature does not recover to %s", l.address.Hex())
+	}
+	return signed, nil
+}
+
+// signPayload streams a signing payload to the device in chunks and returns
+// the signature from the final reply
+func (l *LedgerSigner) signPayload(data []byte) ([]byte, error) {
+	var reply []byte
+	for first := true; len(data) > 0; first = false {
+		chunk := data
+		if len(chunk) > ledgerChunk {
+			chunk = chunk[:ledgerChunk]
+		}
+		data = data[len(chunk):]
+
+		var p1 byte = 0x80
+		if first {
+			p1 = 0x00
+		}
+		var err error
+		if reply, err = l.exchange(ledgerInsSignTx, p1, 0, chunk); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// exchange sends one APDU over the HID transport and returns the reply data
+// without the status word
+func (l *LedgerSigner) exchange(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	apdu := make([]byte, 2, 7+len(data))
+	binary.BigEndian.PutUint16(apdu, uint16(5+len(data)))
+	apdu = append(apdu, ledgerCLA, ins, p1, p2, byte(len(data)))
+	apdu = append(apdu, data...)
+
+	// Frames carry channel 0x0101, tag 0x05, and a sequence number
+	for seq := 0; len(apdu) > 0; seq++ {
+		frame := make([]byte, ledgerReport)
+		copy(frame, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(frame[3:], uint16(seq))
+		n := copy(frame[5:], apdu)
+		apdu = apdu[n:]
+		if err := writeLedgerReport(l.device, frame); err != nil {
+			return nil, fmt.Errorf("failed to write to Ledger: %w", err)
+		}
+	}
+
+	var reply []byte
+	want := -1
+	frame := make([]byte, ledgerReport)
+	for seq := 0; want < 0 || len(reply) < want; seq++ {
+		if _, err := io.ReadFull(l.device, frame); err != nil {
+			return nil, fmt.Errorf("failed to read from Ledger: %w", err)
+		}
+		if frame[0] != 0x01 || frame[1] != 0x01 || frame[2] != 0x05 || int(binary.BigEndian.Uint16(frame[3:])) != seq {
+			return nil, fmt.Errorf("unexpected Ledger reply frame")
+		}
+		payload := frame[5:]
+		if seq == 0 {
+			want = int(binary.BigEndian.Uint16(payload))
+			payload = payload[2:]
+		}
+		if left := want - len(reply); len(payload) > left {
+			payload = payload[:left]
+		}
+		reply = append(reply, payload...)
+	}
+	if len(reply) < 2 {
+		return nil, fmt.Errorf("short Ledger reply")
+	}
+
+	status := binary.BigEndian.Uint16(reply[len(reply)-2:])
+	switch status {
+	case ledgerStatusOK:
+		return reply[:len(reply)-2], nil
+	case ledgerStatusRejected:
+		return nil, ErrLedgerRejected
+	case ledgerStatusLocked:
+		return nil, fmt.Errorf("Ledger is locked; unlock it and open the Ethereum app")
+	default:
+		return nil, fmt.Errorf("Ledger returned status 0x%04x; is the Ethereum app open?", status)
+	}
+}
+
+// Close releases the device
+func (l *LedgerSigner) Close() error {
+	return l.device.Close()
+}
+
+// encodeLedgerPath serializes a derivation path as the Ethereum app expects:
+// a component count followed by big-endian components
+func encodeLedgerPath(path accounts.DerivationPath) []byte {
+	out := []byte{byte(len(path))}
+	for _, component := range path {
+		out = binary.BigEndian.AppendUint32(out, component)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ledgerVendorID is Ledger's USB vendor ID as it appears in HID_ID
+const ledgerVendorID = "00002C97"
+
+// ledgerUsagePage starts the report descriptor of the Ledger's generic HID
+// interface (usage page 0xFFA0), as opposed to its U2F interface
+var ledgerUsagePage = []byte{0x06, 0xa0, 0xff}
+
+// openLedgerDevice opens the hidraw node of the first connected Ledger
+func openLedgerDevice() (io.ReadWriteCloser, error) {
+	nodes, err := filepath.Glob("/sys/class/hidraw/hidraw*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list HID devices: %w", err)
+	}
+	for _, node := range nodes {
+		uevent, err := os.ReadFile(filepath.Join(node, "device", "uevent"))
+		if err != nil || !strings.Contains(strings.ToUpper(string(uevent)), ":"+ledgerVendorID+":") {
+			continue
+		}
+		descriptor, err := os.ReadFile(filepath.Join(node, "device", "report_descriptor"))
+		if err != nil || !bytes.HasPrefix(descriptor, ledgerUsagePage) {
+			continue
+		}
+
+		device, err := os.OpenFile(filepath.Join("/dev", filepath.Base(node)), os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open Ledger (check the udev rules grant access to %s): %w", filepath.Base(node), err)
+		}
+		return device, nil
+	}
+	return nil, fmt.Errorf("no Ledger found; connect it, unlock it, and open the Ethereum app")
+}
+
+// writeLedgerReport writes one HID report. hidraw expects a leading report
+// ID, which is zero for Ledger's unnumbered reports.
+func writeLedgerReport(w io.Writer, report []byte) error {
+	_, err := w.Write(append([]byte{0}, report...))
+	return err
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// openLedgerDevice is only implemented over Linux hidraw
+func openLedgerDevice() (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("Ledger signing is not supported on %s", runtime.GOOS)
+}
+
+// writeLedgerReport writes one HID report
+func writeLedgerReport(w io.Writer, report []byte) error {
+	_, err := w.Write(report)
+	return err
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Log formats accepted by --log-format
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logLevels maps --log-level values to slog levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// NewLogger creates a logger writing to w at level ("debug", "info", "warn",
+// or "error") as text or JSON. Secrets are redacted from every value.
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q (want debug, info, warn, or error)", level)
+	}
+	options := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redactAttr}
+
+	switch format {
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want %s or %s)", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// redactAttr scrubs registered secrets from string and error values
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	switch v := a.Value.Any().(type) {
+	case string:
+		return slog.String(a.Key, Redact(v))
+	case error:
+		return slog.String(a.Key, Redact(v.Error()))
+	}
+	return a
+}
+
+// slowLogBodyLimit caps each body in a slow-call log entry
+const slowLogBodyLimit = 4096
+
+// loggingTransport logs every JSON-RPC request at debug level with its
+// methods, host, duration, and any JSON-RPC error code. Requests slower than
+// the client's slow-call threshold are also logged, with a sample of them
+// carrying the full request and response bodies, at warn level.
+type loggingTransport struct {
+	base   http.RoundTripper
+	client *RPCClient
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := t.client.logger
+	debug := logger.Enabled(req.Context(), slog.LevelDebug)
+	slowThreshold := t.client.slowThreshold
+	if !debug && slowThreshold <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	payload := requestBody(req)
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+	slow := slowThreshold > 0 && elapsed >= slowThreshold
+
+	attrs := []interface{}{
+		"methods", strings.Join(payloadMethods(payload), ","),
+		"host", req.URL.Hostname(),
+		"duration", elapsed,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+		logger.Debug("rpc call failed", attrs...)
+		if slow {
+			t.logSlow(attrs, payload, nil)
+		}
+		return nil, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	attrs = append(attrs, "status", resp.StatusCode)
+	if readErr == nil {
+		if code, message, ok := responseError(body); ok {
+			attrs = append(attrs, "error_code", code, "error", message)
+		}
+	}
+	logger.Debug("rpc call", attrs...)
+	if slow {
+		t.logSlow(attrs, payload, body)
+	}
+	return resp, nil
+}
+
+// logSlow logs a call that crossed the slow-call threshold; sampled calls
+// include their bodies
+func (t *loggingTransport) logSlow(attrs []interface{}, request, response []byte) {
+	attrs = append(attrs, "threshold", t.client.slowThreshold)
+	if rand.Float64() < t.client.slowSample {
+		attrs = append(attrs, "request", truncateBody(request), "response", truncateBody(response))
+	}
+	t.client.logger.Warn("slow rpc call", attrs...)
+}
+
+// truncateBody renders a body for a log entry, cut at slowLogBodyLimit
+func truncateBody(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) <= slowLogBodyLimit {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:slowLogBodyLimit], len(body))
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LogsCase is one eth_getLogs filter exercising a boundary condition
+type LogsCase struct {
+	Name   string
+	Filter map[string]interface{}
+}
+
+// LogsOutcome is a single endpoint's answer to a logs case
+type LogsOutcome struct {
+	Endpoint string
+	Count    int
+	Digest   common.Hash
+	Err      string
+}
+
+// LogsCaseResult collects every endpoint's answer to one case
+type LogsCaseResult struct {
+	Case       string
+	Outcomes   []LogsOutcome
+	Consistent bool
+}
+
+// LogsCaseExpectations records the cases whose outcome the spec pins down:
+// true means the filter must be rejected, false that it must succeed. Cases
+// not listed are implementation-defined and only compared across endpoints.
+var LogsCaseExpectations = map[string]bool{
+	"range":                false,
+	"single block":         false,
+	"empty topics":         false,
+	"null topic wildcard":  false,
+	"topic match":          false,
+	"nested topic OR-list": false,
+	"address list":         false,
+	"blockHash":            false,
+	"blockHash with range": true,
+	"too many topics":      true,
+}
+
+// DefaultLogsCases returns boundary filters around a block range known to
+// contain logs from address with topic as the first topic
+func DefaultLogsCases(from, to uint64, blockHash common.Hash, address common.Address, topic common.Hash) []LogsCase {
+	fromTag, toTag := hexutil.EncodeUint64(from), hexutil.EncodeUint64(to)
+
+	addresses := make([]common.Address, 0, 1000)
+	addresses = append(addresses, address)
+	for i := 1; i < cap(addresses); i++ {
+		addresses = append(addresses, common.BigToAddress(big.NewInt(int64(i))))
+	}
+
+	return []LogsCase{
+		{Name: "range", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": address}},
+		{Name: "from > to", Filter: map[string]interface{}{"fromBlock": toTag, "toBlock": fromTag}},
+		{Name: "single block", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": fromTag, "address": address}},
+		{Name: "empty topics", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": address, "topics": []interface{}{}}},
+		{Name: "null topic wildcard", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": address, "topics": []interface{}{nil}}},
+		{Name: "topic match", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "topics": []interface{}{topic}}},
+		{Name: "nested topic OR-list", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "topics": []interface{}{[]interface{}{topic, common.Hash{}}}}},
+		{Name: "empty nested OR-list", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": address, "topics": []interface{}{[]interface{}{}}}},
+		{Name: "too many topics", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "topics": []interface{}{nil, nil, nil, nil, topic}}},
+		{Name: "address list", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": []common.Address{address, {}}}},
+		{Name: "large address list", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": addresses}},
+		{Name: "empty address list", Filter: map[string]interface{}{"fromBlock": fromTag, "toBlock": toTag, "address": []common.Address{}}},
+		{Name: "blockHash", Filter: map[string]interface{}{"blockHash": blockHash}},
+		{Name: "blockHash with range", Filter: map[string]interface{}{"blockHash": blockHash, "fromBlock": fromTag}},
+		{Name: "unknown blockHash", Filter: map[string]interface{}{"blockHash": common.Hash{0x01}}},
+	}
+}
+
+// transferTopic is the ERC-20 Transfer event topic, common enough to match logs in most ranges
+var transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// logsCasesAt returns the default cases over [from, to], using the hash and
+// fee recipient of block to as client sees it
+func logsCasesAt(ctx context.Context, client *RPCClient, from, to uint64) ([]LogsCase, error) {
+	block, err := client.getBlockSummary(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", to, err)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", to)
+	}
+	return DefaultLogsCases(from, to, block.Hash, block.Miner, transferTopic), nil
+}
+
+// RunLogsBoundarySuite runs every case against every client and reports whether
+// the endpoints agree on the semantics: all fail, or all return the same logs
+func RunLogsBoundarySuite(ctx context.Context, clients []*RPCClient, cases []LogsCase) ([]LogsCaseResult, error) {
+	results := make([]LogsCaseResult, 0, len(cases))
+	for _, c := range cases {
+		result := LogsCaseResult{Case: c.Name, Consistent: true}
+
+		for _, client := range clients {
+			outcome := LogsOutcome{Endpoint: client.DisplayURL()}
+
+			raw, err := client.CallRaw(ctx, "eth_getLogs", c.Filter)
+			if err != nil {
+				outcome.Err = err.Error()
+			} else {
+				count, digest, err := digestLogs(raw)
+				if err != nil {
+					return results, fmt.Errorf("%s: %w", client.DisplayURL(), err)
+				}
+				outcome.Count = count
+				outcome.Digest = digest
+			}
+
+			if len(result.Outcomes) > 0 {
+				first := result.Outcomes[0]
+				if (first.Err == "") != (outcome.Err == "") || first.Digest != outcome.Digest {
+					result.Consistent = false
+				}
+			}
+			result.Outcomes = append(result.Outcomes, outcome)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// digestLogs hashes the identity of every returned log so result sets can be
+// compared across endpoints independent of ordering
+func digestLogs(raw json.RawMessage) (int, common.Hash, error) {
+	var logs []struct {
+		BlockHash common.Hash    `json:"blockHash"`
+		LogIndex  hexutil.Uint64 `json:"logIndex"`
+	}
+	if err := json.Unmarshal(raw, &logs); err != nil {
+		return 0, common.Hash{}, fmt.Errorf("failed to decode logs: %w", err)
+	}
+
+	ids := make([]string, len(logs))
+	for i, l := range logs {
+		ids[i] = fmt.Sprintf("%s:%d", l.BlockHash.Hex(), uint64(l.LogIndex))
+	}
+	sort.Strings(ids)
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return 0, common.Hash{}, err
+	}
+
+	return len(logs), crypto.Keccak256Hash(data), nil
+}
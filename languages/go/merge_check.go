@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ForkHeights tells the block shape check which fields to expect at a height.
+// A nil height means the fork is not expected to be active.
+type ForkHeights struct {
+	Merge    *uint64 `yaml:"merge" toml:"merge"`
+	Shanghai *uint64 `yaml:"shanghai" toml:"shanghai"`
+	Cancun   *uint64 `yaml:"cancun" toml:"cancun"`
+}
+
+// forkHeight returns a pointer to n for ForkHeights literals
+func forkHeight(n uint64) *uint64 {
+	return &n
+}
+
+// boundaries returns the set fork heights up to head, the first block of each fork
+func (f ForkHeights) boundaries(head uint64) []uint64 {
+	var blocks []uint64
+	for _, h := range []*uint64{f.Merge, f.Shanghai, f.Cancun} {
+		if h != nil && *h <= head {
+			blocks = append(blocks, *h)
+		}
+	}
+	return blocks
+}
+
+// BlockShapeIssue describes a post-merge block field that is missing or pre-merge shaped
+type BlockShapeIssue struct {
+	Block  uint64
+	Field  string
+	Detail string
+}
+
+// String formats the issue for reports
+func (i BlockShapeIssue) String() string {
+	return fmt.Sprintf("block %d: %s: %s", i.Block, i.Field, i.Detail)
+}
+
+// postMergeBlock holds the block fields whose shape changed with the merge and later forks
+type postMergeBlock struct {
+	Number                hexutil.Uint64    `json:"number"`
+	Difficulty            *hexutil.Big      `json:"difficulty"`
+	Nonce                 *types.BlockNonce `json:"nonce"`
+	MixHash               *common.Hash      `json:"mixHash"`
+	Sha3Uncles            common.Hash       `json:"sha3Uncles"`
+	Uncles                []common.Hash     `json:"uncles"`
+	WithdrawalsRoot       *common.Hash      `json:"withdrawalsRoot"`
+	Withdrawals           *[]interface{}    `json:"withdrawals"`
+	BlobGasUsed           *hexutil.Uint64   `json:"blobGasUsed"`
+	ExcessBlobGas         *hexutil.Uint64   `json:"excessBlobGas"`
+	ParentBeaconBlockRoot *common.Hash      `json:"parentBeaconBlockRoot"`
+}
+
+// activeAt reports whether a fork height is set and reached by block n
+func activeAt(fork *uint64, n uint64) bool {
+	return fork != nil && n >= *fork
+}
+
+// CheckBlockShape verifies that blocks past the merge carry the post-merge shape:
+// zero difficulty and nonce, empty uncles, prevRandao in mixHash, and the
+// withdrawals and blob fields of the later forks where they are active
+func (r *RPCClient) CheckBlockShape(ctx context.Context, blocks []uint64, forks ForkHeights) ([]BlockShapeIssue, error) {
+	var issues []BlockShapeIssue
+
+	for _, n := range blocks {
+		if !activeAt(forks.Merge, n) {
+			continue
+		}
+
+		raw, err := r.CallRaw(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(n), false)
+		if err != nil {
+			return issues, err
+		}
+
+		var block postMergeBlock
+		if err := json.Unmarshal(raw, &block); err != nil {
+			return issues, fmt.Errorf("failed to decode block %d: %w", n, err)
+		}
+
+		report := func(field, detail string) {
+			issues = append(issues, BlockShapeIssue{Block: n, Field: field, Detail: detail})
+		}
+
+		if block.Difficulty == nil {
+			report("difficulty", "missing")
+		} else if block.Difficulty.ToInt().Sign() != 0 {
+			report("difficulty", fmt.Sprintf("non-zero difficulty %s after the merge", block.Difficulty))
+		}
+		if block.Nonce == nil {
+			report("nonce", "missing")
+		} else if *block.Nonce != (types.BlockNonce{}) {
+			report("nonce", fmt.Sprintf("non-zero PoW nonce %x after the merge", block.Nonce[:]))
+		}
+		if len(block.Uncles) != 0 {
+			report("uncles", fmt.Sprintf("%d uncles after the merge", len(block.Uncles)))
+		}
+		if block.Sha3Uncles != types.EmptyUncleHash {
+			report("sha3Uncles", fmt.Sprintf("%s is not the empty uncle hash", block.Sha3Uncles.Hex()))
+		}
+		if block.MixHash == nil {
+			report("mixHash", "missing prevRandao")
+		} else if *block.MixHash == (common.Hash{}) {
+			report("mixHash", "zero prevRandao")
+		}
+
+		if activeAt(forks.Shanghai, n) {
+			if block.WithdrawalsRoot == nil {
+				report("withdrawalsRoot", "missing after shanghai")
+			}
+			if block.Withdrawals == nil {
+				report("withdrawals", "missing after shanghai")
+			}
+		} else if block.WithdrawalsRoot != nil {
+			report("withdrawalsRoot", "present before shanghai")
+		}
+
+		if activeAt(forks.Cancun, n) {
+			if block.BlobGasUsed == nil {
+				report("blobGasUsed", "missing after cancun")
+			}
+			if block.ExcessBlobGas == nil {
+				report("excessBlobGas", "missing after cancun")
+			}
+			if block.ParentBeaconBlockRoot == nil {
+				report("parentBeaconBlockRoot", "missing after cancun")
+			}
+		}
+	}
+
+	return issues, nil
+}
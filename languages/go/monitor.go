@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// uptimeFile is stored in the user's home directory unless --history is given
+const uptimeFile = ".megaeth-rpc-tester_uptime.jsonl"
+
+// burnRateWindow is the lookback for the short-term error budget burn rate
+const burnRateWindow = time.Hour
+
+// DefaultCanaryRequests is the lightweight request set probed by monitor
+func DefaultCanaryRequests() []DiffRequest {
+	return []DiffRequest{
+		{Method: "eth_chainId"},
+		{Method: "eth_blockNumber"},
+		{Method: "eth_getBlockByNumber", Params: []interface{}{"latest", false}},
+	}
+}
+
+// Probe is one run of the canary set against one endpoint. The endpoint is
+// up when every canary request succeeded.
+type Probe struct {
+	Endpoint  string    `json:"endpoint"`
+	At        time.Time `json:"at"`
+	Up        bool      `json:"up"`
+	LatencyMs float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ProbeHook is called for every probe the monitor runs
+type ProbeHook func(Probe)
+
+// UptimeMonitor probes endpoints with a canary request set on an interval and
+// keeps the current month's probes for SLA metrics
+type UptimeMonitor struct {
+	clients  []*RPCClient
+	canaries []DiffRequest
+	interval time.Duration
+	timeout  time.Duration
+	target   float64
+
+	mu     sync.Mutex
+	probes map[string][]Probe
+	hooks  []ProbeHook
+}
+
+// NewUptimeMonitor creates a monitor that probes every interval, failing a
+// probe that takes longer than timeout, and measures SLAs against target
+// (a fraction such as 0.999)
+func NewUptimeMonitor(clients []*RPCClient, canaries []DiffRequest, interval, timeout time.Duration, target float64) *UptimeMonitor {
+	if len(canaries) == 0 {
+		canaries = DefaultCanaryRequests()
+	}
+	return &UptimeMonitor{
+		clients:  clients,
+		canaries: canaries,
+		interval: interval,
+		timeout:  timeout,
+		target:   target,
+		probes:   make(map[string][]Probe),
+	}
+}
+
+// OnProbe registers a hook called after every probe
+func (m *UptimeMonitor) OnProbe(hook ProbeHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Seed adds earlier probes, such as those loaded from the history file, so a
+// restarted monitor reports the whole month
+func (m *UptimeMonitor) Seed(probes []Probe) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range probes {
+		m.probes[p.Endpoint] = append(m.probes[p.Endpoint], p)
+	}
+}
+
+// Run probes immediately and then every interval until ctx is cancelled
+func (m *UptimeMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.ProbeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeOnce probes every endpoint concurrently
+func (m *UptimeMonitor) ProbeOnce(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, client := range m.clients {
+		wg.Add(1)
+		go func(client *RPCClient) {
+			defer wg.Done()
+			probe := m.probe(ctx, client)
+			// A probe cut short by shutdown says nothing about the endpoint
+			if ctx.Err() != nil {
+				return
+			}
+			m.record(probe)
+		}(client)
+	}
+	wg.Wait()
+}
+
+// probe sends the canary set to one endpoint, stopping at the first failure
+func (m *UptimeMonitor) probe(ctx context.Context, client *RPCClient) Probe {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	probe := Probe{Endpoint: client.DisplayURL(), At: time.Now().UTC(), Up: true}
+	start := time.Now()
+	for _, req := range m.canaries {
+		if _, err := client.CallRaw(ctx, req.Method, req.Params...); err != nil {
+			probe.Up = false
+			probe.Error = Redact(fmt.Sprintf("%s: %v", req.Method, err))
+			break
+		}
+	}
+	probe.LatencyMs = durationMillis(time.Since(start))
+	return probe
+}
+
+// record keeps a probe, dropping those from earlier months, and runs the hooks
+func (m *UptimeMonitor) record(probe Probe) {
+	m.mu.Lock()
+	monthStart := startOfMonth(probe.At)
+	kept := m.probes[probe.Endpoint]
+	for len(kept) > 0 && kept[0].At.Before(monthStart) {
+		kept = kept[1:]
+	}
+	m.probes[probe.Endpoint] = append(kept, probe)
+	hooks := append([]ProbeHook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(probe)
+	}
+}
+
+// SLA returns the current month's SLA report for every endpoint
+func (m *UptimeMonitor) SLA(now time.Time) []SLAReport {
+	m.mu.Lock()
+	var probes []Probe
+	for _, p := range m.probes {
+		probes = append(probes, p...)
+	}
+	m.mu.Unlock()
+
+	reports := ComputeSLA(probes, now, m.target, now)
+	// Endpoints not probed yet still get a row
+	seen := make(map[string]bool, len(reports))
+	for _, r := range reports {
+		seen[r.Endpoint] = true
+	}
+	for _, c := range m.clients {
+		if !seen[c.DisplayURL()] {
+			reports = append(reports, SLAReport{Endpoint: c.DisplayURL(), Month: now.UTC().Format(slaMonthLayout), TargetPct: m.target * 100})
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Endpoint < reports[j].Endpoint })
+	return reports
+}
+
+// BurnRate returns an endpoint's error budget burn rate over the last hour
+func (m *UptimeMonitor) BurnRate(endpoint string, now time.Time) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	probes := m.probes[endpoint]
+	since := now.Add(-burnRateWindow)
+	first := sort.Search(len(probes), func(i int) bool { return !probes[i].At.Before(since) })
+	return burnRate(probes[first:], m.target, now)
+}
+
+// ServeHTTP exposes the current month's SLA metrics in the Prometheus text
+// exposition format
+func (m *UptimeMonitor) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	reports := m.SLA(time.Now())
+
+	gauges := []struct {
+		name, help string
+		value      func(SLAReport) float64
+	}{
+		{"rpc_uptime_ratio", "Fraction of this month's probes that succeeded", func(r SLAReport) float64 { return r.UptimePct / 100 }},
+		{"rpc_uptime_probe_latency_p95_ms", "p95 latency of this month's successful probes", func(r SLAReport) float64 { return r.P95LatencyMs }},
+		{"rpc_error_budget_used_ratio", "Fraction of this month's error budget consumed", func(r SLAReport) float64 { return r.BudgetUsedPct / 100 }},
+		{"rpc_error_budget_burn_rate", "Error budget burn rate over the last hour (1 spends exactly the budget)", func(r SLAReport) float64 { return r.BurnRate }},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+		for _, r := range reports {
+			fmt.Fprintf(w, "%s{endpoint=%q} %g\n", g.name, r.Endpoint, g.value(r))
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP rpc_uptime_probes_total Probes run this month")
+	fmt.Fprintln(w, "# TYPE rpc_uptime_probes_total counter")
+	for _, r := range reports {
+		fmt.Fprintf(w, "rpc_uptime_probes_total{endpoint=%q} %d\n", r.Endpoint, r.Probes)
+	}
+	fmt.Fprintln(w, "# HELP rpc_uptime_probe_failures_total Probes failed this month")
+	fmt.Fprintln(w, "# TYPE rpc_uptime_probe_failures_total counter")
+	for _, r := range reports {
+		fmt.Fprintf(w, "rpc_uptime_probe_failures_total{endpoint=%q} %d\n", r.Endpoint, r.Failures)
+	}
+}
+
+// slaMonthLayout formats the month an SLA report covers
+const slaMonthLayout = "2006-01"
+
+// SLAReport summarizes one endpoint's probes over a calendar month (UTC).
+// Downtime is estimated as the failed share of the month's elapsed time, and
+// the error budget is the downtime the target allows over the whole month.
+type SLAReport struct {
+	Endpoint      string  `json:"endpoint"`
+	Month         string  `json:"month"`
+	Probes        int     `json:"probes"`
+	Failures      int     `json:"failures"`
+	UptimePct     float64 `json:"uptime_pct"`
+	TargetPct     float64 `json:"target_pct"`
+	MeanLatencyMs float64 `json:"mean_latency_ms"`
+	P95LatencyMs  float64 `json:"p95_latency_ms"`
+	Downtime      string  `json:"downtime"`
+	ErrorBudget   string  `json:"error_budget"`
+	BudgetUsedPct float64 `json:"budget_used_pct"`
+	// BurnRate is the failure rate over the last hour divided by the rate
+	// the target allows; above 1 the budget runs out before the month ends
+	BurnRate  float64 `json:"burn_rate_1h"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+// ComputeSLA builds per-endpoint SLA reports for the month containing month
+// against target, counting time up to now for a month still in progress
+func ComputeSLA(probes []Probe, month time.Time, target float64, now time.Time) []SLAReport {
+	start := startOfMonth(month)
+	end := start.AddDate(0, 1, 0)
+	elapsed := end.Sub(start)
+	if now.Before(end) {
+		elapsed = now.Sub(start)
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	budget := time.Duration((1 - target) * float64(end.Sub(start)))
+
+	byEndpoint := make(map[string][]Probe)
+	for _, p := range probes {
+		if p.At.Before(start) || !p.At.Before(end) {
+			continue
+		}
+		byEndpoint[p.Endpoint] = append(byEndpoint[p.Endpoint], p)
+	}
+
+	reports := make([]SLAReport, 0, len(byEndpoint))
+	for endpoint, ps := range byEndpoint {
+		sort.Slice(ps, func(i, j int) bool { return ps[i].At.Before(ps[j].At) })
+		report := SLAReport{
+			Endpoint:    endpoint,
+			Month:       start.Format(slaMonthLayout),
+			Probes:      len(ps),
+			TargetPct:   target * 100,
+			ErrorBudget: budget.Round(time.Second).String(),
+		}
+
+		var latencies []time.Duration
+		for _, p := range ps {
+			if p.Up {
+				latencies = append(latencies, time.Duration(p.LatencyMs*float64(time.Millisecond)))
+			} else {
+				report.Failures++
+				report.LastError = p.Error
+			}
+		}
+
+		failed := float64(report.Failures) / float64(report.Probes)
+		report.UptimePct = math.Round((1-failed)*100000) / 1000
+		downtime := time.Duration(failed * float64(elapsed))
+		report.Downtime = downtime.Round(time.Second).String()
+		if budget > 0 {
+			report.BudgetUsedPct = math.Round(float64(downtime)/float64(budget)*100000) / 1000
+		}
+		report.BurnRate = burnRate(ps, target, now)
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			var total time.Duration
+			for _, l := range latencies {
+				total += l
+			}
+			report.MeanLatencyMs = durationMillis(total / time.Duration(len(latencies)))
+			report.P95LatencyMs = durationMillis(percentile(latencies, 95))
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Endpoint < reports[j].Endpoint })
+	return reports
+}
+
+// burnRate is the failure rate of the probes in the hour before now divided by
+// the failure rate target allows
+func burnRate(probes []Probe, target float64, now time.Time) float64 {
+	since := now.Add(-burnRateWindow)
+	var recent, failures int
+	for _, p := range probes {
+		if p.At.Before(since) || p.At.After(now) {
+			continue
+		}
+		recent++
+		if !p.Up {
+			failures++
+		}
+	}
+	if recent == 0 || target >= 1 {
+		return 0
+	}
+	return math.Round(float64(failures)/float64(recent)/(1-target)*1000) / 1000
+}
+
+// startOfMonth returns midnight UTC on the first of t's month
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// defaultUptimePath returns the probe history location in the home directory
+func defaultUptimePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	return filepath.Join(home, uptimeFile), nil
+}
+
+// LoadProbes reads a probe history file written by monitor, one JSON probe
+// per line, returning those at or after since. A missing file is empty.
+func LoadProbes(path string, since time.Time) ([]Probe, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probe history: %w", err)
+	}
+	defer f.Close()
+
+	var probes []Probe
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var p Probe
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			return nil, fmt.Errorf("failed to parse probe history %s line %d: %w", path, line, err)
+		}
+		if !p.At.Before(since) {
+			probes = append(probes, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read probe history: %w", err)
+	}
+	return probes, nil
+}
+
+// ProbeLog appends probes to a history file; it is safe for concurrent use
+type ProbeLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenProbeLog opens path for appending, creating it if needed
+func OpenProbeLog(path string) (*ProbeLog, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open probe history: %w", err)
+	}
+	return &ProbeLog{f: f}, nil
+}
+
+// Append writes one probe as a line of JSON
+func (l *ProbeLog) Append(p Probe) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode probe: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write probe history: %w", err)
+	}
+	return nil
+}
+
+// Close closes the history file
+func (l *ProbeLog) Close() error {
+	return l.f.Close()
+}
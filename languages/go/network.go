@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Gas strategies a network profile can select for signed transactions
+const (
+	GasStrategyLegacy  = "legacy"
+	GasStrategyEIP1559 = "eip1559"
+)
+
+// NetworkProfile describes a named network the CLI can target with --network
+type NetworkProfile struct {
+	RPCURL      string `yaml:"rpc_url" toml:"rpc_url"`
+	WSURL       string `yaml:"ws_url" toml:"ws_url"`
+	ChainID     uint64 `yaml:"chain_id" toml:"chain_id"`
+	ExplorerURL string `yaml:"explorer_url" toml:"explorer_url"`
+	FaucetURL   string `yaml:"faucet_url" toml:"faucet_url"`
+	GasStrategy string `yaml:"gas_strategy" toml:"gas_strategy"`
+	// Forks are the block heights the block shape check expects forks at
+	Forks ForkHeights `yaml:"forks" toml:"forks"`
+	// BaseFee overrides the EIP-1559 parameters the basefee check predicts with
+	BaseFee BaseFeeConfig `yaml:"basefee" toml:"basefee"`
+}
+
+// BaseFeeConfig holds a chain's EIP-1559 parameters; zero fields keep the
+// Ethereum mainnet values
+type BaseFeeConfig struct {
+	Elasticity  uint64 `yaml:"elasticity" toml:"elasticity"`
+	Denominator uint64 `yaml:"denominator" toml:"denominator"`
+	MinBaseFee  uint64 `yaml:"min_base_fee" toml:"min_base_fee"`
+}
+
+// Params returns the base fee parameters with the configured overrides applied
+func (c BaseFeeConfig) Params() BaseFeeParams {
+	params := DefaultBaseFeeParams()
+	if c.Elasticity != 0 {
+		params.ElasticityMultiplier = c.Elasticity
+	}
+	if c.Denominator != 0 {
+		params.ChangeDenominator = c.Denominator
+	}
+	params.MinBaseFee.SetUint64(c.MinBaseFee)
+	return params
+}
+
+// BuiltinNetworks are available without a config file and can be overridden by one
+var BuiltinNetworks = map[string]NetworkProfile{
+	"megaeth-testnet": {
+		RPCURL:      "https://carrot.megaeth.com/rpc",
+		WSURL:       "wss://carrot.megaeth.com/ws",
+		ChainID:     6342,
+		ExplorerURL: "https://megaexplorer.xyz",
+		FaucetURL:   "https://testnet.megaeth.com",
+		GasStrategy: GasStrategyEIP1559,
+	},
+	"mainnet": {
+		RPCURL:      "https://eth.llamarpc.com",
+		WSURL:       "wss://ethereum-rpc.publicnode.com",
+		ChainID:     1,
+		ExplorerURL: "https://etherscan.io",
+		GasStrategy: GasStrategyEIP1559,
+		Forks:       ForkHeights{Merge: forkHeight(15537394), Shanghai: forkHeight(17034870), Cancun: forkHeight(19426587)},
+	},
+	"sepolia": {
+		RPCURL:      "https://ethereum-sepolia-rpc.publicnode.com",
+		WSURL:       "wss://ethereum-sepolia-rpc.publicnode.com",
+		ChainID:     11155111,
+		ExplorerURL: "https://sepolia.etherscan.io",
+		FaucetURL:   "https://sepoliafaucet.com",
+		GasStrategy: GasStrategyEIP1559,
+		Forks:       ForkHeights{Merge: forkHeight(1450409), Shanghai: forkHeight(2990908), Cancun: forkHeight(5187023)},
+	},
+	"holesky": {
+		RPCURL:      "https://ethereum-holesky-rpc.publicnode.com",
+		WSURL:       "wss://ethereum-holesky-rpc.publicnode.com",
+		ChainID:     17000,
+		ExplorerURL: "https://holesky.etherscan.io",
+		GasStrategy: GasStrategyEIP1559,
+		Forks:       ForkHeights{Merge: forkHeight(0), Shanghai: forkHeight(6698), Cancun: forkHeight(894733)},
+	},
+}
+
+// Validate reports whether the profile is usable
+func (p NetworkProfile) Validate() error {
+	if p.RPCURL == "" {
+		return fmt.Errorf("rpc_url is required")
+	}
+
+	switch p.GasStrategy {
+	case "", GasStrategyLegacy, GasStrategyEIP1559:
+	default:
+		return fmt.Errorf("unknown gas_strategy %q (want %s or %s)", p.GasStrategy, GasStrategyLegacy, GasStrategyEIP1559)
+	}
+
+	return nil
+}
+
+// TxURL returns the explorer link for a transaction, or "" when no explorer is set
+func (p NetworkProfile) TxURL(hash string) string {
+	if p.ExplorerURL == "" {
+		return ""
+	}
+	return strings.TrimRight(p.ExplorerURL, "/") + "/tx/" + hash
+}
+
+// mergeNetworks layers the configured profiles over the built-in ones
+func mergeNetworks(configured map[string]NetworkProfile) map[string]NetworkProfile {
+	merged := make(map[string]NetworkProfile, len(BuiltinNetworks)+len(configured))
+	for name, profile := range BuiltinNetworks {
+		merged[name] = profile
+	}
+	for name, profile := range configured {
+		merged[name] = profile
+	}
+	return merged
+}
+
+// networkNames returns the sorted profile names for error messages and listings
+func networkNames(networks map[string]NetworkProfile) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CosmosChain describes a Cosmos chain the IBC checks can target by name
+type CosmosChain struct {
+	RPCURL    string `yaml:"rpc_url" toml:"rpc_url"`
+	GRPCAddr  string `yaml:"grpc_addr" toml:"grpc_addr"`
+	Plaintext bool   `yaml:"plaintext" toml:"plaintext"`
+}
+
+// Validate reports whether the chain is usable
+func (c CosmosChain) Validate() error {
+	if c.RPCURL == "" {
+		return fmt.Errorf("rpc_url is required")
+	}
+	if c.GRPCAddr == "" {
+		return fmt.Errorf("grpc_addr is required")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the call latency
+// histogram; a final +Inf bucket is implied
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// latencyHistograms is set when --metrics-addr is given; clients created
+// afterwards record every call in it
+var latencyHistograms *LatencyHistograms
+
+// Exemplar links a histogram bucket to the traced call that last landed in it
+type Exemplar struct {
+	TraceID string
+	SpanID  string
+	Value   float64
+	At      time.Time
+}
+
+// histogramKey identifies one labelled latency series
+type histogramKey struct {
+	method, host string
+}
+
+// histogram holds per-bucket (not cumulative) counts and the latest exemplar
+// for each bucket, the last of which is +Inf
+type histogram struct {
+	counts    []uint64
+	exemplars []*Exemplar
+	count     uint64
+	sum       float64
+}
+
+// LatencyHistograms records JSON-RPC call latency per method and host and
+// renders it in the OpenMetrics text format, with trace-id exemplars on the
+// buckets when tracing is on
+type LatencyHistograms struct {
+	mu     sync.Mutex
+	series map[histogramKey]*histogram
+}
+
+// NewLatencyHistograms creates an empty set of histograms
+func NewLatencyHistograms() *LatencyHistograms {
+	return &LatencyHistograms{series: make(map[histogramKey]*histogram)}
+}
+
+// Observe records one call. A valid span context becomes the exemplar of the
+// bucket the call falls in, replacing the previous one.
+func (h *LatencyHistograms) Observe(method, host string, duration time.Duration, span trace.SpanContext) {
+	seconds := duration.Seconds()
+	bucket := sort.SearchFloat64s(latencyBuckets, seconds)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := histogramKey{method, host}
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{
+			counts:    make([]uint64, len(latencyBuckets)+1),
+			exemplars: make([]*Exemplar, len(latencyBuckets)+1),
+		}
+		h.series[key] = s
+	}
+	s.counts[bucket]++
+	s.count++
+	s.sum += seconds
+	if span.IsValid() {
+		s.exemplars[bucket] = &Exemplar{
+			TraceID: span.TraceID().String(),
+			SpanID:  span.SpanID().String(),
+			Value:   seconds,
+			At:      time.Now(),
+		}
+	}
+}
+
+// WriteOpenMetrics renders the histograms as an OpenMetrics exposition,
+// including the terminating # EOF
+func (h *LatencyHistograms) WriteOpenMetrics(w io.Writer) {
+	const name = "rpc_request_duration_seconds"
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	fmt.Fprintf(w, "# UNIT %s seconds\n", name)
+	fmt.Fprintf(w, "# HELP %s JSON-RPC request latency by method and host\n", name)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]histogramKey, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].host < keys[j].host
+	})
+
+	for _, k := range keys {
+		s := h.series[k]
+		labels := fmt.Sprintf("method=%q,host=%q", k.method, k.host)
+		var cumulative uint64
+		for i, n := range s.counts {
+			cumulative += n
+			le := "+Inf"
+			if i < len(latencyBuckets) {
+				le = strconv.FormatFloat(latencyBuckets[i], 'f', -1, 64)
+			}
+			fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d%s\n", name, labels, le, cumulative, formatExemplar(s.exemplars[i]))
+		}
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, s.count)
+		fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(s.sum, 'f', -1, 64))
+	}
+	fmt.Fprintln(w, "# EOF")
+}
+
+// formatExemplar renders the suffix of a bucket sample, e.g.
+// ` # {trace_id="4bf9...",span_id="00f0..."} 0.042 1700000000.123`
+func formatExemplar(e *Exemplar) string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf(" # {trace_id=%q,span_id=%q} %s %s", e.TraceID, e.SpanID,
+		strconv.FormatFloat(e.Value, 'f', -1, 64), strconv.FormatFloat(float64(e.At.UnixMilli())/1000, 'f', 3, 64))
+}
+
+// ServeHTTP implements http.Handler
+func (h *LatencyHistograms) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	h.WriteOpenMetrics(w)
+}
+
+// StartMetricsServer serves the call latency histograms at /metrics on addr
+// until the process exits. It returns once the address is bound, so a port
+// clash fails the command up front.
+func StartMetricsServer(addr string, histograms *LatencyHistograms) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start metrics server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", histograms)
+
+	go http.Serve(listener, mux)
+	return nil
+}
+
+// histogramTransport records every JSON-RPC request in latencyHistograms. It
+// sits inside tracingTransport so the request context carries the call's span.
+type histogramTransport struct {
+	base       http.RoundTripper
+	histograms *LatencyHistograms
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *histogramTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := "unknown"
+	switch methods := requestMethods(req); {
+	case len(methods) > 1:
+		method = "batch"
+	case len(methods) == 1:
+		method = methods[0]
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.histograms.Observe(method, req.URL.Hostname(), time.Since(start), trace.SpanContextFromContext(req.Context()))
+	return resp, err
+}
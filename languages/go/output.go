@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats selectable with --output
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+	OutputRaw   = "raw"
+)
+
+// OutputFormats lists the accepted --output values
+var OutputFormats = []string{OutputTable, OutputJSON, OutputCSV, OutputRaw}
+
+// Field is one named value of a command result. Names are the stable JSON
+// keys and CSV headers, so renaming one is a breaking change.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is an ordered set of fields describing one result
+type Record []Field
+
+// MarshalJSON encodes the record as an object with fields in order
+func (rec Record) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range rec {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", f.Name, err)
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// validateOutputFormat rejects unknown --output values
+func validateOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q (want %s)", format, strings.Join(OutputFormats, ", "))
+}
+
+// WriteRecords renders records in the given format. raw is printed verbatim
+// for the raw format; when it is nil the field values are printed instead.
+func WriteRecords(w io.Writer, format string, records []Record, raw []byte) error {
+	switch format {
+	case OutputJSON:
+		// Always an array, so consumers need not care how many records there are
+		if records == nil {
+			records = []Record{}
+		}
+		out, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		if len(records) > 0 {
+			header := make([]string, len(records[0]))
+			for i, f := range records[0] {
+				header[i] = f.Name
+			}
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		for _, rec := range records {
+			row := make([]string, len(rec))
+			for i, f := range rec {
+				row[i] = formatValue(f.Value)
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case OutputRaw:
+		if raw != nil {
+			_, err := fmt.Fprintln(w, string(raw))
+			return err
+		}
+		for _, rec := range records {
+			values := make([]string, len(rec))
+			for i, f := range rec {
+				values[i] = formatValue(f.Value)
+			}
+			if _, err := fmt.Fprintln(w, strings.Join(values, " ")); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		if len(records) == 1 {
+			// A single record reads better as one field per line
+			for _, f := range records[0] {
+				fmt.Fprintf(tw, "%s\t%s\n", f.Name, formatValue(f.Value))
+			}
+			return tw.Flush()
+		}
+		if len(records) > 0 {
+			names := make([]string, len(records[0]))
+			for i, f := range records[0] {
+				names[i] = strings.ToUpper(f.Name)
+			}
+			fmt.Fprintln(tw, strings.Join(names, "\t"))
+		}
+		for _, rec := range records {
+			values := make([]string, len(rec))
+			for i, f := range rec {
+				values[i] = formatValue(f.Value)
+			}
+			fmt.Fprintln(tw, strings.Join(values, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// formatValue renders a field value for text formats
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// RecordStream writes records one at a time as they arrive, for commands that
+// run until interrupted. JSON is written as one object per line.
+type RecordStream struct {
+	w           io.Writer
+	format      string
+	wroteHeader bool
+}
+
+// NewRecordStream creates a stream writing in the given format
+func NewRecordStream(w io.Writer, format string) *RecordStream {
+	return &RecordStream{w: w, format: format}
+}
+
+// Write renders one record
+func (s *RecordStream) Write(rec Record) error {
+	switch s.format {
+	case OutputJSON:
+		out, err := json.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = fmt.Fprintln(s.w, string(out))
+		return err
+
+	case OutputCSV:
+		cw := csv.NewWriter(s.w)
+		if !s.wroteHeader {
+			header := make([]string, len(rec))
+			for i, f := range rec {
+				header[i] = f.Name
+			}
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+			s.wroteHeader = true
+		}
+		row := make([]string, len(rec))
+		for i, f := range rec {
+			row[i] = formatValue(f.Value)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case OutputRaw:
+		values := make([]string, len(rec))
+		for i, f := range rec {
+			values[i] = formatValue(f.Value)
+		}
+		_, err := fmt.Fprintln(s.w, strings.Join(values, " "))
+		return err
+
+	default:
+		// Rows cannot be aligned ahead of time, so label each value instead
+		parts := make([]string, len(rec))
+		for i, f := range rec {
+			parts[i] = f.Name + "=" + formatValue(f.Value)
+		}
+		_, err := fmt.Fprintln(s.w, strings.Join(parts, "  "))
+		return err
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ProofCheck holds the result of verifying one account's eth_getProof output
+type ProofCheck struct {
+	Address      common.Address
+	Block        uint64
+	AccountOK    bool
+	StorageOK    map[string]bool
+	Detail       string
+	StateRoot    common.Hash
+	StorageSlots int
+}
+
+// Passed reports whether the account proof and every storage proof verified
+func (c ProofCheck) Passed() bool {
+	if !c.AccountOK {
+		return false
+	}
+	for _, ok := range c.StorageOK {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyProof fetches eth_getProof for an account at a block and verifies the
+// account proof against the header stateRoot and each storage proof against
+// the proven storage root
+func (r *RPCClient) VerifyProof(ctx context.Context, address common.Address, slots []common.Hash, block uint64) (*ProofCheck, error) {
+	number := new(big.Int).SetUint64(block)
+	header, err := r.client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header %d: %w", block, err)
+	}
+
+	keys := make([]string, len(slots))
+	for i, slot := range slots {
+		keys[i] = slot.Hex()
+	}
+
+	result, err := gethclient.New(r.client.Client()).GetProof(ctx, address, keys, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proof: %w", err)
+	}
+
+	check := &ProofCheck{
+		Address:      address,
+		Block:        block,
+		StorageOK:    make(map[string]bool),
+		StateRoot:    header.Root,
+		StorageSlots: len(result.StorageProof),
+	}
+
+	if err := verifyAccountProof(header.Root, result); err != nil {
+		check.Detail = err.Error()
+		return check, nil
+	}
+	check.AccountOK = true
+
+	for _, sp := range result.StorageProof {
+		if err := verifyStorageProof(result.StorageHash, sp); err != nil {
+			check.StorageOK[sp.Key] = false
+			if check.Detail == "" {
+				check.Detail = fmt.Sprintf("slot %s: %v", sp.Key, err)
+			}
+			continue
+		}
+		check.StorageOK[sp.Key] = true
+	}
+
+	return check, nil
+}
+
+// proofDB loads hex-encoded proof nodes into an in-memory database keyed by node hash
+func proofDB(nodes []string) (*memorydb.Database, error) {
+	db := memorydb.New()
+	for _, node := range nodes {
+		blob, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof node: %w", err)
+		}
+		if err := db.Put(crypto.Keccak256(blob), blob); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// verifyAccountProof checks that the account proof resolves under stateRoot to
+// exactly the account fields reported alongside it
+func verifyAccountProof(stateRoot common.Hash, result *gethclient.AccountResult) error {
+	db, err := proofDB(result.AccountProof)
+	if err != nil {
+		return err
+	}
+
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(result.Address.Bytes()), db)
+	if err != nil {
+		return fmt.Errorf("account proof invalid: %w", err)
+	}
+
+	balance := result.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+
+	// A nil value proves absence; the reported account must then be empty
+	if value == nil {
+		if result.Nonce != 0 || balance.Sign() != 0 ||
+			(result.StorageHash != (common.Hash{}) && result.StorageHash != types.EmptyRootHash) ||
+			(result.CodeHash != (common.Hash{}) && result.CodeHash != types.EmptyCodeHash) {
+			return fmt.Errorf("proof shows absent account but non-empty fields were reported")
+		}
+		return nil
+	}
+
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return fmt.Errorf("failed to decode proven account: %w", err)
+	}
+
+	switch {
+	case account.Nonce != result.Nonce:
+		return fmt.Errorf("nonce mismatch: proven %d, reported %d", account.Nonce, result.Nonce)
+	case account.Balance.Cmp(balance) != 0:
+		return fmt.Errorf("balance mismatch: proven %s, reported %s", account.Balance, balance)
+	case account.Root != result.StorageHash:
+		return fmt.Errorf("storage root mismatch: proven %s, reported %s", account.Root.Hex(), result.StorageHash.Hex())
+	case !bytes.Equal(account.CodeHash, result.CodeHash.Bytes()):
+		return fmt.Errorf("code hash mismatch: proven %x, reported %s", account.CodeHash, result.CodeHash.Hex())
+	}
+
+	return nil
+}
+
+// verifyStorageProof checks that a storage proof resolves under storageRoot to the reported value
+func verifyStorageProof(storageRoot common.Hash, sp gethclient.StorageResult) error {
+	// An empty storage trie has no nodes to prove with, so nodes send an
+	// empty proof; every slot of it is zero
+	if storageRoot == types.EmptyRootHash && len(sp.Proof) == 0 {
+		if sp.Value != nil && sp.Value.Sign() != 0 {
+			return fmt.Errorf("value mismatch: empty storage proves 0, reported %s", sp.Value)
+		}
+		return nil
+	}
+
+	db, err := proofDB(sp.Proof)
+	if err != nil {
+		return err
+	}
+
+	slot := common.HexToHash(sp.Key)
+	value, err := trie.VerifyProof(storageRoot, crypto.Keccak256(slot.Bytes()), db)
+	if err != nil {
+		return fmt.Errorf("storage proof invalid: %w", err)
+	}
+
+	proven := new(big.Int)
+	if value != nil {
+		var content []byte
+		if err := rlp.DecodeBytes(value, &content); err != nil {
+			return fmt.Errorf("failed to decode proven value: %w", err)
+		}
+		proven.SetBytes(content)
+	}
+
+	reported := sp.Value
+	if reported == nil {
+		reported = new(big.Int)
+	}
+	if proven.Cmp(reported) != 0 {
+		return fmt.Errorf("value mismatch: proven %s, reported %s", proven, reported)
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxProxyBody caps the size of a request body the proxy will accept
+const maxProxyBody = 10 << 20
+
+// rpcRequest is a JSON-RPC 2.0 request object
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// ProxyExchange is one recorded request/response pair
+type ProxyExchange struct {
+	Method     string          `json:"method"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      *rpcError       `json:"error,omitempty"`
+	At         time.Time       `json:"at"`
+	DurationMs float64         `json:"duration_ms"`
+}
+
+// ProxySession is the file written by the recording proxy and served by the
+// replaying one
+type ProxySession struct {
+	Upstream   string          `json:"upstream"`
+	RecordedAt time.Time       `json:"recordedAt"`
+	Exchanges  []ProxyExchange `json:"exchanges"`
+}
+
+// Save writes the session as indented JSON. The file is replaced atomically,
+// so a crash mid-write leaves the previous checkpoint intact.
+func (s *ProxySession) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// LoadProxySession reads a session written by the recording proxy
+func LoadProxySession(path string) (*ProxySession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var session ProxySession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return &session, nil
+}
+
+// ExchangeHook is called for every request/response pair a proxy handles
+type ExchangeHook func(ProxyExchange)
+
+// RecordingProxy forwards JSON-RPC traffic to an upstream endpoint and
+// records every request/response pair with its timing
+type RecordingProxy struct {
+	upstream *RPCClient
+	hook     ExchangeHook
+
+	mu      sync.Mutex
+	session ProxySession
+}
+
+// NewRecordingProxy creates a proxy forwarding to upstream; hook may be nil
+func NewRecordingProxy(upstream *RPCClient, hook ExchangeHook) *RecordingProxy {
+	return &RecordingProxy{
+		upstream: upstream,
+		hook:     hook,
+		session: ProxySession{
+			Upstream:   upstream.DisplayURL(),
+			RecordedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// Session returns a snapshot of everything recorded so far
+func (p *RecordingProxy) Session() *ProxySession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshot := p.session
+	snapshot.Exchanges = append([]ProxyExchange(nil), p.session.Exchanges...)
+	return &snapshot
+}
+
+// Recorded returns how many exchanges have been recorded
+func (p *RecordingProxy) Recorded() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.session.Exchanges)
+}
+
+// ServeHTTP forwards the request body upstream unchanged, relays the
+// response, and records each call it can pair with a response by id
+func (p *RecordingProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, ok := readProxyBody(w, req)
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	status, _, respBody, err := p.upstream.postJSONWithHeaders(req.Context(), body)
+	elapsed := time.Since(start)
+	if err != nil {
+		http.Error(w, Redact(err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(respBody)
+
+	var requests []rpcRequest
+	var responses []rpcResponse
+	if _, err := decodeRPCMessages(body, &requests); err != nil {
+		return
+	}
+	if _, err := decodeRPCMessages(respBody, &responses); err != nil {
+		return
+	}
+	byID := make(map[string]rpcResponse, len(responses))
+	for _, resp := range responses {
+		byID[string(resp.ID)] = resp
+	}
+
+	for _, r := range requests {
+		resp, ok := byID[string(r.ID)]
+		if !ok {
+			continue
+		}
+		exchange := ProxyExchange{
+			Method:     r.Method,
+			Params:     r.Params,
+			Result:     resp.Result,
+			Error:      resp.Error,
+			At:         start.UTC(),
+			DurationMs: durationMillis(elapsed),
+		}
+		p.mu.Lock()
+		p.session.Exchanges = append(p.session.Exchanges, exchange)
+		p.mu.Unlock()
+		if p.hook != nil {
+			p.hook(exchange)
+		}
+	}
+}
+
+// ReplayProxy serves recorded responses without contacting any endpoint.
+// Calls recorded more than once are answered in recording order, wrapping
+// around after the last one, so sequences such as a rising block number replay.
+type ReplayProxy struct {
+	hook ExchangeHook
+
+	mu        sync.Mutex
+	exchanges map[string][]ProxyExchange
+	next      map[string]int
+}
+
+// NewReplayProxy creates a proxy serving the exchanges of session; hook may be nil
+func NewReplayProxy(session *ProxySession, hook ExchangeHook) *ReplayProxy {
+	exchanges := make(map[string][]ProxyExchange)
+	for _, e := range session.Exchanges {
+		key := exchangeKey(e.Method, e.Params)
+		exchanges[key] = append(exchanges[key], e)
+	}
+
+	return &ReplayProxy{
+		hook:      hook,
+		exchanges: exchanges,
+		next:      make(map[string]int),
+	}
+}
+
+// ServeHTTP answers every call in the request from the session. Calls with
+// no recording get a JSON-RPC error rather than failing the whole batch.
+func (p *ReplayProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, ok := readProxyBody(w, req)
+	if !ok {
+		return
+	}
+
+	var requests []rpcRequest
+	batch, err := decodeRPCMessages(body, &requests)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON-RPC request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	responses := make([]rpcResponse, len(requests))
+	for i, r := range requests {
+		resp := rpcResponse{JSONRPC: "2.0", ID: r.ID}
+		exchange, ok := p.lookup(r.Method, r.Params)
+		if ok {
+			resp.Result, resp.Error = exchange.Result, exchange.Error
+			if p.hook != nil {
+				p.hook(exchange)
+			}
+		} else {
+			resp.Error = &rpcError{Code: -32000, Message: fmt.Sprintf("no recorded response for %s", r.Method)}
+		}
+		responses[i] = resp
+	}
+
+	var out interface{} = responses
+	if !batch {
+		out = responses[0]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// lookup returns the next recorded exchange for a call
+func (p *ReplayProxy) lookup(method string, params json.RawMessage) (ProxyExchange, bool) {
+	key := exchangeKey(method, params)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recorded := p.exchanges[key]
+	if len(recorded) == 0 {
+		return ProxyExchange{}, false
+	}
+	i := p.next[key]
+	p.next[key] = (i + 1) % len(recorded)
+	return recorded[i], true
+}
+
+// exchangeKey identifies a call by method and compacted params, treating
+// omitted and empty params alike
+func exchangeKey(method string, params json.RawMessage) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, params); err != nil || compact.Len() == 0 || compact.String() == "null" {
+		return method + "[]"
+	}
+	return method + compact.String()
+}
+
+// readProxyBody reads a POSTed request body, replying with an error and
+// returning false when the request cannot be proxied
+func readProxyBody(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxProxyBody))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request: %v", err), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return body, true
+}
+
+// decodeRPCMessages decodes a single JSON-RPC message or a batch into v,
+// which must point to a slice, and reports whether the payload was a batch
+func decodeRPCMessages(data []byte, v interface{}) (bool, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return false, errors.New("empty payload")
+	}
+	if data[0] == '[' {
+		return true, json.Unmarshal(data, v)
+	}
+	return false, json.Unmarshal(append(append([]byte{'['}, data...), ']'), v)
+}
+
+// ServeUntilDone serves handler on addr until ctx is cancelled
+func ServeUntilDone(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitCodes are JSON-RPC error codes providers use for rate limiting
+var rateLimitCodes = map[int]bool{-32005: true, -32029: true, -32090: true, 429: true}
+
+// RateLimitProfile characterizes how an endpoint enforces its rate limit.
+// It is saved as JSON so retry logic can consume it without re-probing.
+type RateLimitProfile struct {
+	Endpoint     string        `json:"endpoint"`
+	Limited      bool          `json:"limited"`
+	Signal       string        `json:"signal"` // "http-429", "jsonrpc-error" or "none"
+	HTTPStatus   int           `json:"httpStatus,omitempty"`
+	ErrorCode    int           `json:"errorCode,omitempty"`
+	ErrorMessage string        `json:"errorMessage,omitempty"`
+	RetryAfter   time.Duration `json:"retryAfter,omitempty"`
+	LimitRPS     float64       `json:"limitRps,omitempty"`
+	Window       time.Duration `json:"window,omitempty"`
+	// WindowAtLeast means the limit had not lifted after Window, the most
+	// the probe would wait
+	WindowAtLeast    bool      `json:"windowAtLeast,omitempty"`
+	BatchCountsEntry bool      `json:"batchCountsEntry"`
+	ProbedAt         time.Time `json:"probedAt"`
+}
+
+// RetryDelay returns how long a client should wait after being limited
+func (p RateLimitProfile) RetryDelay() time.Duration {
+	if p.RetryAfter > 0 {
+		return p.RetryAfter
+	}
+	if p.Window > 0 {
+		return p.Window
+	}
+	return time.Second
+}
+
+// Save writes the profile as JSON to path
+func (p RateLimitProfile) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return nil
+}
+
+// LoadRateLimitProfile reads a profile previously written by Save
+func LoadRateLimitProfile(path string) (*RateLimitProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var p RateLimitProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode profile: %w", err)
+	}
+	return &p, nil
+}
+
+// rateLimitRetries is how many times a rate-limited call is retried when the
+// client has a rate-limit profile
+const rateLimitRetries = 3
+
+// SetRateLimit makes the client retry calls the endpoint rate limits, waiting
+// as profile says between attempts; nil turns retrying off
+func (r *RPCClient) SetRateLimit(profile *RateLimitProfile) {
+	r.rateLimit = profile
+}
+
+// rateLimitTransport retries requests the endpoint rate limited, up to
+// rateLimitRetries times, waiting for Retry-After or the client's profiled
+// recovery window in between
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	client *RPCClient
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	profile := t.client.rateLimit
+	if profile == nil || !profile.Limited || req.GetBody == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		try := req
+		if attempt > 0 {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			try = req.Clone(req.Context())
+			try.Body = body
+		}
+		resp, err := t.base.RoundTrip(try)
+		if err != nil || attempt == rateLimitRetries {
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return resp, nil
+		}
+		limited := resp.StatusCode == http.StatusTooManyRequests
+		if code, message, ok := responseError(body); ok && !limited {
+			limited = (profile.ErrorCode != 0 && code == profile.ErrorCode) || isRateLimitError(&rpcError{Code: code, Message: message})
+		}
+		if !limited {
+			return resp, nil
+		}
+
+		delay := parseRetryAfter(resp.Header)
+		if delay <= 0 {
+			delay = profile.RetryDelay()
+		}
+		t.client.logger.Debug("rate limited, retrying", "host", req.URL.Hostname(), "attempt", attempt+1, "delay", delay)
+		select {
+		case <-req.Context().Done():
+			return resp, nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+// limitSignal is what a single probe request observed
+type limitSignal struct {
+	limited    bool
+	status     int
+	code       int
+	message    string
+	retryAfter time.Duration
+}
+
+// probeOnce sends one payload and reports whether it was rate limited
+func (r *RPCClient) probeOnce(ctx context.Context, payload []byte) (limitSignal, error) {
+	status, header, body, err := r.postJSONWithHeaders(ctx, payload)
+	if err != nil {
+		return limitSignal{}, err
+	}
+
+	sig := limitSignal{status: status, retryAfter: parseRetryAfter(header)}
+	if status == http.StatusTooManyRequests {
+		sig.limited = true
+		return sig, nil
+	}
+
+	var single rpcResponse
+	var batch []rpcResponse
+	var errs []*rpcError
+	if json.Unmarshal(body, &single) == nil {
+		errs = append(errs, single.Error)
+	} else if json.Unmarshal(body, &batch) == nil {
+		for _, b := range batch {
+			errs = append(errs, b.Error)
+		}
+	}
+
+	for _, e := range errs {
+		if e != nil && isRateLimitError(e) {
+			sig.limited = true
+			sig.code = e.Code
+			sig.message = e.Message
+			return sig, nil
+		}
+	}
+
+	return sig, nil
+}
+
+// isRateLimitError reports whether a JSON-RPC error signals rate limiting,
+// by its code or by a message that names the rate limit. Other "exceeded"
+// errors, such as gas or block range limits, are not rate limiting.
+func isRateLimitError(e *rpcError) bool {
+	if rateLimitCodes[e.Code] {
+		return true
+	}
+	lower := strings.ToLower(e.Message)
+	return strings.Contains(lower, "rate limit") || strings.Contains(lower, "too many requests")
+}
+
+// parseRetryAfter decodes a Retry-After header in either seconds or HTTP-date form
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// burst sends n requests concurrently and returns the first limit signal seen
+func (r *RPCClient) burst(ctx context.Context, n int, payload []byte) (*limitSignal, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var found *limitSignal
+	var firstErr error
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sig, err := r.probeOnce(ctx, payload)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if sig.limited && found == nil {
+				found = &sig
+			}
+		}()
+	}
+	wg.Wait()
+
+	if found == nil && firstErr != nil {
+		return nil, firstErr
+	}
+	return found, nil
+}
+
+// ProbeRateLimit intentionally exceeds the endpoint's rate limit by doubling the
+// request rate every second up to maxRPS, then characterizes the limit: how it is
+// signalled, Retry-After, the recovery window, and whether batch entries count
+// individually. It waits at most maxWindow for the limit to lift; when it does
+// not, the window is recorded as at least maxWindow and batches are not probed.
+func (r *RPCClient) ProbeRateLimit(ctx context.Context, maxRPS int, maxWindow time.Duration) (*RateLimitProfile, error) {
+	profile := &RateLimitProfile{Endpoint: r.DisplayURL(), Signal: "none", ProbedAt: time.Now()}
+	single := []byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":1}`)
+
+	var sig *limitSignal
+	for rps := 1; rps <= maxRPS && sig == nil; rps *= 2 {
+		start := time.Now()
+		found, err := r.burst(ctx, rps, single)
+		if err != nil {
+			return profile, err
+		}
+		if found != nil {
+			sig = found
+			profile.LimitRPS = float64(rps) / math.Max(time.Since(start).Seconds(), 1)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return profile, ctx.Err()
+		case <-time.After(time.Second - time.Since(start)):
+		}
+	}
+	if sig == nil {
+		return profile, nil
+	}
+
+	profile.Limited = true
+	profile.HTTPStatus = sig.status
+	profile.ErrorCode = sig.code
+	profile.ErrorMessage = sig.message
+	profile.RetryAfter = sig.retryAfter
+	if sig.status == http.StatusTooManyRequests {
+		profile.Signal = "http-429"
+	} else {
+		profile.Signal = "jsonrpc-error"
+	}
+
+	// Measure the window by polling until a request gets through again
+	limitedAt := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return profile, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+		if time.Since(limitedAt) >= maxWindow {
+			profile.Window = maxWindow
+			profile.WindowAtLeast = true
+			r.logger.Warn("rate limit did not lift; not probing batches", "endpoint", r.DisplayURL(), "waited", maxWindow)
+			return profile, nil
+		}
+		s, err := r.probeOnce(ctx, single)
+		if err != nil {
+			return profile, err
+		}
+		if !s.limited {
+			profile.Window = time.Since(limitedAt)
+			break
+		}
+	}
+
+	// One batch per second with more entries than the limit allows per second
+	// only gets limited if the provider counts batch entries individually
+	size := int(math.Ceil(profile.LimitRPS)) * 2
+	if size < 2 {
+		size = 2
+	}
+	entries := make([]string, size)
+	for i := range entries {
+		entries[i] = fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_chainId","params":[],"id":%d}`, i+1)
+	}
+	batch := []byte("[" + strings.Join(entries, ",") + "]")
+
+	for i := 0; i < 3; i++ {
+		s, err := r.probeOnce(ctx, batch)
+		if err != nil {
+			return profile, err
+		}
+		if s.limited {
+			profile.BatchCountsEntry = true
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return profile, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return profile, nil
+}
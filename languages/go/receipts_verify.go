@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ReceiptsCheck holds the result of verifying one block's receipts against its header
+type ReceiptsCheck struct {
+	Block            uint64
+	Receipts         int
+	ReceiptsRootOK   bool
+	LogsBloomOK      bool
+	BadReceiptBlooms []int
+	Detail           string
+}
+
+// Passed reports whether every check succeeded for the block
+func (c ReceiptsCheck) Passed() bool {
+	return c.ReceiptsRootOK && c.LogsBloomOK && len(c.BadReceiptBlooms) == 0
+}
+
+// SampleBlockNumbers returns up to count block numbers spread evenly over [from, to]
+func SampleBlockNumbers(from, to uint64, count int) []uint64 {
+	if count <= 0 || from > to {
+		return nil
+	}
+
+	span := to - from + 1
+	if uint64(count) >= span {
+		blocks := make([]uint64, 0, span)
+		for n := from; n <= to; n++ {
+			blocks = append(blocks, n)
+		}
+		return blocks
+	}
+
+	step := span / uint64(count)
+	blocks := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		blocks = append(blocks, from+uint64(i)*step)
+	}
+
+	return blocks
+}
+
+// depositTxType is the OP Stack deposit transaction type, whose receipts
+// carry a deposit nonce and, since Canyon, a receipt version
+const depositTxType = 0x7e
+
+// receiptRLP is the consensus encoding of a receipt; the deposit fields are
+// left out when nil
+type receiptRLP struct {
+	Status            []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	Logs              []*types.Log
+	DepositNonce      *uint64 `rlp:"optional"`
+	DepositVersion    *uint64 `rlp:"optional"`
+}
+
+// depositFields are the receipt JSON fields go-ethereum does not decode
+type depositFields struct {
+	DepositNonce          *hexutil.Uint64 `json:"depositNonce"`
+	DepositReceiptVersion *hexutil.Uint64 `json:"depositReceiptVersion"`
+}
+
+// consensusReceipts encodes receipts for the receipts trie. types.Receipts
+// writes only the type byte for types go-ethereum does not know, such as
+// 0x04 and 0x7e, so every typed receipt is encoded here instead.
+type consensusReceipts struct {
+	receipts types.Receipts
+	deposits []depositFields
+}
+
+func (c consensusReceipts) Len() int { return len(c.receipts) }
+
+func (c consensusReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	r := c.receipts[i]
+	data := receiptRLP{Status: r.PostState, CumulativeGasUsed: r.CumulativeGasUsed, Bloom: r.Bloom, Logs: r.Logs}
+	if len(data.Status) == 0 {
+		data.Status = []byte{}
+		if r.Status == types.ReceiptStatusSuccessful {
+			data.Status = []byte{0x01}
+		}
+	}
+	if r.Type == depositTxType && i < len(c.deposits) {
+		data.DepositNonce = (*uint64)(c.deposits[i].DepositNonce)
+		data.DepositVersion = (*uint64)(c.deposits[i].DepositReceiptVersion)
+	}
+	if r.Type != types.LegacyTxType {
+		w.WriteByte(r.Type)
+	}
+	rlp.Encode(w, &data)
+}
+
+// VerifyReceipts recomputes the receipts trie root and logs bloom of each block
+// from eth_getBlockReceipts output and compares them with the header values
+func (r *RPCClient) VerifyReceipts(ctx context.Context, blocks []uint64) ([]ReceiptsCheck, error) {
+	checks := make([]ReceiptsCheck, 0, len(blocks))
+	for _, n := range blocks {
+		header, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return checks, fmt.Errorf("failed to get header %d: %w", n, err)
+		}
+
+		raw, err := r.CallRaw(ctx, "eth_getBlockReceipts", hexutil.EncodeUint64(n))
+		if err != nil {
+			return checks, err
+		}
+		var receipts types.Receipts
+		if err := json.Unmarshal(raw, &receipts); err != nil {
+			return checks, fmt.Errorf("failed to decode receipts: %w", err)
+		}
+		var deposits []depositFields
+		if err := json.Unmarshal(raw, &deposits); err != nil {
+			return checks, fmt.Errorf("failed to decode receipts: %w", err)
+		}
+
+		check := ReceiptsCheck{Block: n, Receipts: len(receipts)}
+
+		root := types.DeriveSha(consensusReceipts{receipts, deposits}, trie.NewStackTrie(nil))
+		check.ReceiptsRootOK = root == header.ReceiptHash
+
+		bloom := types.CreateBloom(receipts)
+		check.LogsBloomOK = bloom == header.Bloom
+
+		for i, receipt := range receipts {
+			if types.BytesToBloom(types.LogsBloom(receipt.Logs)) != receipt.Bloom {
+				check.BadReceiptBlooms = append(check.BadReceiptBlooms, i)
+			}
+		}
+
+		if !check.ReceiptsRootOK {
+			check.Detail = fmt.Sprintf("receipts root %s, header %s", root.Hex(), header.ReceiptHash.Hex())
+		} else if !check.LogsBloomOK {
+			check.Detail = "logs bloom does not match header"
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testReceipt returns a receipt of the given type with one log
+func testReceipt(txType uint8, status uint64, cumulativeGas uint64) *types.Receipt {
+	logs := []*types.Log{{
+		Address: common.HexToAddress("0x00000000000000000000000000000000000000aa"),
+		Topics:  []common.Hash{transferTopic},
+		Data:    []byte{0x01, 0x02},
+	}}
+	return &types.Receipt{
+		Type:              txType,
+		Status:            status,
+		CumulativeGasUsed: cumulativeGas,
+		Logs:              logs,
+		Bloom:             types.BytesToBloom(types.LogsBloom(logs)),
+	}
+}
+
+func TestConsensusReceiptsKnownTypes(t *testing.T) {
+	receipts := types.Receipts{
+		testReceipt(types.LegacyTxType, types.ReceiptStatusSuccessful, 21000),
+		testReceipt(types.AccessListTxType, types.ReceiptStatusFailed, 50000),
+		testReceipt(types.DynamicFeeTxType, types.ReceiptStatusSuccessful, 90000),
+		testReceipt(types.BlobTxType, types.ReceiptStatusSuccessful, 120000),
+	}
+	want := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	if got := types.DeriveSha(consensusReceipts{receipts: receipts}, trie.NewStackTrie(nil)); got != want {
+		t.Errorf("receipts root %s, go-ethereum derives %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestConsensusReceiptsUnknownTypes(t *testing.T) {
+	// A 7702 receipt has the same layout as a dynamic fee receipt
+	setCode := consensusReceipts{receipts: types.Receipts{testReceipt(0x04, types.ReceiptStatusSuccessful, 21000)}}
+	dynamic := consensusReceipts{receipts: types.Receipts{testReceipt(types.DynamicFeeTxType, types.ReceiptStatusSuccessful, 21000)}}
+	var a, b bytes.Buffer
+	setCode.EncodeIndex(0, &a)
+	dynamic.EncodeIndex(0, &b)
+	if a.Bytes()[0] != 0x04 || !bytes.Equal(a.Bytes()[1:], b.Bytes()[1:]) {
+		t.Errorf("7702 receipt encoded as %x", a.Bytes())
+	}
+
+	nonce, version := hexutil.Uint64(7), hexutil.Uint64(1)
+	tests := []struct {
+		name    string
+		fields  depositFields
+		wantLen int
+	}{
+		{"pre-Regolith", depositFields{}, b.Len()},
+		// One byte each for the small nonce and version
+		{"Regolith", depositFields{DepositNonce: &nonce}, b.Len() + 1},
+		{"Canyon", depositFields{DepositNonce: &nonce, DepositReceiptVersion: &version}, b.Len() + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deposit := consensusReceipts{
+				receipts: types.Receipts{testReceipt(depositTxType, types.ReceiptStatusSuccessful, 21000)},
+				deposits: []depositFields{tt.fields},
+			}
+			var buf bytes.Buffer
+			deposit.EncodeIndex(0, &buf)
+			if buf.Bytes()[0] != depositTxType || buf.Len() != tt.wantLen {
+				t.Errorf("deposit receipt encoded as %x", buf.Bytes())
+			}
+		})
+	}
+}
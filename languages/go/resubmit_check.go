@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// knownTxErrors are the error fragments clients use to reject an already-seen transaction
+var knownTxErrors = []string{"already known", "known transaction", "already imported", "nonce too low", "already in mempool"}
+
+// SubmissionOutcome is the endpoint's answer to one eth_sendRawTransaction attempt
+type SubmissionOutcome struct {
+	Attempt    int
+	Concurrent bool
+	Hash       common.Hash
+	Err        string
+}
+
+// ResubmitCheck holds the outcome of submitting the same raw transaction repeatedly
+type ResubmitCheck struct {
+	TxHash      common.Hash
+	Outcomes    []SubmissionOutcome
+	Included    bool
+	NonceBefore uint64
+	NonceAfter  uint64
+	Problems    []string
+}
+
+// Passed reports whether every resubmission was handled consistently and the
+// transaction was included exactly once
+func (c ResubmitCheck) Passed() bool {
+	return c.Included && len(c.Problems) == 0
+}
+
+// CheckIdempotentResubmission signs a zero-value self transfer and submits the
+// same raw bytes sequential times in a row and then concurrent times in
+// parallel, verifying the endpoint answers with the same hash or a known-tx
+// error every time and that the sender nonce advances by exactly one
+func (r *RPCClient) CheckIdempotentResubmission(ctx context.Context, sequential, concurrent int) (*ResubmitCheck, error) {
+	tx, err := r.SignTransfer(ctx, r.address, common.Big0)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	encoded := hexutil.Encode(raw)
+
+	check := &ResubmitCheck{TxHash: tx.Hash(), NonceBefore: tx.Nonce()}
+
+	submit := func(attempt int, parallel bool) SubmissionOutcome {
+		outcome := SubmissionOutcome{Attempt: attempt, Concurrent: parallel}
+		result, err := r.CallRaw(ctx, "eth_sendRawTransaction", encoded)
+		if err != nil {
+			outcome.Err = err.Error()
+			return outcome
+		}
+		outcome.Hash = common.HexToHash(strings.Trim(string(result), `"`))
+		return outcome
+	}
+
+	for i := 0; i < sequential; i++ {
+		check.Outcomes = append(check.Outcomes, submit(i, false))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func(attempt int) {
+			defer wg.Done()
+			outcome := submit(attempt, true)
+			mu.Lock()
+			check.Outcomes = append(check.Outcomes, outcome)
+			mu.Unlock()
+		}(sequential + i)
+	}
+	wg.Wait()
+
+	accepted := 0
+	errorKinds := make(map[string]bool)
+	for _, o := range check.Outcomes {
+		if o.Err == "" {
+			accepted++
+			if o.Hash != check.TxHash {
+				check.Problems = append(check.Problems, fmt.Sprintf("attempt %d returned hash %s, expected %s", o.Attempt, o.Hash.Hex(), check.TxHash.Hex()))
+			}
+			continue
+		}
+		kind := classifyKnownTxError(o.Err)
+		if kind == "" {
+			check.Problems = append(check.Problems, fmt.Sprintf("attempt %d returned unexpected error: %s", o.Attempt, o.Err))
+			continue
+		}
+		// "nonce too low" legitimately replaces the known-tx error once the
+		// transaction is mined, so it does not count as inconsistent
+		if kind != "nonce too low" {
+			errorKinds[kind] = true
+		}
+	}
+	if accepted == 0 {
+		check.Problems = append(check.Problems, "no submission was accepted")
+	}
+	if len(errorKinds) > 1 {
+		kinds := make([]string, 0, len(errorKinds))
+		for k := range errorKinds {
+			kinds = append(kinds, k)
+		}
+		check.Problems = append(check.Problems, fmt.Sprintf("inconsistent known-tx errors: %s", strings.Join(kinds, ", ")))
+	}
+	if accepted == 0 {
+		return check, nil
+	}
+
+	receipt, err := bind.WaitMined(ctx, r.client, tx)
+	if err != nil {
+		return check, fmt.Errorf("failed waiting for inclusion: %w", err)
+	}
+	check.Included = receipt.TxHash == check.TxHash
+
+	nonce, err := r.client.NonceAt(ctx, r.address, receipt.BlockNumber)
+	if err != nil {
+		return check, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	check.NonceAfter = nonce
+	if nonce != check.NonceBefore+1 {
+		check.Problems = append(check.Problems, fmt.Sprintf("nonce advanced from %d to %d, expected exactly one inclusion", check.NonceBefore, nonce))
+	}
+
+	return check, nil
+}
+
+// classifyKnownTxError maps an error message to the known-tx fragment it contains
+func classifyKnownTxError(msg string) string {
+	lower := strings.ToLower(msg)
+	for _, fragment := range knownTxErrors {
+		if strings.Contains(lower, fragment) {
+			return fragment
+		}
+	}
+	return ""
+}
@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // RPCClient is a Go client for interacting with Ethereum RPC endpoints.
@@ -19,24 +25,52 @@ import (
 type RPCClient struct {
 	client     *ethclient.Client
 	privateKey *ecdsa.PrivateKey
+	signer     Signer
 	address    common.Address
 	rpcURL     string
+	headers    http.Header
+	http       *http.Client
+	logger     *slog.Logger
+
+	// slowThreshold and slowSample control slow-call logging
+	slowThreshold time.Duration
+	slowSample    float64
+
+	// gasStrategy selects legacy or EIP-1559 pricing for signed transfers
+	gasStrategy string
+
+	// rateLimit, when set, retries calls the endpoint rate limits
+	rateLimit *RateLimitProfile
+
+	// profile is the network profile the endpoint was selected by, if any
+	profile *NetworkProfile
 }
 
 // NewRPCClient creates a new RPC client instance
 func NewRPCClient(rpcURL string, privateKeyHex string) (*RPCClient, error) {
+	return NewRPCClientWithHeaders(rpcURL, privateKeyHex, nil)
+}
+
+// NewRPCClientWithHeaders creates a client that sends extra HTTP headers,
+// such as API keys, on every request
+func NewRPCClientWithHeaders(rpcURL string, privateKeyHex string, headers http.Header) (*RPCClient, error) {
+	r := &RPCClient{
+		rpcURL:  rpcURL,
+		headers: headers,
+		logger:  slog.Default(),
+	}
+	r.http = r.newHTTPClient()
+
 	// Connect to Ethereum node
-	client, err := ethclient.Dial(rpcURL)
+	rpcClient, err := rpc.DialOptions(context.Background(), rpcURL, rpc.WithHeaders(headers), rpc.WithHTTPClient(r.http))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
 	}
+	r.client = ethclient.NewClient(rpcClient)
 
 	// Load private key if provided
-	var privateKey *ecdsa.PrivateKey
-	var address common.Address
-
 	if privateKeyHex != "" {
-		privateKey, err = crypto.HexToECDSA(privateKeyHex)
+		privateKey, err := crypto.HexToECDSA(privateKeyHex)
 		if err != nil {
 			return nil, fmt.Errorf("invalid private key: %w", err)
 		}
@@ -47,15 +81,50 @@ func NewRPCClient(rpcURL string, privateKeyHex string) (*RPCClient, error) {
 			return nil, fmt.Errorf("error casting public key to ECDSA")
 		}
 
-		address = crypto.PubkeyToAddress(*publicKeyECDSA)
+		r.privateKey = privateKey
+		r.signer = NewKeySigner(privateKey)
+		r.address = crypto.PubkeyToAddress(*publicKeyECDSA)
+	}
+
+	return r, nil
+}
+
+// newHTTPClient builds the HTTP client for JSON-RPC calls, which logs each
+// call at debug level, retries rate-limited calls once a profile is set, and
+// traces it and reports its metrics once those are enabled
+func (r *RPCClient) newHTTPClient() *http.Client {
+	var transport http.RoundTripper = http.DefaultTransport
+	if metricsSink != nil {
+		transport = &statsdTransport{base: transport, sink: metricsSink}
 	}
+	if latencyHistograms != nil {
+		transport = &histogramTransport{base: transport, histograms: latencyHistograms}
+	}
+	if tracerProvider != nil {
+		transport = &tracingTransport{base: transport}
+	}
+	transport = &rateLimitTransport{base: transport, client: r}
+	return &http.Client{Transport: &loggingTransport{base: transport, client: r}}
+}
+
+// SetLogger replaces the logger used for the client's calls and events
+func (r *RPCClient) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// SetSlowLog logs calls that take at least threshold at warn level, with
+// request and response bodies for the given fraction (0-1) of them; a zero
+// threshold turns slow-call logging off
+func (r *RPCClient) SetSlowLog(threshold time.Duration, sample float64) {
+	r.slowThreshold = threshold
+	r.slowSample = sample
+}
 
-	return &RPCClient{
-		client:     client,
-		privateKey: privateKey,
-		address:    address,
-		rpcURL:     rpcURL,
-	}, nil
+// SetSigner makes the client sign transactions with signer, such as a
+// hardware wallet, and act as its account
+func (r *RPCClient) SetSigner(signer Signer) {
+	r.signer = signer
+	r.address = signer.Address()
 }
 
 // Close closes the RPC client connection
@@ -65,6 +134,32 @@ func (r *RPCClient) Close() {
 	}
 }
 
+// SetGasStrategy selects how SignTransfer prices transactions
+func (r *RPCClient) SetGasStrategy(strategy string) {
+	r.gasStrategy = strategy
+}
+
+// SetProfile records the network profile the endpoint belongs to, which
+// supplies the WebSocket URL and chain parameters some checks need
+func (r *RPCClient) SetProfile(profile *NetworkProfile) {
+	r.profile = profile
+	if profile != nil {
+		r.gasStrategy = profile.GasStrategy
+	}
+}
+
+// wsURL returns the endpoint's WebSocket URL: the endpoint itself when it
+// speaks WebSocket, otherwise the profile's, or "" when neither is known
+func (r *RPCClient) wsURL() string {
+	if strings.HasPrefix(r.rpcURL, "ws://") || strings.HasPrefix(r.rpcURL, "wss://") {
+		return r.rpcURL
+	}
+	if r.profile != nil {
+		return r.profile.WSURL
+	}
+	return ""
+}
+
 // GetAddress returns the client's Ethereum address
 func (r *RPCClient) GetAddress() common.Address {
 	return r.address
@@ -75,6 +170,12 @@ func (r *RPCClient) GetRPCURL() string {
 	return r.rpcURL
 }
 
+// DisplayURL returns the RPC URL with embedded credentials redacted,
+// for use in logs and reports
+func (r *RPCClient) DisplayURL() string {
+	return RedactURL(r.rpcURL)
+}
+
 // GetBlockNumber retrieves the latest block number
 func (r *RPCClient) GetBlockNumber(ctx context.Context) (*big.Int, error) {
 	blockNumber, err := r.client.BlockNumber(ctx)
@@ -117,51 +218,136 @@ func (r *RPCClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
 
 // SendTransaction sends a transaction to the network
 func (r *RPCClient) SendTransaction(ctx context.Context, to common.Address, value *big.Int) (*types.Transaction, error) {
-	if r.privateKey == nil {
-		return nil, fmt.Errorf("private key not set")
+	signedTx, err := r.SignTransfer(ctx, to, value)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get nonce
-	nonce, err := r.client.PendingNonceAt(ctx, r.address)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	// Send transaction
+	if err := r.BroadcastTransaction(ctx, signedTx); err != nil {
+		return nil, err
 	}
 
-	// Get gas price
-	gasPrice, err := r.client.SuggestGasPrice(ctx)
+	return signedTx, nil
+}
+
+// SignTransfer builds and signs a simple value transfer without sending it
+func (r *RPCClient) SignTransfer(ctx context.Context, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return r.BuildTransaction(ctx, TxRequest{To: &to, Value: value, Gas: 21000})
+}
+
+// TxRequest describes a transaction for BuildTransaction; zero fields are
+// filled from the node
+type TxRequest struct {
+	To    *common.Address
+	Value *big.Int
+	Data  []byte
+	// Gas is estimated when zero
+	Gas uint64
+	// Nonce is the pending nonce when nil
+	Nonce *uint64
+}
+
+// BuildTransaction fills in nonce, gas, and fees for req and signs it
+// without sending it, pricing it according to the gas strategy
+func (r *RPCClient) BuildTransaction(ctx context.Context, req TxRequest) (*types.Transaction, error) {
+	tx, chainID, err := r.PrepareTransaction(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+		return nil, err
+	}
+	return r.signer.SignTx(ctx, tx, chainID)
+}
+
+// PrepareTransaction fills in nonce, gas, and fees for req like
+// BuildTransaction but leaves it unsigned, returning the chain ID to sign for
+func (r *RPCClient) PrepareTransaction(ctx context.Context, req TxRequest) (*types.Transaction, *big.Int, error) {
+	if r.signer == nil {
+		return nil, nil, fmt.Errorf("no signer set; pass --key, --ledger, --node-account, or --transit-key")
+	}
+	value := req.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	// Get nonce
+	var nonce uint64
+	if req.Nonce != nil {
+		nonce = *req.Nonce
+	} else {
+		pending, err := r.client.PendingNonceAt(ctx, r.address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get nonce: %w", err)
+		}
+		nonce = pending
 	}
 
 	// Get chain ID
 	chainID, err := r.client.ChainID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+		return nil, nil, fmt.Errorf("failed to get chain ID: %w", err)
 	}
 
-	// Create transaction
-	tx := types.NewTransaction(
-		nonce,
-		to,
-		value,
-		21000, // gas limit for simple transfer
-		gasPrice,
-		nil,
-	)
-
-	// Sign transaction
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), r.privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	gas := req.Gas
+	if gas == 0 {
+		gas, err = r.client.EstimateGas(ctx, ethereum.CallMsg{From: r.address, To: req.To, Value: value, Data: req.Data})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
 	}
 
-	// Send transaction
-	err = r.client.SendTransaction(ctx, signedTx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	var tx *types.Transaction
+	if r.gasStrategy == GasStrategyEIP1559 {
+		tip, err := r.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get gas tip cap: %w", err)
+		}
+		head, err := r.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get latest header: %w", err)
+		}
+		if head.BaseFee == nil {
+			return nil, nil, fmt.Errorf("latest block has no base fee; use the %s gas strategy", GasStrategyLegacy)
+		}
+
+		// Leave room for the base fee to double before the transaction lands
+		feeCap := new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        req.To,
+			Value:     value,
+			Data:      req.Data,
+		})
+	} else {
+		// Get gas price
+		gasPrice, err := r.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      gas,
+			To:       req.To,
+			Value:    value,
+			Data:     req.Data,
+		})
 	}
 
-	return signedTx, nil
+	return tx, chainID, nil
+}
+
+// BroadcastTransaction sends an already signed transaction
+func (r *RPCClient) BroadcastTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := r.client.SendTransaction(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send transaction: %w", err)
+	}
+	r.logger.Info("transaction submitted", "tx_hash", tx.Hash().Hex(), "endpoint", r.DisplayURL())
+	return nil
 }
 
 // WaitForTransaction waits for a transaction to be mined
@@ -174,6 +360,16 @@ func (r *RPCClient) WaitForTransaction(ctx context.Context, txHash common.Hash)
 	return receipt, nil
 }
 
+// CallRaw performs an arbitrary JSON-RPC call and returns the undecoded result
+func (r *RPCClient) CallRaw(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	var result json.RawMessage
+	if err := r.client.Client().CallContext(ctx, &result, method, params...); err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+
+	return result, nil
+}
+
 // VerifySignature verifies an Ethereum signature
 func VerifySignature(message []byte, signature []byte, expectedAddress common.Address) (bool, error) {
 	// Hash the message
@@ -191,51 +387,3 @@ func VerifySignature(message []byte, signature []byte, expectedAddress common.Ad
 	// Compare addresses
 	return recoveredAddr == expectedAddress, nil
 }
-
-// Example usage
-func main() {
-	ctx := context.Background()
-
-	// Create client (read-only, no private key)
-	client, err := NewRPCClient("https://eth.llamarpc.com", "")
-	if err != nil {
-		log.Fatalf("Failed to create RPC client: %v", err)
-	}
-
-	fmt.Println("🔗 Ethereum RPC Client Example\n")
-
-	// Get block number
-	blockNumber, err := client.GetBlockNumber(ctx)
-	if err != nil {
-		log.Printf("Error getting block number: %v", err)
-	} else {
-		fmt.Printf("Latest Block: %s\n", blockNumber.String())
-	}
-
-	// Get chain ID
-	chainID, err := client.GetChainID(ctx)
-	if err != nil {
-		log.Printf("Error getting chain ID: %v", err)
-	} else {
-		fmt.Printf("Chain ID: %s\n", chainID.String())
-	}
-
-	// Get gas price
-	gasPrice, err := client.GetGasPrice(ctx)
-	if err != nil {
-		log.Printf("Error getting gas price: %v", err)
-	} else {
-		fmt.Printf("Gas Price: %s wei\n", gasPrice.String())
-	}
-
-	// Check balance of an address
-	address := common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
-	balance, err := client.GetBalance(ctx, address)
-	if err != nil {
-		log.Printf("Error getting balance: %v", err)
-	} else {
-		fmt.Printf("Balance of %s: %s wei\n", address.Hex(), balance.String())
-	}
-
-	fmt.Println("\n✅ RPC client example completed successfully")
-}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of run the server can start
+const (
+	RunKindConformance = "conformance"
+	RunKindScript      = "script"
+)
+
+// Run states reported by the server
+const (
+	RunRunning = "running"
+	RunDone    = "done"
+	RunStopped = "stopped"
+	RunFailed  = "failed"
+)
+
+// RunRequest is the body of POST /runs
+type RunRequest struct {
+	Kind string `json:"kind"`
+	// Endpoint is a URL or network profile name; empty uses the server's endpoint
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Checks and CheckTimeout configure conformance runs
+	Checks       []string `json:"checks,omitempty"`
+	CheckTimeout string   `json:"check_timeout,omitempty"`
+
+	// Script is run inline, or ScriptFile is read on the server, for script runs
+	Script     *Script                `json:"script,omitempty"`
+	ScriptFile string                 `json:"script_file,omitempty"`
+	Vars       map[string]interface{} `json:"vars,omitempty"`
+}
+
+// RunStatus is the live progress of a run
+type RunStatus struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Endpoint   string     `json:"endpoint"`
+	State      string     `json:"state"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Total      int        `json:"total"`
+	Completed  int        `json:"completed"`
+	Passed     int        `json:"passed"`
+}
+
+// ScriptReport is the report of a script run
+type ScriptReport struct {
+	Endpoint  string       `json:"endpoint"`
+	StartedAt time.Time    `json:"started_at"`
+	Steps     []StepResult `json:"steps"`
+}
+
+// ClientFactory connects to a run's endpoint, where "" is the default
+type ClientFactory func(endpoint string) (*RPCClient, error)
+
+// testRun is one run tracked by the server
+type testRun struct {
+	status RunStatus
+	cancel context.CancelFunc
+	report interface{}
+}
+
+// RunServer exposes a REST API to start, stop, and follow conformance and
+// script runs, and to download their reports:
+//
+//	GET  /runs              list runs
+//	POST /runs              start a run from a RunRequest
+//	GET  /runs/{id}         live status
+//	POST /runs/{id}/stop    cancel a run
+//	GET  /runs/{id}/report  the finished report
+type RunServer struct {
+	connect ClientFactory
+	token   string
+
+	mu     sync.Mutex
+	runs   map[string]*testRun
+	order  []string
+	nextID int
+}
+
+// NewRunServer creates a server that connects through connect; when token is
+// set, requests must carry it as a bearer token
+func NewRunServer(connect ClientFactory, token string) *RunServer {
+	return &RunServer{
+		connect: connect,
+		token:   token,
+		runs:    make(map[string]*testRun),
+	}
+}
+
+// ServeHTTP routes API requests
+func (s *RunServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if s.token != "" {
+		got := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+	}
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if parts[0] != "runs" {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, s.list())
+	case len(parts) == 1 && req.Method == http.MethodPost:
+		s.handleStart(w, req)
+	case len(parts) == 2 && req.Method == http.MethodGet:
+		if status, ok := s.status(parts[1]); ok {
+			writeJSON(w, http.StatusOK, status)
+		} else {
+			writeAPIError(w, http.StatusNotFound, "unknown run "+parts[1])
+		}
+	case len(parts) == 3 && parts[2] == "stop" && req.Method == http.MethodPost:
+		s.handleStop(w, parts[1])
+	case len(parts) == 3 && parts[2] == "report" && req.Method == http.MethodGet:
+		s.handleReport(w, parts[1])
+	default:
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Sprintf("%s %s is not supported", req.Method, req.URL.Path))
+	}
+}
+
+// handleStart validates a RunRequest and starts the run in the background
+func (s *RunServer) handleStart(w http.ResponseWriter, req *http.Request) {
+	var runReq RunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxProxyBody)).Decode(&runReq); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid run request: %v", err))
+		return
+	}
+
+	status, err := s.Start(runReq)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, Redact(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// handleStop cancels a running run
+func (s *RunServer) handleStop(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	if ok {
+		run.cancel()
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown run "+id)
+		return
+	}
+	status, _ := s.status(id)
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleReport returns the report of a finished run
+func (s *RunServer) handleReport(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	var state string
+	var report interface{}
+	if ok {
+		state, report = run.status.State, run.report
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeAPIError(w, http.StatusNotFound, "unknown run "+id)
+	case state == RunRunning:
+		writeAPIError(w, http.StatusConflict, "run "+id+" is still running")
+	case report == nil:
+		writeAPIError(w, http.StatusNotFound, "run "+id+" produced no report")
+	default:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "run-"+id+".json"))
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// Start connects to the run's endpoint and starts it in the background
+func (s *RunServer) Start(req RunRequest) (RunStatus, error) {
+	var execute func(ctx context.Context, client *RPCClient, run *testRun) interface{}
+	var total int
+
+	switch req.Kind {
+	case RunKindConformance:
+		timeout := 2 * time.Minute
+		if req.CheckTimeout != "" {
+			d, err := time.ParseDuration(req.CheckTimeout)
+			if err != nil || d <= 0 {
+				return RunStatus{}, fmt.Errorf("invalid check_timeout %q", req.CheckTimeout)
+			}
+			timeout = d
+		}
+		checks, err := SelectChecks(DefaultConformanceChecks(), req.Checks)
+		if err != nil {
+			return RunStatus{}, err
+		}
+		total = len(checks)
+		execute = func(ctx context.Context, client *RPCClient, run *testRun) interface{} {
+			return RunConformanceWithProgress(ctx, client, checks, timeout, func(r ConformanceResult) {
+				s.advance(run, r.Status == StatusPass)
+			})
+		}
+
+	case RunKindScript:
+		script := req.Script
+		if req.ScriptFile != "" {
+			loaded, err := LoadScript(req.ScriptFile)
+			if err != nil {
+				return RunStatus{}, err
+			}
+			script = loaded
+		}
+		if script == nil || len(script.Steps) == 0 {
+			return RunStatus{}, fmt.Errorf("script runs need a script or script_file with steps")
+		}
+		total = len(script.Steps)
+		execute = func(ctx context.Context, client *RPCClient, run *testRun) interface{} {
+			report := ScriptReport{Endpoint: client.DisplayURL(), StartedAt: time.Now()}
+			report.Steps = client.RunScript(ctx, script, req.Vars, func(r StepResult) {
+				s.advance(run, r.Status == StatusPass)
+			})
+			return report
+		}
+
+	default:
+		return RunStatus{}, fmt.Errorf("unknown run kind %q (want %s or %s)", req.Kind, RunKindConformance, RunKindScript)
+	}
+
+	client, err := s.connect(req.Endpoint)
+	if err != nil {
+		return RunStatus{}, err
+	}
+
+	// Runs outlive the request that started them, so they get their own context
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("%d", s.nextID)
+	run := &testRun{
+		status: RunStatus{
+			ID:        id,
+			Kind:      req.Kind,
+			Endpoint:  client.DisplayURL(),
+			State:     RunRunning,
+			StartedAt: time.Now().UTC(),
+			Total:     total,
+		},
+		cancel: cancel,
+	}
+	s.runs[id] = run
+	s.order = append(s.order, id)
+	status := run.status
+	s.mu.Unlock()
+
+	go func() {
+		defer client.Close()
+		defer cancel()
+		report := execute(ctx, client, run)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finished := time.Now().UTC()
+		run.status.FinishedAt = &finished
+		run.report = report
+		switch {
+		case ctx.Err() != nil:
+			run.status.State = RunStopped
+		case run.status.Passed < run.status.Total:
+			run.status.State = RunFailed
+		default:
+			run.status.State = RunDone
+		}
+	}()
+
+	return status, nil
+}
+
+// advance records one finished unit of work
+func (s *RunServer) advance(run *testRun, passed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.status.Completed++
+	if passed {
+		run.status.Passed++
+	}
+}
+
+// status returns a snapshot of one run's status
+func (s *RunServer) status(id string) (RunStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return RunStatus{}, false
+	}
+	return run.status, true
+}
+
+// list returns every run's status in start order
+func (s *RunServer) list() []RunStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RunStatus, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, s.runs[id].status)
+	}
+	return out
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeAPIError writes an {"error": message} response
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
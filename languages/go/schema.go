@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+//go:embed schemas/eth.json
+var ethSchemaJSON []byte
+
+// SchemaViolation describes a response field that does not match the execution-apis schema
+type SchemaViolation struct {
+	Method string
+	Path   string
+	Kind   string // "missing", "extra", "mistyped" or "error"
+	Detail string
+}
+
+// String formats the violation for reports
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%s %s: %s: %s", v.Method, v.Path, v.Kind, v.Detail)
+}
+
+// jsonSchema is the subset of JSON schema used by the execution-apis specification
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Pattern              string                 `json:"pattern"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+
+	pattern *regexp.Regexp
+}
+
+// SchemaValidator validates RPC results against the embedded method schemas
+type SchemaValidator struct {
+	methods    map[string]*jsonSchema
+	components map[string]*jsonSchema
+}
+
+// NewSchemaValidator loads the embedded execution-apis schemas
+func NewSchemaValidator() (*SchemaValidator, error) {
+	var doc struct {
+		Methods    map[string]*jsonSchema `json:"methods"`
+		Components struct {
+			Schemas map[string]*jsonSchema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(ethSchemaJSON, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded schemas: %w", err)
+	}
+
+	v := &SchemaValidator{methods: doc.Methods, components: doc.Components.Schemas}
+	for _, s := range v.methods {
+		if err := compileSchema(s); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range v.components {
+		if err := compileSchema(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// compileSchema precompiles every pattern in a schema tree
+func compileSchema(s *jsonSchema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := compileSchema(p); err != nil {
+			return err
+		}
+	}
+	for _, o := range s.OneOf {
+		if err := compileSchema(o); err != nil {
+			return err
+		}
+	}
+	return compileSchema(s.Items)
+}
+
+// Supports reports whether a schema exists for the method
+func (v *SchemaValidator) Supports(method string) bool {
+	_, ok := v.methods[method]
+	return ok
+}
+
+// Methods returns the sorted names of methods with a schema
+func (v *SchemaValidator) Methods() []string {
+	names := make([]string, 0, len(v.methods))
+	for name := range v.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks a raw result of the given method against its schema
+func (v *SchemaValidator) Validate(method string, raw json.RawMessage) ([]SchemaViolation, error) {
+	schema, ok := v.methods[method]
+	if !ok {
+		return nil, fmt.Errorf("no schema for method %s", method)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+
+	violations := v.validate("$", schema, value)
+	for i := range violations {
+		violations[i].Method = method
+	}
+
+	return violations, nil
+}
+
+func (v *SchemaValidator) resolve(s *jsonSchema) *jsonSchema {
+	for s != nil && s.Ref != "" {
+		s = v.components[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+func (v *SchemaValidator) validate(path string, s *jsonSchema, value interface{}) []SchemaViolation {
+	s = v.resolve(s)
+	if s == nil {
+		return nil
+	}
+
+	// oneOf is treated as "at least one branch matches", which is how the
+	// execution-apis specification uses it in practice
+	if len(s.OneOf) > 0 {
+		var closest []SchemaViolation
+		for _, branch := range s.OneOf {
+			violations := v.validate(path, branch, value)
+			if len(violations) == 0 {
+				return nil
+			}
+			// Prefer reporting against a branch of the right JSON type
+			if closest == nil || matchesType(v.resolve(branch).Type, value) {
+				closest = violations
+			}
+		}
+		return closest
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		return []SchemaViolation{{Path: path, Kind: "mistyped", Detail: fmt.Sprintf("expected %s, got %s", s.Type, jsonTypeOf(value))}}
+	}
+
+	var violations []SchemaViolation
+	switch val := value.(type) {
+	case string:
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			violations = append(violations, SchemaViolation{Path: path, Kind: "mistyped", Detail: fmt.Sprintf("%q does not match %s", truncate(val, 80), s.Pattern)})
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				violations = append(violations, SchemaViolation{Path: path + "." + name, Kind: "missing", Detail: "required field not present"})
+			}
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					violations = append(violations, SchemaViolation{Path: path + "." + k, Kind: "extra", Detail: "field not in schema"})
+				}
+				continue
+			}
+			violations = append(violations, v.validate(path+"."+k, prop, val[k])...)
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				violations = append(violations, v.validate(fmt.Sprintf("%s[%d]", path, i), s.Items, item)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+func matchesType(t string, value interface{}) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number", "integer":
+		_, ok := value.(float64)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// ValidateResponses issues each request and validates its result against the
+// embedded schemas; methods without a schema are skipped, and a JSON-RPC error
+// is recorded as a violation of kind "error"
+func (r *RPCClient) ValidateResponses(ctx context.Context, validator *SchemaValidator, requests []DiffRequest) ([]SchemaViolation, error) {
+	var violations []SchemaViolation
+	for _, req := range requests {
+		if !validator.Supports(req.Method) {
+			continue
+		}
+
+		raw, err := r.CallRaw(ctx, req.Method, req.Params...)
+		if err != nil {
+			// A method the endpoint rejects has no result to validate, but
+			// the rest of the requests still do
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) {
+				return violations, err
+			}
+			violations = append(violations, SchemaViolation{Method: req.Method, Path: "$", Kind: "error", Detail: rpcErr.Error()})
+			continue
+		}
+
+		found, err := validator.Validate(req.Method, raw)
+		if err != nil {
+			return violations, err
+		}
+		violations = append(violations, found...)
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testLog is a Log result that matches the schema; tests alter it by replacement
+const testLog = `{"removed":false,"logIndex":"0x0","transactionIndex":"0x1",` +
+	`"transactionHash":"0x` + "1111111111111111111111111111111111111111111111111111111111111111" + `",` +
+	`"blockHash":"0x` + "2222222222222222222222222222222222222222222222222222222222222222" + `",` +
+	`"blockNumber":"0x10","address":"0x5FbDB2315678afecb367f032d93F642f64180aa3","data":"0x","topics":[]}`
+
+func TestSchemaValidate(t *testing.T) {
+	validator, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		raw    string
+		want   []string
+	}{
+		{"quantity", "eth_chainId", `"0x18c6"`, nil},
+		{"quantity zero", "eth_chainId", `"0x0"`, nil},
+		{"quantity leading zero", "eth_chainId", `"0x018c6"`, []string{"$ mistyped"}},
+		{"quantity as number", "eth_chainId", `6342`, []string{"$ mistyped"}},
+		{"syncing false", "eth_syncing", `false`, nil},
+		{"syncing object", "eth_syncing", `{"currentBlock":"0x1"}`, nil},
+		{"syncing string", "eth_syncing", `"no"`, []string{"$ mistyped"}},
+		{"missing block", "eth_getBlockByNumber", `null`, nil},
+		{"block as string", "eth_getBlockByNumber", `"0x1"`, []string{"$ mistyped"}},
+		{"logs", "eth_getLogs", "[" + testLog + "]", nil},
+		{"no logs", "eth_getLogs", `[]`, nil},
+		{"log missing field", "eth_getLogs", "[" + strings.Replace(testLog, `"removed":false,`, "", 1) + "]", []string{"$[0].removed missing"}},
+		{"log extra field", "eth_getLogs", "[" + strings.Replace(testLog, `"data":"0x"`, `"data":"0x","extra":1`, 1) + "]", []string{"$[0].extra extra"}},
+		{"log uppercase hash", "eth_getLogs", "[" + strings.Replace(testLog, "0x2222", "0xAAAA", 1) + "]", []string{"$[0].blockHash mistyped"}},
+		{"second log mistyped", "eth_getLogs", "[" + testLog + "," + strings.Replace(testLog, `"removed":false`, `"removed":"false"`, 1) + "]", []string{"$[1].removed mistyped"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := validator.Validate(tt.method, []byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range violations {
+				if v.Method != tt.method {
+					t.Errorf("violation method %s, want %s", v.Method, tt.method)
+				}
+				got = append(got, v.Path+" "+v.Kind)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaValidateErrors(t *testing.T) {
+	validator, err := NewSchemaValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := validator.Validate("eth_unknown", []byte(`"0x1"`)); err == nil {
+		t.Error("expected an error for a method without a schema")
+	}
+	if _, err := validator.Validate("eth_chainId", []byte(`{`)); err == nil {
+		t.Error("expected an error for an undecodable result")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// scoreboardFile is stored in the user's home directory unless --scoreboard is given
+const scoreboardFile = ".megaeth-rpc-tester_scoreboard.json"
+
+// Scoreboard retention: samples older than this, or beyond the newest
+// maxScoreboardSamples per endpoint, are dropped on save
+const (
+	scoreboardRetention  = 90 * 24 * time.Hour
+	maxScoreboardSamples = 2000
+)
+
+// HealthSample is one run's observation of an endpoint
+type HealthSample struct {
+	At time.Time `json:"at"`
+	// Source is the command that observed the endpoint, e.g. "bench"
+	Source    string `json:"source"`
+	Reachable bool   `json:"reachable"`
+
+	// Requests, Errors, and P95Ms come from load runs
+	Requests int     `json:"requests,omitempty"`
+	Errors   int     `json:"errors,omitempty"`
+	P95Ms    float64 `json:"p95_ms,omitempty"`
+
+	// Checks and ChecksFailed come from conformance runs
+	Checks       int `json:"checks,omitempty"`
+	ChecksFailed int `json:"checks_failed,omitempty"`
+}
+
+// EndpointHealthScore summarizes an endpoint's samples over a window. Score is
+// availability × (1 − error rate) × conformance pass rate, from 0 to 1;
+// latency is reported beside it rather than folded in.
+type EndpointHealthScore struct {
+	Endpoint        string    `json:"endpoint"`
+	Score           float64   `json:"score"`
+	Availability    float64   `json:"availability"`
+	ErrorRate       float64   `json:"error_rate"`
+	MeanP95Ms       float64   `json:"mean_p95_ms"`
+	ConformancePass float64   `json:"conformance_pass_rate"`
+	Samples         int       `json:"samples"`
+	LastSeen        time.Time `json:"last_seen"`
+}
+
+// Scoreboard is the persisted history of endpoint observations
+type Scoreboard struct {
+	Endpoints map[string][]HealthSample `json:"endpoints"`
+}
+
+// defaultScoreboardPath returns the scoreboard location in the home directory
+func defaultScoreboardPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	return filepath.Join(home, scoreboardFile), nil
+}
+
+// LoadScoreboard reads a scoreboard, returning an empty one if path does not exist
+func LoadScoreboard(path string) (*Scoreboard, error) {
+	board := &Scoreboard{Endpoints: make(map[string][]HealthSample)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return board, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoreboard: %w", err)
+	}
+	if err := json.Unmarshal(data, board); err != nil {
+		return nil, fmt.Errorf("failed to parse scoreboard %s: %w", path, err)
+	}
+	if board.Endpoints == nil {
+		board.Endpoints = make(map[string][]HealthSample)
+	}
+	return board, nil
+}
+
+// Save prunes old samples and writes the scoreboard, replacing the file
+// atomically so an interrupted run cannot corrupt the history
+func (b *Scoreboard) Save(path string) error {
+	cutoff := time.Now().Add(-scoreboardRetention)
+	for endpoint, samples := range b.Endpoints {
+		kept := samples[:0]
+		for _, s := range samples {
+			if s.At.After(cutoff) {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) > maxScoreboardSamples {
+			kept = kept[len(kept)-maxScoreboardSamples:]
+		}
+		if len(kept) == 0 {
+			delete(b.Endpoints, endpoint)
+		} else {
+			b.Endpoints[endpoint] = kept
+		}
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scoreboard: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write scoreboard: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write scoreboard: %w", err)
+	}
+	return nil
+}
+
+// Record appends a sample for endpoint, stamping it with the current time
+func (b *Scoreboard) Record(endpoint string, sample HealthSample) {
+	if sample.At.IsZero() {
+		sample.At = time.Now().UTC()
+	}
+	b.Endpoints[endpoint] = append(b.Endpoints[endpoint], sample)
+}
+
+// Scores summarizes every endpoint's samples newer than since, best first
+func (b *Scoreboard) Scores(since time.Time) []EndpointHealthScore {
+	var scores []EndpointHealthScore
+	for endpoint, samples := range b.Endpoints {
+		score := EndpointHealthScore{Endpoint: endpoint, Availability: 1, ConformancePass: 1}
+		var reachable, requests, errs, checks, failed, latencies int
+		var p95Total float64
+		for _, s := range samples {
+			if s.At.Before(since) {
+				continue
+			}
+			score.Samples++
+			if s.At.After(score.LastSeen) {
+				score.LastSeen = s.At
+			}
+			if s.Reachable {
+				reachable++
+			}
+			requests += s.Requests
+			errs += s.Errors
+			checks += s.Checks
+			failed += s.ChecksFailed
+			if s.P95Ms > 0 {
+				p95Total += s.P95Ms
+				latencies++
+			}
+		}
+		if score.Samples == 0 {
+			continue
+		}
+
+		score.Availability = float64(reachable) / float64(score.Samples)
+		if requests > 0 {
+			score.ErrorRate = float64(errs) / float64(requests)
+		}
+		if checks > 0 {
+			score.ConformancePass = float64(checks-failed) / float64(checks)
+		}
+		if latencies > 0 {
+			score.MeanP95Ms = math.Round(p95Total/float64(latencies)*1000) / 1000
+		}
+		score.Score = math.Round(score.Availability*(1-score.ErrorRate)*score.ConformancePass*1000) / 1000
+		score.Availability = roundRatio(score.Availability)
+		score.ErrorRate = roundRatio(score.ErrorRate)
+		score.ConformancePass = roundRatio(score.ConformancePass)
+		scores = append(scores, score)
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Endpoint < scores[j].Endpoint
+	})
+	return scores
+}
+
+// roundRatio rounds a 0-1 ratio to four places for display
+func roundRatio(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+// ScriptStep is one JSON-RPC call in a script. Strings in Params may refer to
+// variables as ${name}; a string that is exactly one reference is replaced by
+// the variable's value with its JSON type intact.
+type ScriptStep struct {
+	Name   string        `yaml:"name" json:"name"`
+	Method string        `yaml:"method" json:"method"`
+	Params []interface{} `yaml:"params" json:"params"`
+	// Capture maps variable names to paths into the result, such as
+	// $.transactionHash or $.logs[0].topics[1]
+	Capture map[string]string `yaml:"capture" json:"capture"`
+	// Expect maps paths into the result to the values they must hold
+	Expect map[string]string `yaml:"expect" json:"expect"`
+	// ExpectError passes the step only when the call fails
+	ExpectError bool `yaml:"expect_error" json:"expect_error"`
+	// Wait re-issues the call until it returns a non-null result, for
+	// receipts and other values that appear later
+	Wait ScriptDuration `yaml:"wait" json:"wait"`
+}
+
+// ScriptDuration is a duration written as a string such as "30s", or as a
+// number of seconds, in both YAML and JSON scripts
+type ScriptDuration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *ScriptDuration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *ScriptDuration) UnmarshalYAML(node *yaml.Node) error {
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// MarshalJSON implements json.Marshaler
+func (d ScriptDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// set parses a decoded duration string or number of seconds
+func (d *ScriptDuration) set(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		*d = 0
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*d = ScriptDuration(parsed)
+	case float64:
+		*d = ScriptDuration(v * float64(time.Second))
+	case int:
+		*d = ScriptDuration(time.Duration(v) * time.Second)
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+	return nil
+}
+
+// Script is a sequence of calls with variables shared between steps
+type Script struct {
+	Vars  map[string]interface{} `yaml:"vars" json:"vars"`
+	Steps []ScriptStep           `yaml:"steps" json:"steps"`
+	// ContinueOnFailure runs the remaining steps after one fails
+	ContinueOnFailure bool `yaml:"continue_on_failure" json:"continue_on_failure"`
+}
+
+// StepResult is the outcome of one script step
+type StepResult struct {
+	Name       string                 `json:"name"`
+	Method     string                 `json:"method"`
+	Status     string                 `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Result     json.RawMessage        `json:"result,omitempty"`
+	Captured   map[string]interface{} `json:"captured,omitempty"`
+	DurationMs float64                `json:"duration_ms"`
+}
+
+// scriptPollInterval is how often a waiting step re-issues its call
+const scriptPollInterval = time.Second
+
+// scriptVarPattern matches a ${name} variable reference
+var scriptVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadScript reads a script from a YAML or JSON file
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var script Script
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &script)
+	default:
+		err = yaml.Unmarshal(data, &script)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse script: %w", err)
+	}
+	if len(script.Steps) == 0 {
+		return nil, fmt.Errorf("script %s has no steps", path)
+	}
+	for i, step := range script.Steps {
+		if step.Method == "" {
+			return nil, fmt.Errorf("script step %d has no method", i)
+		}
+		if script.Steps[i].Name == "" {
+			script.Steps[i].Name = fmt.Sprintf("%d", i+1)
+		}
+	}
+
+	return &script, nil
+}
+
+// RunScript executes the steps of script in order, capturing variables from
+// each result for later steps. vars seed and override the script's own vars.
+// onStep is called with each result as soon as its step finishes.
+func (r *RPCClient) RunScript(ctx context.Context, script *Script, vars map[string]interface{}, onStep func(StepResult)) []StepResult {
+	scope := make(map[string]interface{}, len(script.Vars)+len(vars))
+	for k, v := range script.Vars {
+		scope[k] = v
+	}
+	for k, v := range vars {
+		scope[k] = v
+	}
+
+	ctx, span := startSpan(ctx, "script", attribute.Int("script.steps", len(script.Steps)))
+	defer span.End()
+
+	var results []StepResult
+	failed := false
+	for _, step := range script.Steps {
+		result := StepResult{Name: step.Name, Method: step.Method}
+		if failed && !script.ContinueOnFailure {
+			result.Status = StatusSkipped
+		} else {
+			stepCtx, stepSpan := startSpan(ctx, "step "+step.Name, attribute.String("rpc.method", step.Method))
+			result = r.runStep(stepCtx, step, scope)
+			stepSpan.SetAttributes(attribute.String("script.status", result.Status))
+			stepSpan.End()
+			failed = failed || result.Status != StatusPass
+		}
+
+		results = append(results, result)
+		if onStep != nil {
+			onStep(result)
+		}
+	}
+
+	return results
+}
+
+// runStep issues one step and applies its expectations and captures
+func (r *RPCClient) runStep(ctx context.Context, step ScriptStep, scope map[string]interface{}) StepResult {
+	result := StepResult{Name: step.Name, Method: step.Method}
+	fail := func(status, format string, args ...interface{}) StepResult {
+		result.Status = status
+		result.Detail = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	params := make([]interface{}, len(step.Params))
+	for i, p := range step.Params {
+		v, err := substituteVars(p, scope)
+		if err != nil {
+			return fail(StatusError, "param %d: %v", i, err)
+		}
+		params[i] = v
+	}
+
+	start := time.Now()
+	raw, err := r.CallRaw(ctx, step.Method, params...)
+	wait := time.Duration(step.Wait)
+	for err == nil && wait > 0 && isNullResult(raw) && time.Since(start) < wait {
+		select {
+		case <-ctx.Done():
+			return fail(StatusError, "gave up waiting: %v", ctx.Err())
+		case <-time.After(scriptPollInterval):
+		}
+		raw, err = r.CallRaw(ctx, step.Method, params...)
+	}
+	result.DurationMs = durationMillis(time.Since(start))
+	result.Result = raw
+
+	switch {
+	case step.ExpectError && err == nil:
+		return fail(StatusFail, "expected an error, got a result")
+	case step.ExpectError:
+		result.Status = StatusPass
+		result.Detail = Redact(err.Error())
+		return result
+	case err != nil:
+		return fail(StatusError, "%s", Redact(err.Error()))
+	}
+
+	// Numbers stay json.Number so they substitute into later steps verbatim
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return fail(StatusError, "failed to decode result: %v", err)
+	}
+
+	for path, want := range step.Expect {
+		expected, err := substituteVars(want, scope)
+		if err != nil {
+			return fail(StatusError, "expect %s: %v", path, err)
+		}
+		got, err := lookupPath(value, path)
+		if err != nil {
+			return fail(StatusFail, "expect %s: %v", path, err)
+		}
+		if !scriptValuesEqual(got, expected) {
+			return fail(StatusFail, "%s is %v, want %v", path, got, expected)
+		}
+	}
+
+	result.Captured = make(map[string]interface{}, len(step.Capture))
+	for name, path := range step.Capture {
+		got, err := lookupPath(value, path)
+		if err != nil {
+			return fail(StatusFail, "capture %s: %v", name, err)
+		}
+		scope[name] = got
+		result.Captured[name] = got
+	}
+
+	result.Status = StatusPass
+	return result
+}
+
+// substituteVars replaces ${name} references in strings, recursing into
+// lists and maps
+func substituteVars(v interface{}, scope map[string]interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if m := scriptVarPattern.FindStringSubmatch(val); m != nil && m[0] == val {
+			got, ok := scope[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable %s", m[1])
+			}
+			return got, nil
+		}
+		var missing string
+		out := scriptVarPattern.ReplaceAllStringFunc(val, func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			got, ok := scope[name]
+			if !ok {
+				missing = name
+				return ref
+			}
+			return fmt.Sprint(got)
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("undefined variable %s", missing)
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			sub, err := substituteVars(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = sub
+		}
+		return out, nil
+
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			sub, err := substituteVars(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = sub
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// lookupPath resolves a path such as $.logs[0].topics into a decoded value
+func lookupPath(v interface{}, path string) (interface{}, error) {
+	rest := strings.TrimPrefix(path, "$")
+	cur := v
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: not an object", path)
+			}
+			if cur, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("%s: no field %q", path, key)
+			}
+			rest = rest[end+1:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unterminated index", path)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid index %q", path, rest[1:end])
+			}
+			list, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: not a list", path)
+			}
+			if i < 0 || i >= len(list) {
+				return nil, fmt.Errorf("%s: index %d out of range", path, i)
+			}
+			cur = list[i]
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("malformed path %q", path)
+		}
+	}
+
+	return cur, nil
+}
+
+// scriptValuesEqual compares a result value with an expected one, ignoring
+// case so hex quantities and addresses match however they are written
+func scriptValuesEqual(got, want interface{}) bool {
+	return strings.EqualFold(fmt.Sprint(got), fmt.Sprint(want))
+}
+
+// isNullResult reports whether a raw result is JSON null
+func isNullResult(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// redactedValue replaces secrets in logs and reports
+const redactedValue = "[REDACTED]"
+
+// secretQueryParams are URL query parameters that commonly carry API keys
+var secretQueryParams = []string{"key", "apikey", "api_key", "api-key", "token", "access_token", "secret"}
+
+// secretHeaders are request headers that carry credentials whatever their
+// prefix; headers ending in -Api-Key or -Token are taken as well
+var secretHeaders = []string{"authorization", "proxy-authorization", "cookie", "api-key", "token"}
+
+// minSecretLength avoids redacting short values that would mangle unrelated text
+const minSecretLength = 6
+
+// minPathKeyLength is the shortest path segment taken for an API key, as in
+// Infura's /v3/KEY and Alchemy's /v2/KEY
+const minPathKeyLength = 20
+
+var secrets struct {
+	sync.RWMutex
+	values []string
+}
+
+// RegisterSecret marks a value that must never appear in logs or reports
+func RegisterSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range secrets.values {
+		if v == value {
+			return
+		}
+	}
+	secrets.values = append(secrets.values, value)
+}
+
+// Redact replaces every registered secret in s
+func Redact(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+	return s
+}
+
+// RedactURL hides credentials embedded in an endpoint URL: the userinfo
+// password, API-key query parameters, API keys in the path, and any
+// registered secret
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Redact(raw)
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			for _, secret := range secretQueryParams {
+				if strings.EqualFold(name, secret) {
+					query.Set(name, "redacted")
+				}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if isPathKey(segment) {
+			segments[i] = "redacted"
+		}
+	}
+	u.Path, u.RawPath = strings.Join(segments, "/"), ""
+
+	return Redact(u.String())
+}
+
+// isPathKey reports whether a URL path segment looks like an API key: long,
+// and a mix of letters and digits with nothing but - and _ between them
+func isPathKey(segment string) bool {
+	if len(segment) < minPathKeyLength {
+		return false
+	}
+	var letters, digits bool
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letters = true
+		case c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return letters && digits
+}
+
+// registerURLSecrets registers the credentials embedded in an endpoint URL
+func registerURLSecrets(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return
+	}
+
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			RegisterSecret(password)
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if isPathKey(segment) {
+			RegisterSecret(segment)
+		}
+	}
+	for name, values := range u.Query() {
+		for _, secret := range secretQueryParams {
+			if strings.EqualFold(name, secret) {
+				for _, v := range values {
+					RegisterSecret(v)
+				}
+			}
+		}
+	}
+}
+
+// isSecretHeader reports whether a request header carries credentials
+func isSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretHeaders {
+		if name == secret {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "-api-key") || strings.HasSuffix(name, "-token")
+}
+
+// registerHeaderSecrets registers the values of credential headers. An
+// Authorization credential is also registered without its scheme, so a
+// bearer token is hidden wherever it appears on its own.
+func registerHeaderSecrets(headers http.Header) {
+	for name, values := range headers {
+		if !isSecretHeader(name) {
+			continue
+		}
+		for _, v := range values {
+			RegisterSecret(v)
+			if _, credential, ok := strings.Cut(v, " "); ok && strings.HasSuffix(strings.ToLower(name), "authorization") {
+				RegisterSecret(strings.TrimSpace(credential))
+			}
+		}
+	}
+}
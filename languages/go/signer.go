@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions for one account. Implementations may hold the
+// key locally or ask a device or service to sign.
+type Signer interface {
+	// Address is the account that signs
+	Address() common.Address
+	// SignTx returns tx signed for chainID
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// keySigner signs with a private key held in memory
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a signer for a local private key
+func NewKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// Address implements Signer
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return signed, nil
+}
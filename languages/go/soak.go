@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SoakOptions configure a soak run
+type SoakOptions struct {
+	// Interval is the time between rounds; each idle account sends one
+	// transfer per round
+	Interval time.Duration
+	// Duration ends the run; zero runs until ctx is done
+	Duration time.Duration
+	// RotateEvery rotates the accounts on a schedule; zero disables it
+	RotateEvery time.Duration
+	// Rotate requests a rotation whenever it receives, e.g. on SIGHUP
+	Rotate <-chan os.Signal
+	// WaitTimeout bounds draining in-flight transfers and funding a new set,
+	// and is how long a transfer may stay in flight before it is replaced
+	WaitTimeout time.Duration
+	BatchSize   int
+	// FreshAccounts returns n new accounts to rotate to. It must store their
+	// keys before returning: funds are moved to them straight after.
+	FreshAccounts func(n int) ([]HDAccount, error)
+	// Source names the run on the event bus
+	Source string
+	Events *EventBus
+}
+
+// SoakResult summarizes a soak run
+type SoakResult struct {
+	Sent      int
+	Confirmed int
+	Reverted  int
+	Failed    int
+	// Replaced counts stuck transfers sent again with higher fees
+	Replaced int
+	// Dropped counts stuck transfers whose nonce something else used
+	Dropped   int
+	Rotations int
+	// Accounts is the set in use when the run ended
+	Accounts []HDAccount
+}
+
+// soakRun is the state of one soak run. Rounds, receipt polling, and
+// rotations all happen on the run's goroutine, so none of it is locked.
+type soakRun struct {
+	client *RPCClient
+	opts   SoakOptions
+	result SoakResult
+
+	accounts []HDAccount
+	// nonces is each account's next nonce, fetched on first use
+	nonces map[common.Address]uint64
+	// inflight is each account's unconfirmed transfer; an account sends no
+	// more until it is confirmed
+	inflight map[common.Address]*types.Transaction
+	// sent is when each in-flight transfer was last sent
+	sent map[common.Address]time.Time
+	// pendingRotation is the reason for a rotation that waits on transfers
+	// still in flight
+	pendingRotation string
+}
+
+// Soak keeps every account sending a zero-value transfer to itself, one in
+// flight per account, until ctx is done or opts.Duration passes. On rotation
+// it stops sending, drains the in-flight transfers, moves each account's
+// balance to a fresh account, and carries on with the fresh set, so a long
+// run can retire keys that may be exposed without restarting.
+func (r *RPCClient) Soak(ctx context.Context, accounts []HDAccount, opts SoakOptions) SoakResult {
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+	s := &soakRun{
+		client:   r,
+		opts:     opts,
+		accounts: accounts,
+		nonces:   make(map[common.Address]uint64),
+		inflight: make(map[common.Address]*types.Transaction),
+		sent:     make(map[common.Address]time.Time),
+	}
+
+	rounds := time.NewTicker(opts.Interval)
+	defer rounds.Stop()
+	var scheduled <-chan time.Time
+	if opts.RotateEvery > 0 {
+		ticker := time.NewTicker(opts.RotateEvery)
+		defer ticker.Stop()
+		scheduled = ticker.C
+	}
+
+	opts.Events.Publish(Event{Kind: EventRunStarted, Source: opts.Source, Endpoint: r.DisplayURL()})
+	for running := true; running; {
+		select {
+		case <-ctx.Done():
+			running = false
+		case <-scheduled:
+			s.rotate("scheduled")
+		case <-opts.Rotate:
+			s.rotate("requested")
+		case <-rounds.C:
+			roundCtx, cancel := context.WithTimeout(ctx, opts.WaitTimeout)
+			s.poll(roundCtx)
+			if s.pendingRotation != "" && len(s.inflight) == 0 {
+				s.rotate(s.pendingRotation)
+			}
+			s.round(roundCtx)
+			cancel()
+		}
+	}
+
+	// Let what is in flight land so the summary counts it
+	s.drain()
+	s.result.Accounts = s.accounts
+	opts.Events.Publish(Event{
+		Kind:     EventRunFinished,
+		Source:   opts.Source,
+		Endpoint: r.DisplayURL(),
+		Message:  fmt.Sprintf("%d sent, %d confirmed, %d rotations", s.result.Sent, s.result.Confirmed, s.result.Rotations),
+	})
+	return s.result
+}
+
+// round sends a transfer from every account with nothing in flight
+func (s *soakRun) round(ctx context.Context) {
+	var idle []HDAccount
+	for _, account := range s.accounts {
+		if s.inflight[account.Address] == nil {
+			idle = append(idle, account)
+		}
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	// Price the round once, from its first account
+	template, chainID, err := s.client.withKey(idle[0].PrivateKey).PrepareTransaction(ctx, TxRequest{To: &idle[0].Address, Gas: transferGas})
+	if err != nil {
+		s.client.logger.Warn("failed to price soak round", "error", err)
+		return
+	}
+
+	plan := make([]PlannedTransfer, 0, len(idle))
+	for _, account := range idle {
+		nonce, ok := s.nonces[account.Address]
+		if !ok {
+			if nonce, err = s.client.client.PendingNonceAt(ctx, account.Address); err != nil {
+				s.client.logger.Warn("failed to get nonce", "address", account.Address.Hex(), "error", err)
+				continue
+			}
+		}
+		unsigned := repriceTransfer(template, chainID, nonce, account.Address, new(big.Int))
+		tx, err := NewKeySigner(account.PrivateKey).SignTx(ctx, unsigned, chainID)
+		if err != nil {
+			s.client.logger.Warn("failed to sign soak transfer", "address", account.Address.Hex(), "error", err)
+			continue
+		}
+		plan = append(plan, PlannedTransfer{Path: account.Path, From: account.Address, To: account.Address, Tx: tx})
+	}
+
+	if err := s.client.BroadcastBatch(ctx, plan, s.opts.BatchSize); err != nil {
+		s.client.logger.Warn("failed to submit soak round", "error", err)
+		s.result.Failed += len(plan)
+		return
+	}
+	for _, t := range plan {
+		if t.Err != nil {
+			s.result.Failed++
+			// The nonce may have moved; fetch it again next round
+			delete(s.nonces, t.From)
+			s.client.logger.Warn("soak transfer failed", "address", t.From.Hex(), "error", t.Err)
+			continue
+		}
+		s.result.Sent++
+		s.nonces[t.From] = t.Tx.Nonce() + 1
+		s.inflight[t.From] = t.Tx
+		s.sent[t.From] = time.Now()
+	}
+}
+
+// poll collects the receipts of in-flight transfers that have landed
+func (s *soakRun) poll(ctx context.Context) {
+	for from, tx := range s.inflight {
+		receipt, err := s.client.client.TransactionReceipt(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			if time.Since(s.sent[from]) >= s.opts.WaitTimeout {
+				s.unstick(ctx, from, tx)
+			}
+			continue
+		}
+		if err != nil {
+			s.client.logger.Warn("failed to get soak receipt", "tx_hash", tx.Hash().Hex(), "error", err)
+			continue
+		}
+		s.settle(from, receipt)
+	}
+}
+
+// settle records a landed transfer and frees its account
+func (s *soakRun) settle(from common.Address, receipt *types.Receipt) {
+	delete(s.inflight, from)
+	delete(s.sent, from)
+	s.result.Confirmed++
+	if receipt.Status == 0 {
+		s.result.Reverted++
+	}
+}
+
+// replacementFeeBump is the fee increase, in percent, that lets a transfer
+// replace a stuck one with the same nonce; geth requires at least 10
+const replacementFeeBump = 20
+
+// unstick handles a transfer in flight for WaitTimeout with no receipt. If the
+// account's nonce has moved past it, the transfer landed under another hash
+// or was replaced, and the account is freed. Otherwise the transfer was
+// dropped or priced out, so it is sent again at the same nonce with fees
+// raised past both its own and the current price.
+func (s *soakRun) unstick(ctx context.Context, from common.Address, tx *types.Transaction) {
+	nonce, err := s.client.client.NonceAt(ctx, from, nil)
+	if err != nil {
+		s.client.logger.Warn("failed to get nonce", "address", from.Hex(), "error", err)
+		return
+	}
+	if nonce > tx.Nonce() {
+		s.client.logger.Warn("stuck soak transfer superseded", "address", from.Hex(), "tx_hash", tx.Hash().Hex(), "nonce", tx.Nonce())
+		delete(s.inflight, from)
+		delete(s.sent, from)
+		s.nonces[from] = nonce
+		s.result.Dropped++
+		return
+	}
+
+	var account *HDAccount
+	for i := range s.accounts {
+		if s.accounts[i].Address == from {
+			account = &s.accounts[i]
+		}
+	}
+	if account == nil {
+		return
+	}
+	current, err := s.client.withKey(account.PrivateKey).BuildTransaction(ctx, TxRequest{To: &from, Gas: transferGas})
+	if err != nil {
+		s.client.logger.Warn("failed to price soak replacement", "address", from.Hex(), "error", err)
+		return
+	}
+	var replacement *types.Transaction
+	if tx.Type() == types.DynamicFeeTxType {
+		replacement = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   tx.ChainId(),
+			Nonce:     tx.Nonce(),
+			GasTipCap: bumpFee(tx.GasTipCap(), current.GasTipCap()),
+			GasFeeCap: bumpFee(tx.GasFeeCap(), current.GasFeeCap()),
+			Gas:       tx.Gas(),
+			To:        tx.To(),
+			Value:     tx.Value(),
+		})
+	} else {
+		replacement = types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: bumpFee(tx.GasPrice(), current.GasPrice()),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+		})
+	}
+	if replacement, err = NewKeySigner(account.PrivateKey).SignTx(ctx, replacement, current.ChainId()); err != nil {
+		s.client.logger.Warn("failed to sign soak replacement", "address", from.Hex(), "error", err)
+		return
+	}
+	if err := s.client.client.SendTransaction(ctx, replacement); err != nil {
+		s.client.logger.Warn("failed to replace stuck soak transfer", "address", from.Hex(), "tx_hash", tx.Hash().Hex(), "error", err)
+		return
+	}
+	s.client.logger.Warn("replaced stuck soak transfer", "address", from.Hex(), "old_hash", tx.Hash().Hex(), "tx_hash", replacement.Hash().Hex(), "nonce", tx.Nonce())
+	s.inflight[from] = replacement
+	s.sent[from] = time.Now()
+	s.result.Replaced++
+}
+
+// bumpFee raises fee by replacementFeeBump percent, or to current if higher
+func bumpFee(fee, current *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+replacementFeeBump))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	if current.Cmp(bumped) > 0 {
+		return new(big.Int).Set(current)
+	}
+	return bumped
+}
+
+// drain waits up to WaitTimeout for every in-flight transfer. Transfers that
+// do not land stay in flight.
+func (s *soakRun) drain() {
+	if len(s.inflight) == 0 {
+		return
+	}
+	s.client.logger.Info("draining in-flight transfers", "transfers", len(s.inflight))
+
+	// Draining runs after the run's context is done, so it gets its own
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.WaitTimeout)
+	defer cancel()
+	for from, tx := range s.inflight {
+		receipt, err := s.client.WaitForReceipt(ctx, tx.Hash(), time.Second, func(ReceiptStatus) {})
+		if err != nil {
+			s.client.logger.Warn("in-flight transfer did not land", "tx_hash", tx.Hash().Hex(), "error", err)
+			continue
+		}
+		s.settle(from, receipt)
+	}
+}
+
+// rotate replaces the accounts with a fresh set. Each old account's balance
+// is swept to its replacement; an account whose sweep does not land is kept,
+// so a failed rotation never strands funds or stops the run.
+func (s *soakRun) rotate(reason string) {
+	s.client.logger.Info("rotating accounts", "reason", reason, "accounts", len(s.accounts))
+	s.drain()
+	if len(s.inflight) > 0 {
+		// Rounds replace or clear stuck transfers, and the rotation runs
+		// once none are left
+		s.client.logger.Warn("rotation postponed: transfers are still in flight", "transfers", len(s.inflight))
+		s.pendingRotation = reason
+		return
+	}
+	s.pendingRotation = ""
+
+	fresh, err := s.opts.FreshAccounts(len(s.accounts))
+	if err != nil {
+		s.client.logger.Error("rotation failed: no fresh accounts", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.WaitTimeout)
+	defer cancel()
+
+	// swept marks old accounts whose balance reached their replacement
+	swept := make(map[common.Address]bool, len(s.accounts))
+	var plan []PlannedTransfer
+	for i, old := range s.accounts {
+		transfers, err := s.client.PlanSweep(ctx, []HDAccount{old}, fresh[i].Address)
+		if err != nil {
+			s.client.logger.Warn("failed to plan rotation sweep", "address", old.Address.Hex(), "error", err)
+			continue
+		}
+		if len(transfers) == 0 {
+			// Nothing worth moving; the fresh account starts empty either way
+			swept[old.Address] = true
+		}
+		plan = append(plan, transfers...)
+	}
+	if err := SignPlan(ctx, plan); err != nil {
+		s.client.logger.Error("rotation failed: sweep not signed", "error", err)
+		return
+	}
+	if err := s.client.BroadcastBatch(ctx, plan, s.opts.BatchSize); err != nil {
+		s.client.logger.Error("rotation failed: sweep not submitted", "error", err)
+		return
+	}
+	for _, t := range plan {
+		if t.Err != nil {
+			s.client.logger.Warn("rotation sweep failed", "address", t.From.Hex(), "error", t.Err)
+			continue
+		}
+		receipt, err := s.client.WaitForReceipt(ctx, t.Tx.Hash(), time.Second, func(ReceiptStatus) {})
+		if err != nil || receipt.Status == 0 {
+			s.client.logger.Warn("rotation sweep did not land", "address", t.From.Hex(), "tx_hash", t.Tx.Hash().Hex(), "error", err)
+			continue
+		}
+		swept[t.From] = true
+	}
+
+	replaced := 0
+	next := make([]HDAccount, len(s.accounts))
+	for i, old := range s.accounts {
+		next[i] = old
+		if swept[old.Address] {
+			next[i] = fresh[i]
+			delete(s.nonces, old.Address)
+			replaced++
+		}
+	}
+	s.accounts = next
+	s.result.Rotations++
+	s.client.logger.Info("accounts rotated", "replaced", replaced, "kept", len(next)-replaced)
+	s.opts.Events.Publish(Event{
+		Kind:     EventAccountsRotated,
+		Source:   s.opts.Source,
+		Endpoint: s.client.DisplayURL(),
+		Message:  fmt.Sprintf("%s rotation: %d of %d accounts replaced", reason, replaced, len(next)),
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric formats accepted by --statsd-format
+const (
+	StatsDFormatPlain = "statsd"
+	StatsDFormatDog   = "dogstatsd"
+)
+
+// metricsSink is set when --statsd is given; clients created afterwards
+// report every call to it
+var metricsSink *StatsD
+
+// StatsD emits metrics over UDP. In DogStatsD format the method, host, and
+// error code are tags; in plain StatsD format they are folded into the name.
+type StatsD struct {
+	conn   net.Conn
+	prefix string
+	tagged bool
+}
+
+// NewStatsD creates an emitter for the agent at addr (host:port) that names
+// metrics under prefix
+func NewStatsD(addr, prefix, format string) (*StatsD, error) {
+	var tagged bool
+	switch format {
+	case StatsDFormatDog:
+		tagged = true
+	case StatsDFormatPlain:
+	default:
+		return nil, fmt.Errorf("unknown StatsD format %q (want %s or %s)", format, StatsDFormatPlain, StatsDFormatDog)
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open StatsD socket: %w", err)
+	}
+	prefix = strings.TrimSuffix(prefix, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+	return &StatsD{conn: conn, prefix: prefix, tagged: tagged}, nil
+}
+
+// statsdTag is one name:value dimension of a metric
+type statsdTag struct {
+	name, value string
+}
+
+// line formats one metric, e.g. "rpc.duration:12.5|ms|#method:eth_call"
+func (s *StatsD) line(name, value, kind string, tags ...statsdTag) string {
+	if s.tagged {
+		parts := make([]string, len(tags))
+		for i, t := range tags {
+			parts[i] = t.name + ":" + t.value
+		}
+		return fmt.Sprintf("%s%s:%s|%s|#%s", s.prefix, name, value, kind, strings.Join(parts, ","))
+	}
+
+	segments := []string{name}
+	for _, t := range tags {
+		segments = append(segments, statsdSegment(t.value))
+	}
+	return fmt.Sprintf("%s%s:%s|%s", s.prefix, strings.Join(segments, "."), value, kind)
+}
+
+// statsdSegment makes a tag value safe to use as one segment of a plain
+// StatsD metric name
+func statsdSegment(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, v)
+}
+
+// RecordCall reports one JSON-RPC request: a request count, its duration, and
+// an error count when errorCode is set. method is "batch" for batches.
+func (s *StatsD) RecordCall(method, host string, duration time.Duration, errorCode string) {
+	tags := []statsdTag{{"method", method}, {"host", host}}
+	lines := []string{
+		s.line("rpc.requests", "1", "c", tags...),
+		s.line("rpc.duration", strconv.FormatFloat(durationMillis(duration), 'f', -1, 64), "ms", tags...),
+	}
+	if errorCode != "" {
+		lines = append(lines, s.line("rpc.errors", "1", "c", append(tags, statsdTag{"code", errorCode})...))
+	}
+	// StatsD is fire-and-forget; a missing agent must not fail the run
+	s.conn.Write([]byte(strings.Join(lines, "\n")))
+}
+
+// statsdTransport reports every JSON-RPC request to metricsSink
+type statsdTransport struct {
+	base http.RoundTripper
+	sink *StatsD
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *statsdTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := "unknown"
+	switch methods := requestMethods(req); {
+	case len(methods) > 1:
+		method = "batch"
+	case len(methods) == 1:
+		method = methods[0]
+	}
+	host := req.URL.Hostname()
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.sink.RecordCall(method, host, elapsed, "transport")
+		return nil, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	errorCode := ""
+	if readErr != nil {
+		errorCode = "transport"
+	} else if code, _, ok := responseError(body); ok {
+		errorCode = strconv.Itoa(code)
+	} else if resp.StatusCode >= 400 {
+		errorCode = "http_" + strconv.Itoa(resp.StatusCode)
+	}
+	t.sink.RecordCall(method, host, elapsed, errorCode)
+	return resp, nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Sources a WatchEvent can arrive through
+const (
+	SourceSubscription = "subscription"
+	SourcePoll         = "poll"
+)
+
+// errNoSubscription means neither the endpoint nor a WebSocket URL can subscribe
+var errNoSubscription = errors.New("no websocket endpoint available")
+
+// WatchEvent is one head, log, or pending transaction seen by a watcher
+type WatchEvent struct {
+	Source      string
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	TxCount     int
+	LogIndex    uint
+	Address     common.Address
+	Topic0      common.Hash
+	Received    time.Time
+	// Latency is how long after the block timestamp the event arrived; zero
+	// for pending transactions, which have no block yet
+	Latency time.Duration
+}
+
+// WatchFunc receives events; returning an error stops the watcher
+type WatchFunc func(WatchEvent) error
+
+// subscriptionClient returns a client able to subscribe: the main connection
+// when it already speaks WebSocket, or a new connection to wsURL
+func (r *RPCClient) subscriptionClient(ctx context.Context, wsURL string) (*rpc.Client, func(), error) {
+	if strings.HasPrefix(r.rpcURL, "ws://") || strings.HasPrefix(r.rpcURL, "wss://") {
+		return r.client.Client(), func() {}, nil
+	}
+	if wsURL == "" {
+		return nil, nil, errNoSubscription
+	}
+
+	client, err := rpc.DialOptions(ctx, wsURL, rpc.WithHeaders(r.headers))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial websocket: %w", err)
+	}
+	return client, client.Close, nil
+}
+
+// latencySince returns how long after the block timestamp t the event was received
+func latencySince(received time.Time, timestamp uint64) time.Duration {
+	return received.Sub(time.Unix(int64(timestamp), 0))
+}
+
+// WatchHeads streams new block headers, subscribing over WebSocket when possible
+// and polling every interval otherwise or after the subscription fails
+func (r *RPCClient) WatchHeads(ctx context.Context, wsURL string, interval time.Duration, emit WatchFunc) error {
+	var next uint64
+
+	if client, closeClient, err := r.subscriptionClient(ctx, wsURL); err == nil {
+		defer closeClient()
+		// Heads are decoded as summaries so the hash is the endpoint's
+		heads := make(chan *blockSummary)
+		sub, err := client.EthSubscribe(ctx, heads, "newHeads")
+		if err == nil {
+			defer sub.Unsubscribe()
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-sub.Err():
+					break loop
+				case head := <-heads:
+					if err := r.emitHead(ctx, head, SourceSubscription, emit); err != nil {
+						return err
+					}
+					next = uint64(head.Number) + 1
+				}
+			}
+		}
+	}
+
+	return r.pollHeads(ctx, next, interval, emit)
+}
+
+// pollHeads emits every block from next (or the current head when zero) onwards
+func (r *RPCClient) pollHeads(ctx context.Context, next uint64, interval time.Duration, emit WatchFunc) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		head, err := r.client.BlockNumber(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get block number: %w", err)
+		}
+		if next == 0 {
+			next = head
+		}
+		for ; next <= head; next++ {
+			head, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(next))
+			if err != nil {
+				return fmt.Errorf("failed to get block %d: %w", next, err)
+			}
+			if head == nil {
+				return fmt.Errorf("block %d not found", next)
+			}
+			if err := r.emitHead(ctx, head, SourcePoll, emit); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// emitHead converts a head into an event, looking up its transaction count
+func (r *RPCClient) emitHead(ctx context.Context, head *blockSummary, source string, emit WatchFunc) error {
+	received := time.Now()
+	count, err := r.client.TransactionCount(ctx, head.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction count: %w", err)
+	}
+
+	return emit(WatchEvent{
+		Source:      source,
+		BlockNumber: uint64(head.Number),
+		BlockHash:   head.Hash,
+		TxCount:     int(count),
+		Received:    received,
+		Latency:     latencySince(received, uint64(head.Timestamp)),
+	})
+}
+
+// WatchLogs streams logs matching query, subscribing when possible and
+// otherwise polling eth_getLogs over each new block range
+func (r *RPCClient) WatchLogs(ctx context.Context, wsURL string, interval time.Duration, query ethereum.FilterQuery, emit WatchFunc) error {
+	times := newBlockTimeCache(r)
+	var next uint64
+
+	if client, closeClient, err := r.subscriptionClient(ctx, wsURL); err == nil {
+		defer closeClient()
+		logs := make(chan types.Log)
+		sub, err := ethclient.NewClient(client).SubscribeFilterLogs(ctx, query, logs)
+		if err == nil {
+			defer sub.Unsubscribe()
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-sub.Err():
+					break loop
+				case l := <-logs:
+					if err := r.emitLog(ctx, times, l, SourceSubscription, emit); err != nil {
+						return err
+					}
+					next = l.BlockNumber + 1
+				}
+			}
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		head, err := r.client.BlockNumber(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to get block number: %w", err)
+		}
+		if next == 0 {
+			next = head
+		}
+		if next <= head {
+			q := query
+			q.FromBlock = new(big.Int).SetUint64(next)
+			q.ToBlock = new(big.Int).SetUint64(head)
+			logs, err := r.client.FilterLogs(ctx, q)
+			if err != nil {
+				return fmt.Errorf("failed to get logs %d-%d: %w", next, head, err)
+			}
+			for _, l := range logs {
+				if err := r.emitLog(ctx, times, l, SourcePoll, emit); err != nil {
+					return err
+				}
+			}
+			next = head + 1
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// emitLog converts a log into an event
+func (r *RPCClient) emitLog(ctx context.Context, times *blockTimeCache, l types.Log, source string, emit WatchFunc) error {
+	received := time.Now()
+	timestamp, err := times.get(ctx, l.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	event := WatchEvent{
+		Source:      source,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TxHash,
+		LogIndex:    l.Index,
+		Address:     l.Address,
+		Received:    received,
+		Latency:     latencySince(received, timestamp),
+	}
+	if len(l.Topics) > 0 {
+		event.Topic0 = l.Topics[0]
+	}
+	return emit(event)
+}
+
+// WatchPending streams pending transaction hashes, subscribing when possible
+// and otherwise polling a pending-transaction filter
+func (r *RPCClient) WatchPending(ctx context.Context, wsURL string, interval time.Duration, emit WatchFunc) error {
+	if client, closeClient, err := r.subscriptionClient(ctx, wsURL); err == nil {
+		defer closeClient()
+		hashes := make(chan common.Hash)
+		sub, err := gethclient.New(client).SubscribePendingTransactions(ctx, hashes)
+		if err == nil {
+			defer sub.Unsubscribe()
+		loop:
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-sub.Err():
+					break loop
+				case hash := <-hashes:
+					if err := emit(WatchEvent{Source: SourceSubscription, TxHash: hash, Received: time.Now()}); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	raw, err := r.CallRaw(ctx, "eth_newPendingTransactionFilter")
+	if err != nil {
+		return err
+	}
+	var filterID string
+	if err := json.Unmarshal(raw, &filterID); err != nil {
+		return fmt.Errorf("invalid filter id %s", raw)
+	}
+	defer r.CallRaw(context.Background(), "eth_uninstallFilter", filterID)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		raw, err := r.CallRaw(ctx, "eth_getFilterChanges", filterID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var hashes []common.Hash
+		if err := json.Unmarshal(raw, &hashes); err != nil {
+			return fmt.Errorf("failed to decode filter changes: %w", err)
+		}
+		for _, hash := range hashes {
+			if err := emit(WatchEvent{Source: SourcePoll, TxHash: hash, Received: time.Now()}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// blockTimeCacheSize bounds the timestamps kept while watching logs
+const blockTimeCacheSize = 256
+
+// blockTimeCache remembers recent block timestamps so each log does not
+// cost a header lookup
+type blockTimeCache struct {
+	client *RPCClient
+	times  map[common.Hash]uint64
+}
+
+func newBlockTimeCache(client *RPCClient) *blockTimeCache {
+	return &blockTimeCache{client: client, times: make(map[common.Hash]uint64)}
+}
+
+// get returns the timestamp of the block with the given hash
+func (c *blockTimeCache) get(ctx context.Context, hash common.Hash) (uint64, error) {
+	if t, ok := c.times[hash]; ok {
+		return t, nil
+	}
+
+	header, err := c.client.client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get header %s: %w", hash.Hex(), err)
+	}
+	if len(c.times) >= blockTimeCacheSize {
+		c.times = make(map[common.Hash]uint64)
+	}
+	c.times[hash] = header.Time
+	return header.Time, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockTags are the named block tags defined by the execution-apis specification
+var BlockTags = []string{"earliest", "finalized", "safe", "latest", "pending"}
+
+// invalidBlockTags must be rejected by every endpoint
+var invalidBlockTags = []string{"newest", "LATEST", "0xZZ", ""}
+
+// TagSupport describes how an endpoint resolves one block tag
+type TagSupport struct {
+	Tag       string
+	Supported bool
+	Number    uint64
+	Err       string
+}
+
+// TagCheck is the endpoint's tag support matrix and any ordering violations
+type TagCheck struct {
+	Tags     []TagSupport
+	Problems []string
+}
+
+// Passed reports whether every supported tag resolved sensibly and every invalid tag failed cleanly
+func (c TagCheck) Passed() bool {
+	return len(c.Problems) == 0
+}
+
+// resolveTag fetches the block for a tag and returns its number, or nil if the endpoint returned null
+func (r *RPCClient) resolveTag(ctx context.Context, tag string) (*uint64, error) {
+	raw, err := r.CallRaw(ctx, "eth_getBlockByNumber", tag, false)
+	if err != nil {
+		return nil, err
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+
+	var block struct {
+		Number *hexutil.Uint64 `json:"number"`
+	}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, fmt.Errorf("failed to decode block: %w", err)
+	}
+	if block.Number == nil {
+		return nil, nil
+	}
+
+	n := uint64(*block.Number)
+	return &n, nil
+}
+
+// CheckBlockTags resolves every block tag and verifies finalized <= safe <= latest <= pending,
+// that earliest resolves to genesis, and that invalid tags fail with a JSON-RPC error
+func (r *RPCClient) CheckBlockTags(ctx context.Context) (*TagCheck, error) {
+	check := &TagCheck{}
+	resolved := make(map[string]uint64)
+
+	for _, tag := range BlockTags {
+		support := TagSupport{Tag: tag}
+
+		number, err := r.resolveTag(ctx, tag)
+		switch {
+		case err != nil:
+			var rpcErr rpc.Error
+			if !errors.As(err, &rpcErr) {
+				return check, err
+			}
+			support.Err = rpcErr.Error()
+		case number == nil:
+			support.Err = "null block"
+		default:
+			support.Supported = true
+			support.Number = *number
+			resolved[tag] = *number
+		}
+
+		check.Tags = append(check.Tags, support)
+	}
+
+	if _, ok := resolved["latest"]; !ok {
+		check.Problems = append(check.Problems, "latest tag is not supported")
+	}
+	if n, ok := resolved["earliest"]; ok && n != 0 {
+		check.Problems = append(check.Problems, fmt.Sprintf("earliest resolved to %d instead of genesis", n))
+	}
+
+	order := []string{"finalized", "safe", "latest", "pending"}
+	for i := 0; i < len(order); i++ {
+		lower, ok := resolved[order[i]]
+		if !ok {
+			continue
+		}
+		for j := i + 1; j < len(order); j++ {
+			upper, ok := resolved[order[j]]
+			if !ok {
+				continue
+			}
+			if lower > upper {
+				check.Problems = append(check.Problems, fmt.Sprintf("%s (%d) is ahead of %s (%d)", order[i], lower, order[j], upper))
+			}
+		}
+	}
+
+	if latest, ok := resolved["latest"]; ok {
+		if pending, ok := resolved["pending"]; ok && pending > latest+1 {
+			check.Problems = append(check.Problems, fmt.Sprintf("pending (%d) is more than one block ahead of latest (%d)", pending, latest))
+		}
+	}
+
+	if err := r.checkPendingNonce(ctx, check); err != nil {
+		return check, err
+	}
+
+	for _, tag := range invalidBlockTags {
+		number, err := r.resolveTag(ctx, tag)
+		if err == nil {
+			if number != nil {
+				check.Problems = append(check.Problems, fmt.Sprintf("invalid tag %q resolved to block %d", tag, *number))
+			} else {
+				check.Problems = append(check.Problems, fmt.Sprintf("invalid tag %q returned null instead of an error", tag))
+			}
+			continue
+		}
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) {
+			return check, err
+		}
+	}
+
+	return check, nil
+}
+
+// checkPendingNonce verifies that when the client has an address, its pending
+// nonce is never behind its latest nonce, which is the minimum for pending to
+// reflect mempool state
+func (r *RPCClient) checkPendingNonce(ctx context.Context, check *TagCheck) error {
+	if r.privateKey == nil {
+		return nil
+	}
+
+	latest, err := r.client.NonceAt(ctx, r.address, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", err)
+	}
+	pending, err := r.client.PendingNonceAt(ctx, r.address)
+	if err != nil {
+		return fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+	if pending < latest {
+		check.Problems = append(check.Problems, fmt.Sprintf("pending nonce %d is behind latest nonce %d", pending, latest))
+	}
+
+	return nil
+}
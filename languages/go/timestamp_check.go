@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// IntervalStats summarizes the distribution of block intervals in seconds
+type IntervalStats struct {
+	Count     int
+	Min       uint64
+	Max       uint64
+	Mean      float64
+	P50       uint64
+	P95       uint64
+	Histogram map[uint64]int
+}
+
+// TimestampIssue describes a block whose timestamp is out of order or in the future
+type TimestampIssue struct {
+	Block     uint64
+	Timestamp uint64
+	Detail    string
+}
+
+// TimestampCheck holds the interval distribution and any timestamp violations
+type TimestampCheck struct {
+	Intervals IntervalStats
+	Issues    []TimestampIssue
+}
+
+// Passed reports whether every timestamp was monotonic and within tolerance
+func (c TimestampCheck) Passed() bool {
+	return len(c.Issues) == 0
+}
+
+// CheckTimestamps walks headers in [from, to], validates timestamp monotonicity,
+// flags timestamps more than futureTolerance ahead of the local clock, and
+// measures the block interval distribution. allowEqual permits several blocks
+// per second, as on chains with sub-second block times.
+func (r *RPCClient) CheckTimestamps(ctx context.Context, from, to uint64, futureTolerance time.Duration, allowEqual bool) (*TimestampCheck, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid block range: %d > %d", from, to)
+	}
+
+	check := &TimestampCheck{}
+	var intervals []uint64
+	var prevTime uint64
+	havePrev := false
+
+	for n := from; n <= to; n++ {
+		header, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return check, fmt.Errorf("failed to get header %d: %w", n, err)
+		}
+
+		limit := uint64(time.Now().Add(futureTolerance).Unix())
+		if header.Time > limit {
+			check.Issues = append(check.Issues, TimestampIssue{
+				Block:     n,
+				Timestamp: header.Time,
+				Detail:    fmt.Sprintf("%ds in the future", header.Time-uint64(time.Now().Unix())),
+			})
+		}
+
+		if havePrev {
+			switch {
+			case header.Time < prevTime:
+				check.Issues = append(check.Issues, TimestampIssue{Block: n, Timestamp: header.Time, Detail: fmt.Sprintf("earlier than parent timestamp %d", prevTime)})
+			case header.Time == prevTime && !allowEqual:
+				check.Issues = append(check.Issues, TimestampIssue{Block: n, Timestamp: header.Time, Detail: "equal to parent timestamp"})
+			default:
+				intervals = append(intervals, header.Time-prevTime)
+			}
+		}
+
+		prevTime = header.Time
+		havePrev = true
+	}
+
+	check.Intervals = summarizeIntervals(intervals)
+
+	return check, nil
+}
+
+// summarizeIntervals computes the distribution statistics of block intervals
+func summarizeIntervals(intervals []uint64) IntervalStats {
+	stats := IntervalStats{Count: len(intervals), Histogram: make(map[uint64]int)}
+	if len(intervals) == 0 {
+		return stats
+	}
+
+	sorted := append([]uint64(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum uint64
+	for _, v := range sorted {
+		sum += v
+		stats.Histogram[v]++
+	}
+
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = float64(sum) / float64(len(sorted))
+	stats.P50 = sorted[len(sorted)*50/100]
+	stats.P95 = sorted[(len(sorted)*95)/100]
+
+	return stats
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// callFrame is a node of the callTracer output
+type callFrame struct {
+	Type         string      `json:"type"`
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	Value        string      `json:"value"`
+	Gas          string      `json:"gas"`
+	GasUsed      string      `json:"gasUsed"`
+	Input        string      `json:"input"`
+	Output       string      `json:"output"`
+	Error        string      `json:"error"`
+	RevertReason string      `json:"revertReason"`
+	Calls        []callFrame `json:"calls"`
+}
+
+// TraceDivergence describes a structural difference between two call trees
+type TraceDivergence struct {
+	TxHash    common.Hash
+	Path      string
+	Field     string
+	Target    string
+	Reference string
+}
+
+// String formats the divergence for reports
+func (d TraceDivergence) String() string {
+	return fmt.Sprintf("%s %s.%s: target=%s reference=%s", d.TxHash.Hex(), d.Path, d.Field, d.Target, d.Reference)
+}
+
+// traceCall fetches the callTracer tree of a transaction
+func (r *RPCClient) traceCall(ctx context.Context, txHash common.Hash) (*callFrame, error) {
+	raw, err := r.CallRaw(ctx, "debug_traceTransaction", txHash, map[string]interface{}{"tracer": "callTracer"})
+	if err != nil {
+		return nil, err
+	}
+
+	var frame callFrame
+	if err := json.Unmarshal(raw, &frame); err != nil {
+		return nil, fmt.Errorf("failed to decode trace: %w", err)
+	}
+	return &frame, nil
+}
+
+// DiffTraces fetches debug_traceTransaction call trees for each transaction from
+// the differ's target and reference endpoints and reports divergences in call
+// order, gas per frame, and reverts
+func (d *Differ) DiffTraces(ctx context.Context, txHashes []common.Hash) ([]TraceDivergence, error) {
+	var divergences []TraceDivergence
+	for _, hash := range txHashes {
+		target, err := d.target.traceCall(ctx, hash)
+		if err != nil {
+			return divergences, fmt.Errorf("target: %w", err)
+		}
+		reference, err := d.reference.traceCall(ctx, hash)
+		if err != nil {
+			return divergences, fmt.Errorf("reference: %w", err)
+		}
+
+		compareFrames("root", target, reference, func(path, field, t, r string) {
+			divergences = append(divergences, TraceDivergence{TxHash: hash, Path: path, Field: field, Target: t, Reference: r})
+		})
+	}
+
+	return divergences, nil
+}
+
+// compareFrames walks two call trees in call order and reports every differing field
+func compareFrames(path string, a, b *callFrame, report func(path, field, a, b string)) {
+	fields := []struct {
+		name     string
+		a, b     string
+		quantity bool
+	}{
+		{"type", a.Type, b.Type, false},
+		{"from", a.From, b.From, false},
+		{"to", a.To, b.To, false},
+		{"value", a.Value, b.Value, true},
+		{"gas", a.Gas, b.Gas, true},
+		{"gasUsed", a.GasUsed, b.GasUsed, true},
+		{"input", a.Input, b.Input, false},
+		{"output", a.Output, b.Output, false},
+		{"error", a.Error, b.Error, false},
+		{"revertReason", a.RevertReason, b.RevertReason, false},
+	}
+	for _, f := range fields {
+		normalize := normalizeHex
+		if f.quantity {
+			normalize = normalizeQuantity
+		}
+		if normalize(strings.ToLower(f.a)) != normalize(strings.ToLower(f.b)) {
+			report(path, f.name, f.a, f.b)
+		}
+	}
+
+	if len(a.Calls) != len(b.Calls) {
+		report(path, "calls", fmt.Sprintf("%d subcalls", len(a.Calls)), fmt.Sprintf("%d subcalls", len(b.Calls)))
+	}
+	n := len(a.Calls)
+	if len(b.Calls) < n {
+		n = len(b.Calls)
+	}
+	for i := 0; i < n; i++ {
+		compareFrames(fmt.Sprintf("%s.calls[%d]", path, i), &a.Calls[i], &b.Calls[i], report)
+	}
+}
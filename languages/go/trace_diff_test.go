@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareFrames(t *testing.T) {
+	transfer := callFrame{Type: "CALL", From: "0xaa", To: "0xbb", Value: "0x10", Gas: "0x5208", GasUsed: "0x5208"}
+
+	tests := []struct {
+		name string
+		a, b callFrame
+		want []string
+	}{
+		{
+			name: "identical",
+			a:    transfer,
+			b:    transfer,
+		},
+		{
+			name: "address case",
+			a:    callFrame{Type: "CALL", To: "0xAbCd"},
+			b:    callFrame{Type: "CALL", To: "0xabcd"},
+		},
+		{
+			name: "quantity leading zeros",
+			a:    callFrame{Type: "CALL", Gas: "0x05208", Value: "0x0"},
+			b:    callFrame{Type: "CALL", Gas: "0x5208", Value: "0x000"},
+		},
+		{
+			name: "input keeps leading zeros",
+			a:    callFrame{Type: "CALL", Input: "0x00a9059cbb"},
+			b:    callFrame{Type: "CALL", Input: "0xa9059cbb"},
+			want: []string{"root.input"},
+		},
+		{
+			name: "gas used and revert",
+			a:    callFrame{Type: "CALL", GasUsed: "0x100", Error: "execution reverted", RevertReason: "nope"},
+			b:    callFrame{Type: "CALL", GasUsed: "0x200"},
+			want: []string{"root.gasUsed", "root.error", "root.revertReason"},
+		},
+		{
+			name: "subcall order",
+			a:    callFrame{Type: "CALL", Calls: []callFrame{{Type: "STATICCALL", To: "0x01"}, {Type: "CALL", To: "0x02"}}},
+			b:    callFrame{Type: "CALL", Calls: []callFrame{{Type: "CALL", To: "0x02"}, {Type: "STATICCALL", To: "0x01"}}},
+			want: []string{"root.calls[0].type", "root.calls[0].to", "root.calls[1].type", "root.calls[1].to"},
+		},
+		{
+			name: "missing subcall",
+			a:    callFrame{Type: "CALL", Calls: []callFrame{transfer, {Type: "DELEGATECALL", GasUsed: "0x1"}}},
+			b:    callFrame{Type: "CALL", Calls: []callFrame{transfer}},
+			want: []string{"root.calls"},
+		},
+		{
+			name: "nested divergence",
+			a:    callFrame{Type: "CALL", Calls: []callFrame{{Type: "CALL", Calls: []callFrame{{Type: "CREATE", Output: "0x60"}}}}},
+			b:    callFrame{Type: "CALL", Calls: []callFrame{{Type: "CALL", Calls: []callFrame{{Type: "CREATE2", Output: "0x60"}}}}},
+			want: []string{"root.calls[0].calls[0].type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			compareFrames("root", &tt.a, &tt.b, func(path, field, _, _ string) {
+				got = append(got, path+"."+field)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
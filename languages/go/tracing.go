@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// envOTLPEndpoint is the standard OpenTelemetry collector variable; setting it
+// turns tracing on just like --otlp-endpoint
+const envOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// tracerName identifies spans created by this tool
+const tracerName = "megaeth-rpc-tester"
+
+// tracerProvider is set once tracing is enabled and flushed by ShutdownTracing
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracing exports spans over OTLP/HTTP to endpoint, a collector URL such
+// as http://localhost:4318, or to the OTEL_EXPORTER_OTLP_* settings when
+// endpoint is empty
+func InitTracing(ctx context.Context, endpoint string) error {
+	var options []otlptracehttp.Option
+	if endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", tracerName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return nil
+}
+
+// ShutdownTracing flushes buffered spans; it does nothing when tracing is off
+func ShutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracerProvider.Shutdown(ctx)
+}
+
+// startSpan starts a span under any span in ctx. Without InitTracing the
+// global provider is a no-op, so callers need not check.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan marks span failed when err is set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, Redact(err.Error()))
+	}
+	span.End()
+}
+
+// tracingTransport creates a client span per JSON-RPC request, or per batch,
+// and propagates the trace context to the endpoint in the request headers
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	methods := requestMethods(req)
+	name := "jsonrpc"
+	switch {
+	case len(methods) > 1:
+		name = "jsonrpc batch"
+	case len(methods) == 1:
+		name = methods[0]
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			// Only the host: paths and query strings often carry API keys
+			attribute.String("server.address", req.URL.Hostname()),
+			attribute.Int("rpc.jsonrpc.batch_size", len(methods)),
+		),
+	)
+	defer span.End()
+	if len(methods) == 1 {
+		span.SetAttributes(attribute.String("rpc.method", methods[0]))
+	} else if len(methods) > 1 {
+		span.SetAttributes(attribute.StringSlice("rpc.methods", methods))
+	}
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, Redact(err.Error()))
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, Redact(err.Error()))
+		return resp, nil
+	}
+	if code, message, ok := responseError(body); ok {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", code))
+		span.SetStatus(codes.Error, Redact(message))
+	} else if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// requestMethods returns the JSON-RPC methods in a request body without
+// consuming it
+func requestMethods(req *http.Request) []string {
+	return payloadMethods(requestBody(req))
+}
+
+// requestBody returns a copy of a request's body, or nil if it cannot be reread
+func requestBody(req *http.Request) []byte {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// payloadMethods returns the methods of a JSON-RPC request or batch payload
+func payloadMethods(data []byte) []string {
+	var calls []rpcRequest
+	if _, err := decodeRPCMessages(data, &calls); err != nil {
+		return nil
+	}
+	methods := make([]string, len(calls))
+	for i, c := range calls {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+// responseError returns the first error in a JSON-RPC response or batch
+func responseError(body []byte) (int, string, bool) {
+	var responses []struct {
+		Error *rpcError `json:"error"`
+	}
+	if _, err := decodeRPCMessages(body, &responses); err != nil {
+		return 0, "", false
+	}
+	for _, r := range responses {
+		if r.Error != nil {
+			return r.Error.Code, r.Error.Message, true
+		}
+	}
+	return 0, "", false
+}
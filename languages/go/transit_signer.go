@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Environment variables read for Vault access, as the vault CLI names them
+const (
+	envVaultAddr      = "VAULT_ADDR"
+	envVaultToken     = "VAULT_TOKEN"
+	envVaultNamespace = "VAULT_NAMESPACE"
+	envVaultRoleID    = "VAULT_ROLE_ID"
+	envVaultSecretID  = "VAULT_SECRET_ID"
+)
+
+// transitTimeout bounds each request to Vault
+const transitTimeout = 15 * time.Second
+
+// errVaultForbidden is a 403 from Vault: a bad, expired, or underprivileged token
+var errVaultForbidden = errors.New("permission denied by Vault")
+
+// oidSecp256k1 identifies the secp256k1 curve in a public key's parameters
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+// errNotSecp256k1 is a transit public key on another curve
+var errNotSecp256k1 = errors.New("key is not on secp256k1")
+
+// TransitConfig locates a secp256k1 key in a Vault transit engine and the
+// credentials to use it. Either Token or RoleID and SecretID must be set.
+type TransitConfig struct {
+	Addr      string
+	Mount     string
+	Key       string
+	Namespace string
+	Token     string
+	RoleID    string
+	SecretID  string
+}
+
+// TransitSigner signs with a secp256k1 key held in HashiCorp Vault's transit
+// engine, so no key material is ever on the machine running the tests. Vault
+// signs the Keccak digest as a prehashed input; the recovery id, which Vault
+// does not return, is found by trying both candidates against the address.
+//
+// Stock Vault transit has no secp256k1 key type, only the NIST curves, so the
+// mount must be a transit-compatible secrets engine plugin that adds one. It
+// has to serve keys/NAME with PEM public keys and sign/NAME with prehashed
+// input and ASN.1 signatures, as the builtin engine does.
+type TransitSigner struct {
+	cfg     TransitConfig
+	http    *http.Client
+	address common.Address
+
+	// mu guards token, which is replaced when an AppRole login expires
+	mu    sync.Mutex
+	token string
+}
+
+// NewTransitSigner logs in if needed and reads the key's public key to learn
+// its address
+func NewTransitSigner(ctx context.Context, cfg TransitConfig) (*TransitSigner, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("a Vault address is required; set %s", envVaultAddr)
+	}
+	if cfg.Token == "" && (cfg.RoleID == "" || cfg.SecretID == "") {
+		return nil, fmt.Errorf("Vault credentials are required; set %s, or %s and %s", envVaultToken, envVaultRoleID, envVaultSecretID)
+	}
+	s := &TransitSigner{
+		cfg:   cfg,
+		http:  &http.Client{Timeout: transitTimeout},
+		token: cfg.Token,
+	}
+	if s.token == "" {
+		if err := s.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var key struct {
+		Type          string `json:"type"`
+		LatestVersion int    `json:"latest_version"`
+		Keys          map[string]struct {
+			PublicKey string `json:"public_key"`
+		} `json:"keys"`
+	}
+	if err := s.do(ctx, http.MethodGet, s.keyPath("keys"), nil, &key); err != nil {
+		return nil, fmt.Errorf("failed to read transit key %s: %w", cfg.Key, err)
+	}
+	version, ok := key.Keys[fmt.Sprint(key.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("transit key %s has no public key for version %d", cfg.Key, key.LatestVersion)
+	}
+	address, err := transitAddress(version.PublicKey)
+	if errors.Is(err, errNotSecp256k1) {
+		return nil, fmt.Errorf("transit key %s is of type %s, not secp256k1; stock Vault transit cannot create secp256k1 keys, so mount a transit-compatible plugin that can and point --transit-mount at it", cfg.Key, key.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transit key %s (type %s): %w", cfg.Key, key.Type, err)
+	}
+	s.address = address
+	return s, nil
+}
+
+// Address implements Signer
+func (s *TransitSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx implements Signer
+func (s *TransitSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+
+	request := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(hash[:]),
+		"prehashed":            true,
+		"hash_algorithm":       "sha2-256",
+		"marshaling_algorithm": "asn1",
+	}
+	var result struct {
+		Signature string `json:"signature"`
+	}
+	if err := s.do(ctx, http.MethodPost, s.keyPath("sign"), request, &result); err != nil {
+		return nil, fmt.Errorf("failed to sign with transit key %s: %w", s.cfg.Key, err)
+	}
+
+	// Signatures come back as vault:vN:base64(DER)
+	parts := strings.Split(result.Signature, ":")
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("unexpected transit signature %q", result.Signature)
+	}
+	der, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid transit signature encoding: %w", err)
+	}
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("invalid transit signature: %w", err)
+	}
+
+	// Ethereum only accepts the lower of the two valid s values
+	n := crypto.S256().Params().N
+	if rs.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		rs.S.Sub(n, rs.S)
+	}
+	signature := make([]byte, 65)
+	rs.R.FillBytes(signature[:32])
+	rs.S.FillBytes(signature[32:64])
+	for v := byte(0); v < 2; v++ {
+		signature[64] = v
+		pub, err := crypto.SigToPub(hash[:], signature)
+		if err == nil && crypto.PubkeyToAddress(*pub) == s.address {
+			signed, err := tx.WithSignature(signer, signature)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transit signature: %w", err)
+			}
+			return signed, nil
+		}
+	}
+	return nil, fmt.Errorf("transit signature does not recover to %s; was the key rotated?", s.address.Hex())
+}
+
+// keyPath returns the transit endpoint for an operation on the key
+func (s *TransitSigner) keyPath(op string) string {
+	return strings.Trim(s.cfg.Mount, "/") + "/" + op + "/" + url.PathEscape(s.cfg.Key)
+}
+
+// login exchanges the AppRole credentials for a token
+func (s *TransitSigner) login(ctx context.Context) error {
+	var auth struct {
+		ClientToken string `json:"client_token"`
+	}
+	body := map[string]string{"role_id": s.cfg.RoleID, "secret_id": s.cfg.SecretID}
+	if err := s.request(ctx, http.MethodPost, "auth/approle/login", "", body, &auth, "auth"); err != nil {
+		return fmt.Errorf("failed to log in to Vault with AppRole: %w", err)
+	}
+	if auth.ClientToken == "" {
+		return fmt.Errorf("Vault AppRole login returned no token")
+	}
+	RegisterSecret(auth.ClientToken)
+	s.mu.Lock()
+	s.token = auth.ClientToken
+	s.mu.Unlock()
+	return nil
+}
+
+// do calls a Vault API path with the current token, logging in again once
+// when an AppRole token has expired
+func (s *TransitSigner) do(ctx context.Context, method, path string, body, out interface{}) error {
+	s.mu.Lock()
+	token := s.token
+	s.mu.Unlock()
+
+	err := s.request(ctx, method, path, token, body, out, "data")
+	if errors.Is(err, errVaultForbidden) && s.cfg.RoleID != "" {
+		if err := s.login(ctx); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		token = s.token
+		s.mu.Unlock()
+		err = s.request(ctx, method, path, token, body, out, "data")
+	}
+	return err
+}
+
+// request sends one Vault API request and decodes the named top-level field
+// of the response into out
+func (s *TransitSigner) request(ctx context.Context, method, path, token string, body, out interface{}, field string) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode Vault request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(s.cfg.Addr, "/")+"/v1/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build Vault request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if s.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.cfg.Namespace)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Vault: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Vault response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return errVaultForbidden
+	}
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(data, &failure) == nil && len(failure.Errors) > 0 {
+			return fmt.Errorf("Vault returned %s: %s", resp.Status, strings.Join(failure.Errors, "; "))
+		}
+		return fmt.Errorf("Vault returned %s", resp.Status)
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	if err := json.Unmarshal(envelope[field], out); err != nil {
+		return fmt.Errorf("failed to decode Vault response %s: %w", field, err)
+	}
+	return nil
+}
+
+// transitAddress derives the Ethereum address of a PEM-encoded secp256k1
+// public key. The standard library cannot parse keys on this curve, so the
+// SubjectPublicKeyInfo is unpacked by hand.
+func transitAddress(publicKey string) (common.Address, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return common.Address{}, fmt.Errorf("public key is not PEM encoded")
+	}
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return common.Address{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(oidSecp256k1) {
+		return common.Address{}, errNotSecp256k1
+	}
+
+	point := info.PublicKey.Bytes
+	if len(point) == 33 {
+		pub, err := crypto.DecompressPubkey(point)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("invalid public key: %w", err)
+		}
+		return crypto.PubkeyToAddress(*pub), nil
+	}
+	pub, err := crypto.UnmarshalPubkey(point)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
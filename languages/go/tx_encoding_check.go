@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SetCodeTxType is the EIP-7702 transaction type, which the pinned go-ethereum
+// version does not decode natively
+const SetCodeTxType = 0x04
+
+// TxTypeNames maps transaction type bytes to human readable names
+var TxTypeNames = map[uint8]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "eip-2930",
+	types.DynamicFeeTxType: "eip-1559",
+	types.BlobTxType:       "eip-4844",
+	SetCodeTxType:          "eip-7702",
+}
+
+// TxEncodingMismatch describes a transaction whose returned fields do not re-encode to its hash
+type TxEncodingMismatch struct {
+	Block    uint64
+	Hash     common.Hash
+	Type     uint8
+	Computed common.Hash
+	Detail   string
+}
+
+// TxEncodingCheck summarizes the round-trip results over the sampled blocks
+type TxEncodingCheck struct {
+	Checked map[string]int
+	// Unchecked counts transactions of types with no known encoding, such as
+	// rollup deposits, by type
+	Unchecked  map[string]int
+	Mismatches []TxEncodingMismatch
+}
+
+// Passed reports whether every sampled transaction re-encoded to its hash
+func (c TxEncodingCheck) Passed() bool {
+	return len(c.Mismatches) == 0
+}
+
+// CheckTxEncoding fetches each sampled block with full transactions, re-encodes
+// every returned transaction into its canonical RLP form, recomputes the hash
+// and verifies it matches the hash reported by the endpoint. Transactions of
+// types not in TxTypeNames are counted as unchecked.
+func (r *RPCClient) CheckTxEncoding(ctx context.Context, blocks []uint64) (*TxEncodingCheck, error) {
+	check := &TxEncodingCheck{Checked: make(map[string]int), Unchecked: make(map[string]int)}
+
+	for _, n := range blocks {
+		raw, err := r.CallRaw(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(n), true)
+		if err != nil {
+			return check, err
+		}
+
+		var block struct {
+			Transactions []json.RawMessage `json:"transactions"`
+		}
+		if err := json.Unmarshal(raw, &block); err != nil {
+			return check, fmt.Errorf("failed to decode block %d: %w", n, err)
+		}
+
+		for _, txRaw := range block.Transactions {
+			var meta struct {
+				Hash common.Hash    `json:"hash"`
+				Type hexutil.Uint64 `json:"type"`
+			}
+			if err := json.Unmarshal(txRaw, &meta); err != nil {
+				return check, fmt.Errorf("failed to decode transaction in block %d: %w", n, err)
+			}
+
+			txType := uint8(meta.Type)
+			name, ok := TxTypeNames[txType]
+			if !ok {
+				check.Unchecked[fmt.Sprintf("type-0x%x", txType)]++
+				continue
+			}
+			check.Checked[name]++
+
+			computed, err := recomputeTxHash(txType, txRaw)
+			if err != nil {
+				check.Mismatches = append(check.Mismatches, TxEncodingMismatch{Block: n, Hash: meta.Hash, Type: txType, Detail: err.Error()})
+				continue
+			}
+			if computed != meta.Hash {
+				check.Mismatches = append(check.Mismatches, TxEncodingMismatch{
+					Block:    n,
+					Hash:     meta.Hash,
+					Type:     txType,
+					Computed: computed,
+					Detail:   "re-encoded hash differs",
+				})
+			}
+		}
+	}
+
+	return check, nil
+}
+
+// recomputeTxHash decodes a JSON transaction object and returns the hash of its canonical encoding
+func recomputeTxHash(txType uint8, raw json.RawMessage) (common.Hash, error) {
+	if txType == SetCodeTxType {
+		return setCodeTxHash(raw)
+	}
+
+	var tx types.Transaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return common.Hash{}, fmt.Errorf("lossy transaction object: %w", err)
+	}
+
+	return tx.Hash(), nil
+}
+
+// setCodeAuthorization is an EIP-7702 authorization tuple as returned over JSON-RPC
+type setCodeAuthorization struct {
+	ChainID hexutil.Big    `json:"chainId"`
+	Address common.Address `json:"address"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	YParity hexutil.Uint64 `json:"yParity"`
+	R       hexutil.Big    `json:"r"`
+	S       hexutil.Big    `json:"s"`
+}
+
+// setCodeTxJSON is an EIP-7702 transaction as returned over JSON-RPC
+type setCodeTxJSON struct {
+	ChainID              *hexutil.Big           `json:"chainId"`
+	Nonce                *hexutil.Uint64        `json:"nonce"`
+	MaxPriorityFeePerGas *hexutil.Big           `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big           `json:"maxFeePerGas"`
+	Gas                  *hexutil.Uint64        `json:"gas"`
+	To                   *common.Address        `json:"to"`
+	Value                *hexutil.Big           `json:"value"`
+	Input                *hexutil.Bytes         `json:"input"`
+	AccessList           types.AccessList       `json:"accessList"`
+	AuthorizationList    []setCodeAuthorization `json:"authorizationList"`
+	YParity              *hexutil.Uint64        `json:"yParity"`
+	V                    *hexutil.Big           `json:"v"`
+	R                    *hexutil.Big           `json:"r"`
+	S                    *hexutil.Big           `json:"s"`
+}
+
+// setCodeTxHash re-encodes an EIP-7702 transaction as 0x04 || rlp(fields) and hashes it
+func setCodeTxHash(raw json.RawMessage) (common.Hash, error) {
+	var dec setCodeTxJSON
+	if err := json.Unmarshal(raw, &dec); err != nil {
+		return common.Hash{}, fmt.Errorf("lossy transaction object: %w", err)
+	}
+	if dec.ChainID == nil || dec.Nonce == nil || dec.MaxPriorityFeePerGas == nil || dec.MaxFeePerGas == nil ||
+		dec.Gas == nil || dec.To == nil || dec.Value == nil || dec.Input == nil || dec.R == nil || dec.S == nil {
+		return common.Hash{}, fmt.Errorf("lossy transaction object: missing required eip-7702 field")
+	}
+
+	yParity := uint64(0)
+	switch {
+	case dec.YParity != nil:
+		yParity = uint64(*dec.YParity)
+	case dec.V != nil:
+		yParity = (*big.Int)(dec.V).Uint64()
+	default:
+		return common.Hash{}, fmt.Errorf("lossy transaction object: missing yParity")
+	}
+
+	auths := make([]interface{}, len(dec.AuthorizationList))
+	for i := range dec.AuthorizationList {
+		a := &dec.AuthorizationList[i]
+		auths[i] = []interface{}{
+			(*big.Int)(&a.ChainID), a.Address, uint64(a.Nonce), uint64(a.YParity), (*big.Int)(&a.R), (*big.Int)(&a.S),
+		}
+	}
+
+	accessList := dec.AccessList
+	if accessList == nil {
+		accessList = types.AccessList{}
+	}
+
+	payload, err := rlp.EncodeToBytes([]interface{}{
+		(*big.Int)(dec.ChainID),
+		uint64(*dec.Nonce),
+		(*big.Int)(dec.MaxPriorityFeePerGas),
+		(*big.Int)(dec.MaxFeePerGas),
+		uint64(*dec.Gas),
+		*dec.To,
+		(*big.Int)(dec.Value),
+		[]byte(*dec.Input),
+		accessList,
+		auths,
+		yParity,
+		(*big.Int)(dec.R),
+		(*big.Int)(dec.S),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode eip-7702 transaction: %w", err)
+	}
+
+	return crypto.Keccak256Hash(append([]byte{SetCodeTxType}, payload...)), nil
+}
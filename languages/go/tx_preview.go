@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// knownCalldataABI covers the calls most often sent from the CLI, so their
+// arguments can be shown without supplying an ABI
+const knownCalldataABI = `[
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+	{"type":"function","name":"approve","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}]},
+	{"type":"function","name":"transferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+	{"type":"function","name":"deposit","inputs":[]},
+	{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}]}
+]`
+
+var knownCalldata, _ = abi.JSON(strings.NewReader(knownCalldataABI))
+
+// DecodeCalldata renders calldata as a call such as transfer(to=0x.., amount=1),
+// falling back to the selector and 32-byte words when the method is unknown
+func DecodeCalldata(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if len(data) < 4 {
+		return hexutil.Encode(data)
+	}
+
+	if method, err := knownCalldata.MethodById(data[:4]); err == nil {
+		if values, err := method.Inputs.Unpack(data[4:]); err == nil {
+			args := make([]string, len(values))
+			for i, v := range values {
+				args[i] = fmt.Sprintf("%s=%v", method.Inputs[i].Name, v)
+			}
+			return fmt.Sprintf("%s(%s)", method.Name, strings.Join(args, ", "))
+		}
+	}
+
+	words := make([]string, 0, (len(data)-4+31)/32)
+	for i := 4; i < len(data); i += 32 {
+		end := i + 32
+		if end > len(data) {
+			end = len(data)
+		}
+		words = append(words, hexutil.Encode(data[i:end]))
+	}
+	return fmt.Sprintf("%s(%s)", hexutil.Encode(data[:4]), strings.Join(words, ", "))
+}
+
+// DescribeTransaction lists the fields of a built transaction for review
+// before it is sent. An unsigned transaction has no hash yet, and a legacy
+// one does not carry its chain ID, so chainID supplies it.
+func DescribeTransaction(tx *types.Transaction, chainID *big.Int, from common.Address) Record {
+	to := "(contract creation)"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	hash := "(unsigned)"
+	if isSigned(tx) {
+		hash = tx.Hash().Hex()
+	}
+
+	rec := Record{
+		{"hash", hash},
+		{"type", TxTypeNames[tx.Type()]},
+		{"chain_id", chainID.String()},
+		{"from", from.Hex()},
+		{"to", to},
+		{"nonce", tx.Nonce()},
+		{"value_wei", tx.Value().String()},
+		{"gas_limit", tx.Gas()},
+	}
+	if tx.Type() == types.LegacyTxType {
+		rec = append(rec, Field{"gas_price_wei", tx.GasPrice().String()})
+	} else {
+		rec = append(rec,
+			Field{"max_fee_per_gas_wei", tx.GasFeeCap().String()},
+			Field{"max_priority_fee_per_gas_wei", tx.GasTipCap().String()},
+		)
+	}
+
+	// The most the sender can be charged: value plus gas at the fee cap
+	maxCost := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+	maxCost.Add(maxCost, tx.Value())
+	rec = append(rec, Field{"max_cost_wei", maxCost.String()})
+
+	if len(tx.Data()) > 0 {
+		rec = append(rec, Field{"calldata", DecodeCalldata(tx.Data())})
+	}
+	return rec
+}
+
+// isSigned reports whether tx carries a signature
+func isSigned(tx *types.Transaction) bool {
+	_, r, s := tx.RawSignatureValues()
+	return r.Sign() != 0 || s.Sign() != 0
+}
+
+// Simulation is the outcome of running a transaction with eth_call and
+// eth_estimateGas instead of sending it
+type Simulation struct {
+	GasEstimate uint64
+	ReturnData  []byte
+	// Err is the revert or other failure the transaction would hit
+	Err error
+}
+
+// SimulateTransaction runs tx from the given sender against the latest state
+// without broadcasting it
+func (r *RPCClient) SimulateTransaction(ctx context.Context, tx *types.Transaction, from common.Address) Simulation {
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Value: tx.Value(), Data: tx.Data(), Gas: tx.Gas()}
+
+	var sim Simulation
+	out, err := r.client.CallContract(ctx, msg, nil)
+	if err != nil {
+		sim.Err = fmt.Errorf("call failed: %w", err)
+		return sim
+	}
+	sim.ReturnData = out
+
+	// Estimate without the built gas limit so an underpriced limit shows up
+	msg.Gas = 0
+	gas, err := r.client.EstimateGas(ctx, msg)
+	if err != nil {
+		sim.Err = fmt.Errorf("failed to estimate gas: %w", err)
+		return sim
+	}
+	sim.GasEstimate = gas
+	if gas > tx.Gas() {
+		sim.Err = fmt.Errorf("needs %d gas but the limit is %d", gas, tx.Gas())
+	}
+	return sim
+}
+
+// Record describes the simulation for output
+func (s Simulation) Record() Record {
+	status, detail := "ok", ""
+	if s.Err != nil {
+		status, detail = "would fail", Redact(s.Err.Error())
+	}
+	return Record{
+		{"simulation", status},
+		{"gas_estimate", s.GasEstimate},
+		{"return_data", hexutil.Encode(s.ReturnData)},
+		{"detail", detail},
+	}
+}
+
+// ReceiptStatus is a progress update while waiting for a receipt
+type ReceiptStatus struct {
+	Elapsed time.Duration
+	// State is "pending" while the node knows the transaction, "unknown" when
+	// it does not, and "included" once a receipt exists
+	State   string
+	Receipt *types.Receipt
+}
+
+// WaitForReceipt polls until the transaction is included, reporting each
+// poll through onStatus
+func (r *RPCClient) WaitForReceipt(ctx context.Context, hash common.Hash, interval time.Duration, onStatus func(ReceiptStatus)) (*types.Receipt, error) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := r.client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			onStatus(ReceiptStatus{Elapsed: time.Since(start), State: "included", Receipt: receipt})
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
+		}
+
+		state := "unknown"
+		if _, pending, err := r.client.TransactionByHash(ctx, hash); err == nil && pending {
+			state = "pending"
+		}
+		onStatus(ReceiptStatus{Elapsed: time.Since(start), State: state})
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for receipt: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// vaultVersion is the current key vault file format
+const vaultVersion = 1
+
+// Scrypt cost parameters for vault passwords, matching the keystore's
+// standard and light settings
+const (
+	vaultScryptN      = 1 << 18
+	vaultLightScryptN = 1 << 12
+	vaultScryptR      = 8
+	vaultScryptP      = 1
+)
+
+// vaultKDF records how the vault's encryption key was derived from the password
+type vaultKDF struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// KeyVault is an encrypted pool of load-test keys. The private keys are
+// sealed with XChaCha20-Poly1305 under a scrypt-derived key; the addresses
+// stay readable, and are authenticated with the keys, so the accounts can be
+// listed and their balances checked without the password.
+type KeyVault struct {
+	Version    int      `json:"version"`
+	KDF        vaultKDF `json:"kdf"`
+	Cipher     string   `json:"cipher"`
+	Nonce      string   `json:"nonce"`
+	Ciphertext string   `json:"ciphertext"`
+	Addresses  []string `json:"addresses"`
+}
+
+// SealVault encrypts keys under password. light trades brute-force
+// resistance for a faster unlock.
+func SealVault(keys []*ecdsa.PrivateKey, password string, light bool) (*KeyVault, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	kdf := vaultKDF{Name: "scrypt", N: vaultScryptN, R: vaultScryptR, P: vaultScryptP, Salt: hex.EncodeToString(salt)}
+	if light {
+		kdf.N = vaultLightScryptN
+	}
+	aead, err := kdf.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+
+	vault := &KeyVault{Version: vaultVersion, KDF: kdf, Cipher: "xchacha20-poly1305"}
+	plaintext := make([]string, len(keys))
+	for i, key := range keys {
+		plaintext[i] = hex.EncodeToString(crypto.FromECDSA(key))
+		vault.Addresses = append(vault.Addresses, crypto.PubkeyToAddress(key.PublicKey).Hex())
+	}
+	data, err := json.Marshal(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode keys: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	vault.Nonce = hex.EncodeToString(nonce)
+	vault.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, data, vault.associatedData()))
+	return vault, nil
+}
+
+// cipher derives the vault key from password
+func (k vaultKDF) cipher(password string) (cipher.AEAD, error) {
+	if k.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported vault KDF %q", k.Name)
+	}
+	salt, err := hex.DecodeString(k.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault salt: %w", err)
+	}
+	key, err := scrypt.Key([]byte(password), salt, k.N, k.R, k.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault key: %w", err)
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// associatedData binds the plaintext address list to the sealed keys
+func (v *KeyVault) associatedData() []byte {
+	return []byte(strings.Join(v.Addresses, ","))
+}
+
+// Unlock decrypts the vault's keys. A wrong password and a tampered file
+// fail the same way.
+func (v *KeyVault) Unlock(password string) ([]*ecdsa.PrivateKey, error) {
+	if v.Version != vaultVersion {
+		return nil, fmt.Errorf("unsupported vault version %d", v.Version)
+	}
+	aead, err := v.KDF.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(v.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid vault nonce")
+	}
+	ciphertext, err := hex.DecodeString(v.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault ciphertext: %w", err)
+	}
+	data, err := aead.Open(nil, nonce, ciphertext, v.associatedData())
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock vault: wrong password or corrupted file")
+	}
+	defer clear(data)
+
+	var encoded []string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode vault keys: %w", err)
+	}
+	if len(encoded) != len(v.Addresses) {
+		return nil, fmt.Errorf("vault holds %d keys for %d addresses", len(encoded), len(v.Addresses))
+	}
+	keys := make([]*ecdsa.PrivateKey, len(encoded))
+	for i, e := range encoded {
+		key, err := crypto.HexToECDSA(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d in vault: %w", i, err)
+		}
+		if crypto.PubkeyToAddress(key.PublicKey) != common.HexToAddress(v.Addresses[i]) {
+			return nil, fmt.Errorf("vault key %d does not match address %s", i, v.Addresses[i])
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// Accounts unlocks the vault and returns its keys as accounts, with paths
+// of the form vault[i]
+func (v *KeyVault) Accounts(password string) ([]HDAccount, error) {
+	keys, err := v.Unlock(password)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]HDAccount, len(keys))
+	for i, key := range keys {
+		accounts[i] = HDAccount{
+			Path:       fmt.Sprintf("vault[%d]", i),
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: key,
+		}
+	}
+	return accounts, nil
+}
+
+// GrowVault adds n random keys to the vault at path, creating it if needed,
+// and returns them as accounts. An existing vault keeps its password and
+// strength; light applies only to a new one.
+func GrowVault(path, password string, n int, light bool) ([]HDAccount, error) {
+	var keys []*ecdsa.PrivateKey
+	existing, err := LoadVault(path)
+	switch {
+	case err == nil:
+		if keys, err = existing.Unlock(password); err != nil {
+			return nil, err
+		}
+		light = existing.KDF.N < vaultScryptN
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	first := len(keys)
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	vault, err := SealVault(keys, password, light)
+	if err != nil {
+		return nil, err
+	}
+	if err := vault.Save(path); err != nil {
+		return nil, err
+	}
+
+	added := make([]HDAccount, 0, n)
+	for i := first; i < len(keys); i++ {
+		added = append(added, HDAccount{
+			Path:       fmt.Sprintf("vault[%d]", i),
+			Address:    crypto.PubkeyToAddress(keys[i].PublicKey),
+			PrivateKey: keys[i],
+		})
+	}
+	return added, nil
+}
+
+// LoadVault reads a vault file
+func LoadVault(path string) (*KeyVault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+	var vault KeyVault
+	if err := json.Unmarshal(data, &vault); err != nil {
+		return nil, fmt.Errorf("failed to parse vault %s: %w", path, err)
+	}
+	return &vault, nil
+}
+
+// Save writes the vault readable only by the owner, replacing the file
+// atomically so an interrupted save cannot lose keys
+func (v *KeyVault) Save(path string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vault: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testKeys generates n random keys
+func testKeys(t *testing.T, n int) []*ecdsa.PrivateKey {
+	t.Helper()
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	keys := testKeys(t, 3)
+	vault, err := SealVault(keys, "hunter2", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "pool.json")
+	if err := vault.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadVault(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loaded.Accounts("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != len(keys) {
+		t.Fatalf("got %d accounts, want %d", len(accounts), len(keys))
+	}
+	for i, account := range accounts {
+		if !account.PrivateKey.Equal(keys[i]) {
+			t.Errorf("account %d: key differs after the round trip", i)
+		}
+		if account.Address != crypto.PubkeyToAddress(keys[i].PublicKey) {
+			t.Errorf("account %d: address %s", i, account.Address.Hex())
+		}
+		if loaded.Addresses[i] != account.Address.Hex() {
+			t.Errorf("account %d: listed address %s, want %s", i, loaded.Addresses[i], account.Address.Hex())
+		}
+	}
+}
+
+func TestVaultWrongPassword(t *testing.T) {
+	vault, err := SealVault(testKeys(t, 1), "hunter2", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.Unlock("hunter3"); err == nil {
+		t.Error("unlocked with the wrong password")
+	}
+}
+
+func TestVaultTamperedAddresses(t *testing.T) {
+	keys := testKeys(t, 2)
+	vault, err := SealVault(keys, "hunter2", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The address list is authenticated with the keys
+	vault.Addresses[0], vault.Addresses[1] = vault.Addresses[1], vault.Addresses[0]
+	if _, err := vault.Unlock("hunter2"); err == nil {
+		t.Error("unlocked a vault with a tampered address list")
+	}
+}
+
+func TestGrowVault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.json")
+	first, err := GrowVault(path, "hunter2", 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, err := GrowVault(path, "hunter2", 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 3 || added[0].Path != "vault[2]" {
+		t.Fatalf("second grow added %d accounts starting at %s", len(added), added[0].Path)
+	}
+
+	vault, err := LoadVault(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An existing vault keeps its strength
+	if vault.KDF.N != vaultLightScryptN {
+		t.Errorf("scrypt N %d, want %d", vault.KDF.N, vaultLightScryptN)
+	}
+	accounts, err := vault.Accounts("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := append(first, added...)
+	if len(accounts) != len(all) {
+		t.Fatalf("vault holds %d accounts, want %d", len(accounts), len(all))
+	}
+	for i, account := range accounts {
+		if account.Address != all[i].Address || account.Path != all[i].Path {
+			t.Errorf("account %d: got %s at %s, want %s at %s", i, account.Address.Hex(), account.Path, all[i].Address.Hex(), all[i].Path)
+		}
+	}
+
+	if _, err := GrowVault(path, "hunter3", 1, true); err == nil {
+		t.Error("grew the vault with the wrong password")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+)
+
+// ResubscribeCheck holds the outcome of the WebSocket disconnect/resubscription test
+type ResubscribeCheck struct {
+	OldSubscription  string
+	NewSubscription  string
+	HeadsBefore      int
+	HeadsAfter       int
+	Backfilled       int
+	DuplicateHeads   []common.Hash
+	MissingHeads     []uint64
+	OldSubStillValid bool
+	Problems         []string
+}
+
+// Passed reports whether resubscription from the checkpoint was gap- and duplicate-free
+func (c ResubscribeCheck) Passed() bool {
+	return len(c.Problems) == 0 && len(c.DuplicateHeads) == 0 && len(c.MissingHeads) == 0 && !c.OldSubStillValid
+}
+
+// wsHead is the subset of a newHeads notification the test tracks
+type wsHead struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+}
+
+// wsMessage is either a call response or a subscription notification
+type wsMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	Params *struct {
+		Subscription string `json:"subscription"`
+		Result       wsHead `json:"result"`
+	} `json:"params"`
+}
+
+// wsSession is a raw JSON-RPC WebSocket connection; the test needs subscription
+// ids and abrupt disconnects, which the go-ethereum client hides
+type wsSession struct {
+	conn   *websocket.Conn
+	nextID int
+}
+
+func dialWS(ctx context.Context, url string, header http.Header) (*wsSession, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial websocket: %w", err)
+	}
+	return &wsSession{conn: conn}, nil
+}
+
+// call sends a request and waits for its response, discarding notifications
+func (s *wsSession) call(method string, timeout time.Duration, params ...interface{}) (json.RawMessage, error) {
+	s.nextID++
+	id := s.nextID
+	if err := s.conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return nil, fmt.Errorf("failed to read %s response: %w", method, err)
+		}
+		if msg.ID == nil || *msg.ID != id {
+			continue
+		}
+		if msg.Error != nil {
+			return nil, fmt.Errorf("%s failed: %d %s", method, msg.Error.Code, msg.Error.Message)
+		}
+		return msg.Result, nil
+	}
+}
+
+// readHeads reads newHeads notifications for subscription until count heads arrive
+func (s *wsSession) readHeads(subscription string, count int, timeout time.Duration) ([]wsHead, error) {
+	heads := make([]wsHead, 0, count)
+	deadline := time.Now().Add(timeout)
+	for len(heads) < count {
+		if err := s.conn.SetReadDeadline(deadline); err != nil {
+			return heads, err
+		}
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return heads, fmt.Errorf("stopped after %d of %d heads: %w", len(heads), count, err)
+		}
+		if msg.Params == nil {
+			continue
+		}
+		if msg.Params.Subscription != subscription {
+			return heads, fmt.Errorf("notification for unknown subscription %s", msg.Params.Subscription)
+		}
+		heads = append(heads, msg.Params.Result)
+	}
+	return heads, nil
+}
+
+// subscribeHeads opens a newHeads subscription and returns its id
+func (s *wsSession) subscribeHeads(timeout time.Duration) (string, error) {
+	raw, err := s.call("eth_subscribe", timeout, "newHeads")
+	if err != nil {
+		return "", err
+	}
+	var id string
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return "", fmt.Errorf("invalid subscription id %s", raw)
+	}
+	return id, nil
+}
+
+// CheckResubscription subscribes to newHeads over wsURL, receives heads, drops
+// the connection without unsubscribing, reconnects, and verifies the old
+// subscription id is no longer valid and that resubscribing from the last
+// seen head as a checkpoint yields no duplicate or missing heads
+func (r *RPCClient) CheckResubscription(ctx context.Context, wsURL string, heads int, timeout time.Duration) (*ResubscribeCheck, error) {
+	if heads <= 0 {
+		return nil, fmt.Errorf("head count must be positive")
+	}
+
+	check := &ResubscribeCheck{}
+
+	first, err := dialWS(ctx, wsURL, r.headers)
+	if err != nil {
+		return nil, err
+	}
+	check.OldSubscription, err = first.subscribeHeads(timeout)
+	if err != nil {
+		first.conn.Close()
+		return nil, err
+	}
+	before, err := first.readHeads(check.OldSubscription, heads, timeout)
+	check.HeadsBefore = len(before)
+
+	// Drop the TCP connection without a close handshake, as a network failure would
+	first.conn.UnderlyingConn().Close()
+	if err != nil {
+		return check, err
+	}
+
+	second, err := dialWS(ctx, wsURL, r.headers)
+	if err != nil {
+		return check, err
+	}
+	defer second.conn.Close()
+
+	raw, err := second.call("eth_unsubscribe", timeout, check.OldSubscription)
+	if err == nil {
+		var ok bool
+		if json.Unmarshal(raw, &ok) == nil && ok {
+			check.OldSubStillValid = true
+		}
+	}
+
+	check.NewSubscription, err = second.subscribeHeads(timeout)
+	if err != nil {
+		return check, err
+	}
+	if check.NewSubscription == check.OldSubscription {
+		check.Problems = append(check.Problems, "new subscription reused the dropped subscription id")
+	}
+
+	after, err := second.readHeads(check.NewSubscription, heads, timeout)
+	check.HeadsAfter = len(after)
+	if err != nil {
+		return check, err
+	}
+
+	seen := make(map[common.Hash]bool)
+	for _, h := range before {
+		seen[h.Hash] = true
+	}
+	for _, h := range after {
+		if seen[h.Hash] {
+			check.DuplicateHeads = append(check.DuplicateHeads, h.Hash)
+		}
+		seen[h.Hash] = true
+	}
+
+	// Backfill from the checkpoint over plain RPC and verify the stitched chain links up
+	checkpoint := before[len(before)-1]
+	resumed := after[0]
+	prev := blockSummary{Number: checkpoint.Number, Hash: checkpoint.Hash}
+	for n := uint64(checkpoint.Number) + 1; n < uint64(resumed.Number); n++ {
+		block, err := r.getBlockSummary(ctx, "eth_getBlockByNumber", hexutil.EncodeUint64(n))
+		if err != nil {
+			return check, err
+		}
+		if block == nil {
+			check.MissingHeads = append(check.MissingHeads, n)
+			continue
+		}
+		if block.ParentHash != prev.Hash {
+			check.Problems = append(check.Problems, fmt.Sprintf("backfilled block %d does not link to %s", n, prev.Hash.Hex()))
+		}
+		check.Backfilled++
+		prev = *block
+	}
+	if uint64(resumed.Number) > uint64(checkpoint.Number) && resumed.ParentHash != prev.Hash {
+		check.Problems = append(check.Problems, fmt.Sprintf("first head after resubscribing (%d) does not link to the backfilled chain", uint64(resumed.Number)))
+	}
+
+	return check, nil
+}